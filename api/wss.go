@@ -38,11 +38,10 @@ func (h *Handler) ConnectWSS(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error preparing request", http.StatusInternalServerError)
 		return
 	}
-	h.instance.Wsc.SignedHeader = headers
+	h.instance.Wsc.SetSignedHeader(headers)
 	if h.instance.Wsc.IsWebSocketClosed() {
-		h.instance.Wsc.Connect()
-		if h.instance.Wsc.IsWebSocketClosed() {
-			http.Error(w, h.instance.Wsc.Error, http.StatusInternalServerError)
+		if err := h.instance.Wsc.ConnectE(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 		} else {
 			w.Write([]byte("Connected!"))
 		}
@@ -0,0 +1,51 @@
+package boilingdata
+
+import (
+	"time"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// AuditRecord describes one SQL query executed through an Instance. SQL is
+// passed through the redactor set with WithRedactor.
+type AuditRecord struct {
+	User      string
+	RequestID string
+	SQL       string
+	Start     time.Time
+	Duration  time.Duration
+	Rows      int
+	Err       error
+}
+
+// OnAudit registers fn to be called with an AuditRecord for every SQL query,
+// including those answered from the result cache or shared with an
+// identical in-flight query.
+func (instance *Instance) OnAudit(fn func(record AuditRecord)) {
+	instance.hooks.mu.Lock()
+	defer instance.hooks.mu.Unlock()
+	instance.hooks.onAudit = append(instance.hooks.onAudit, fn)
+}
+
+// audit reports the query of payload, started at start, to the audit hooks.
+func (instance *Instance) audit(payload message.Payload, start time.Time, response *message.Response, err error) {
+	instance.hooks.mu.RLock()
+	defer instance.hooks.mu.RUnlock()
+	if len(instance.hooks.onAudit) == 0 {
+		return
+	}
+	record := AuditRecord{
+		User:      instance.Auth.userName,
+		RequestID: payload.RequestID,
+		SQL:       instance.redactor.Redact(payload.SQL),
+		Start:     start,
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+	if err == nil && response != nil {
+		record.Rows = response.RowCount()
+	}
+	for _, fn := range instance.hooks.onAudit {
+		fn(record)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -18,6 +19,8 @@ import (
 	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
 	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
 	"github.com/boilingdata/go-boilingdata/constants"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+	"golang.org/x/oauth2"
 )
 
 type AwsCredentials struct {
@@ -25,21 +28,87 @@ type AwsCredentials struct {
 	SecretAccessKey string
 	SessionToken    string
 	CredentialScope string
+	// Expiration is when the credentials expire, when known.
+	Expiration time.Time
 }
 
 type Auth struct {
 	userName                        string
-	password                        string
+	credentials                     CredentialsProvider
+	impersonation                   *impersonation
+	federation                      *federation
+	tokenSource                     oauth2.TokenSource
 	authResult                      *cognitoidentityprovider.AuthenticationResultType
 	timeWhenLastJwtTokenWasRecieved time.Time
+	httpClient                      *http.Client
+	endpoint                        string
+	region                          string
+	onRefresh                       func()
+	userAgent                       string
+	awsCredentials                  *AwsCredentials
+	credentialsMu                   sync.Mutex
+	// signIn coalesces concurrent Authenticate calls.
+	signIn flight[string]
+	hooks  authHooks
+}
+
+// wssURL returns the websocket endpoint the signature is created for.
+func (auth *Auth) wssURL() string {
+	if auth.endpoint != "" {
+		return auth.endpoint
+	}
+	return constants.WssUrl
+}
+
+// signingRegion returns the region of the websocket endpoint.
+func (auth *Auth) signingRegion() string {
+	if auth.region != "" {
+		return auth.region
+	}
+	return constants.Region
+}
+
+// SetHTTPClient sets the client used for the Cognito calls. A nil client
+// restores the default transport.
+func (auth *Auth) SetHTTPClient(client *http.Client) {
+	auth.httpClient = client
+}
+
+// client returns the HTTP client for auth and API calls. Its requests carry
+// the client identification headers.
+func (auth *Auth) client() *http.Client {
+	base := http.DefaultClient
+	if auth.httpClient != nil {
+		base = auth.httpClient
+	}
+	client := *base
+	client.Transport = &identifyingTransport{base: base.Transport, userAgent: auth.userAgent}
+	return &client
+}
+
+// identifyingTransport adds the client identification headers to requests.
+type identifyingTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *identifyingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	req = req.Clone(req.Context())
+	wsclient.SetClientHeaders(req.Header, t.userAgent)
+	return base.RoundTrip(req)
 }
 
 func (s *Auth) GetSignedWssHeader(token string) (http.Header, error) {
-	creds, err := GetAwsCredentialss(token)
+	creds, err := getAwsCredentials(token, s.client())
 	if err != nil {
 		return nil, err
 	}
-	header, err := getSignedHeaders(creds)
+	s.setAWSCredentials(creds)
+	header, err := getSignedHeaders(creds, s.wssURL(), s.signingRegion())
 	if err != nil {
 		log.Printf("Error getting singned url headers: " + err.Error())
 		return nil, err
@@ -53,7 +122,7 @@ func (s *Auth) GetSignedWssUrl(headers http.Header) (string, error) {
 		log.Printf("Error Extracting Credential and Signature: " + err.Error())
 		return "", err
 	}
-	signedUrl := constants.WssUrl + "?" + fmt.Sprintf(constants.SignWrlFormat, url.QueryEscape(credential)+"&",
+	signedUrl := s.wssURL() + "?" + fmt.Sprintf(constants.SignWrlFormat, url.QueryEscape(credential)+"&",
 		url.QueryEscape(headers["X-Amz-Date"][0])+"&", url.QueryEscape(headers["X-Amz-Security-Token"][0])+"&", url.QueryEscape(signature))
 	return signedUrl, nil
 }
@@ -80,9 +149,13 @@ func extractCredentialAndSignature(header string) (string, string, error) {
 }
 
 func GetAwsCredentialss(jwtIdToken string) (AwsCredentials, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(constants.Region))
+	return getAwsCredentials(jwtIdToken, (&Auth{}).client())
+}
+
+func getAwsCredentials(jwtIdToken string, httpClient *http.Client) (AwsCredentials, error) {
+	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithRegion(constants.Region), config.WithHTTPClient(httpClient))
 	if err != nil {
-		return AwsCredentials{}, fmt.Errorf("failed to load configuration, %v", err)
+		return AwsCredentials{}, fmt.Errorf("failed to load configuration, %w", err)
 	}
 	cognitoClient := cognitoidentity.NewFromConfig(cfg)
 
@@ -114,6 +187,9 @@ func GetAwsCredentialss(jwtIdToken string) (AwsCredentials, error) {
 		SecretAccessKey: *credRes.Credentials.SecretKey,
 		SessionToken:    *credRes.Credentials.SessionToken,
 	}
+	if credRes.Credentials.Expiration != nil {
+		awsCreds.Expiration = *credRes.Credentials.Expiration
+	}
 
 	return awsCreds, nil
 }
@@ -158,16 +234,15 @@ func (auth *Auth) GetAWSSingingHeaders(urlString string) (http.Header, error) {
 	return headers, nil
 }
 
-func getSignedHeaders(creds AwsCredentials) (http.Header, error) {
+func getSignedHeaders(creds AwsCredentials, wsURL string, region string) (http.Header, error) {
 	// Create a signer with the given AWS credentials
 	signer := v4.NewSigner(credentials.NewStaticCredentials(creds.AccessKeyId, creds.SecretAccessKey, creds.SessionToken))
-	wsURL := constants.WssUrl
 	req, err := http.NewRequest("GET", wsURL, nil)
 	if err != nil {
 		return nil, err
 	}
 	// Sign the request
-	_, err = signer.Sign(req, nil, constants.Service, constants.Region, time.Now())
+	_, err = signer.Sign(req, nil, constants.Service, region, time.Now())
 	if err != nil {
 		log.Println("Error signing request:", err)
 		return nil, nil
@@ -176,10 +251,51 @@ func getSignedHeaders(creds AwsCredentials) (http.Header, error) {
 	return req.Header, err
 }
 
+// Authenticate returns a valid ID token, refreshing it or logging in when
+// needed. Concurrent calls share a single attempt and its result or error.
+// The hooks of the attempt run once it is over, so they may call
+// Authenticate themselves.
 func (auth *Auth) Authenticate() (string, error) {
+	var events authEvents
+	token, err := auth.signIn.do(func() (string, error) {
+		return auth.authenticate(&events)
+	})
+	if events.refreshed && auth.onRefresh != nil {
+		auth.onRefresh()
+	}
+	auth.hooks.fire(events)
+	return token, err
+}
+
+// authenticate runs one attempt, recording what happened in events.
+func (auth *Auth) authenticate(events *authEvents) (token string, err error) {
 	muLock.Lock()
 	defer muLock.Unlock()
-	if auth.userName == "" || auth.password == "" {
+	events.expired = auth.IsUserLoggedIn() && auth.IsTokenExpired()
+	previous := auth.authResult
+	defer func() {
+		events.refreshed = auth.authResult != nil && auth.authResult != previous
+		events.failed = err
+	}()
+	if auth.impersonation != nil {
+		if auth.IsUserLoggedIn() && !auth.IsTokenExpired() {
+			return *auth.authResult.IdToken, nil
+		}
+		return auth.exchangeToken()
+	}
+	if auth.federation != nil {
+		if auth.IsUserLoggedIn() && !auth.IsTokenExpired() {
+			return *auth.authResult.IdToken, nil
+		}
+		return auth.federatedToken()
+	}
+	if auth.tokenSource != nil {
+		if auth.IsUserLoggedIn() && !auth.IsTokenExpired() {
+			return *auth.authResult.IdToken, nil
+		}
+		return auth.sourceToken()
+	}
+	if auth.userName == "" || auth.credentials == nil {
 		return "", fmt.Errorf("UnAuthorized")
 	}
 	var authInput *cognitoidentityprovider.InitiateAuthInput
@@ -197,12 +313,19 @@ func (auth *Auth) Authenticate() (string, error) {
 		}
 	} else {
 		log.Println("Logging in..")
+		creds, err := auth.credentials.Credentials()
+		if err != nil {
+			return "", fmt.Errorf("Error getting credentials: %w", err)
+		}
+		if creds.UserName == "" {
+			creds.UserName = auth.userName
+		}
 		// Authenticate user
 		authInput = &cognitoidentityprovider.InitiateAuthInput{
 			AuthFlow: aws.String("USER_PASSWORD_AUTH"),
 			AuthParameters: map[string]*string{
-				"USERNAME": aws.String(auth.userName),
-				"PASSWORD": aws.String(auth.password),
+				"USERNAME": aws.String(creds.UserName),
+				"PASSWORD": aws.String(creds.Password),
 				"POOL_ID":  aws.String(constants.PoolID),
 			},
 			ClientId: aws.String(constants.ClientID),
@@ -210,8 +333,9 @@ func (auth *Auth) Authenticate() (string, error) {
 	}
 	//
 	sess, err := session.NewSession(&aws.Config{
-		Region: aws.String(constants.Region)},
-	)
+		Region:     aws.String(constants.Region),
+		HTTPClient: auth.client(),
+	})
 	if err != nil {
 		return "", err
 	}
@@ -220,6 +344,9 @@ func (auth *Auth) Authenticate() (string, error) {
 	//
 	if err != nil {
 		log.Println("Login unsucessful, ->" + err.Error())
+		if provider, ok := auth.credentials.(InvalidatingProvider); ok && aws.StringValue(authInput.AuthFlow) == "USER_PASSWORD_AUTH" {
+			provider.Invalidate()
+		}
 		auth.authResult = nil
 		RemoveUser(auth.userName)
 		return "", err
@@ -228,6 +355,9 @@ func (auth *Auth) Authenticate() (string, error) {
 	auth.authResult = authOutput.AuthenticationResult
 	// Handle MFA challenges if required
 	if authOutput.ChallengeName != nil {
+		if strings.HasSuffix(*authOutput.ChallengeName, "_MFA") {
+			events.mfa = *authOutput.ChallengeName
+		}
 		switch *authOutput.ChallengeName {
 		case "SMS_MFA":
 			mfaCode, err := promptMFA("Please enter MFA (sms)")
@@ -314,3 +444,44 @@ func completeNewPasswordChallenge(client *cognitoidentityprovider.CognitoIdentit
 	// we might need additional logic here
 	return "", nil
 }
+
+// AWSCredentials returns the temporary AWS credentials of the Cognito
+// identity pool identity, the ones the websocket connection is signed with,
+// so they can be reused for other AWS calls such as reading the queried S3
+// data. They are fetched again, logging in first if needed, when they expire
+// within constants.RotationMargin.
+func (auth *Auth) AWSCredentials() (AwsCredentials, error) {
+	auth.credentialsMu.Lock()
+	creds := auth.awsCredentials
+	auth.credentialsMu.Unlock()
+	if creds != nil && (creds.Expiration.IsZero() || time.Until(creds.Expiration) > constants.RotationMargin) {
+		return *creds, nil
+	}
+	idToken, err := auth.Authenticate()
+	if err != nil {
+		return AwsCredentials{}, fmt.Errorf("Error : %w", err)
+	}
+	fresh, err := getAwsCredentials(idToken, auth.client())
+	if err != nil {
+		return AwsCredentials{}, err
+	}
+	auth.setAWSCredentials(fresh)
+	return fresh, nil
+}
+
+// expire makes the next Authenticate refresh the token, and the next
+// signature fetch new AWS credentials.
+func (auth *Auth) expire() {
+	muLock.Lock()
+	auth.timeWhenLastJwtTokenWasRecieved = time.Time{}
+	muLock.Unlock()
+	auth.credentialsMu.Lock()
+	auth.awsCredentials = nil
+	auth.credentialsMu.Unlock()
+}
+
+func (auth *Auth) setAWSCredentials(creds AwsCredentials) {
+	auth.credentialsMu.Lock()
+	defer auth.credentialsMu.Unlock()
+	auth.awsCredentials = &creds
+}
@@ -0,0 +1,80 @@
+package boilingdata
+
+import "sync"
+
+// authHooks holds the authentication callbacks registered on an Auth. They
+// run after the attempt that triggered them, without holding any lock.
+type authHooks struct {
+	mu          sync.RWMutex
+	onRefreshed []func()
+	onExpired   []func()
+	onFailed    []func(err error)
+	onMFA       []func(challenge string)
+}
+
+// authEvents records what happened during one authentication attempt.
+type authEvents struct {
+	refreshed bool
+	expired   bool
+	failed    error
+	mfa       string
+}
+
+// OnTokenRefreshed registers fn to be called whenever a new ID token is
+// obtained, by login, refresh or token exchange.
+func (auth *Auth) OnTokenRefreshed(fn func()) {
+	auth.hooks.mu.Lock()
+	defer auth.hooks.mu.Unlock()
+	auth.hooks.onRefreshed = append(auth.hooks.onRefreshed, fn)
+}
+
+// OnTokenExpired registers fn to be called when a token is found expired
+// and has to be refreshed or replaced.
+func (auth *Auth) OnTokenExpired(fn func()) {
+	auth.hooks.mu.Lock()
+	defer auth.hooks.mu.Unlock()
+	auth.hooks.onExpired = append(auth.hooks.onExpired, fn)
+}
+
+// OnLoginFailed registers fn to be called with the error of every failed
+// login, refresh or token exchange, so credential problems can be alerted
+// on before queries start failing.
+func (auth *Auth) OnLoginFailed(fn func(err error)) {
+	auth.hooks.mu.Lock()
+	defer auth.hooks.mu.Unlock()
+	auth.hooks.onFailed = append(auth.hooks.onFailed, fn)
+}
+
+// OnMFARequired registers fn to be called with the challenge name, such as
+// SMS_MFA or SOFTWARE_TOKEN_MFA, after a login that asked for a second
+// factor, whether or not the code was accepted.
+func (auth *Auth) OnMFARequired(fn func(challenge string)) {
+	auth.hooks.mu.Lock()
+	defer auth.hooks.mu.Unlock()
+	auth.hooks.onMFA = append(auth.hooks.onMFA, fn)
+}
+
+func (h *authHooks) fire(events authEvents) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if events.expired {
+		for _, fn := range h.onExpired {
+			fn()
+		}
+	}
+	if events.mfa != "" {
+		for _, fn := range h.onMFA {
+			fn(events.mfa)
+		}
+	}
+	if events.failed != nil {
+		for _, fn := range h.onFailed {
+			fn(events.failed)
+		}
+	}
+	if events.refreshed {
+		for _, fn := range h.onRefreshed {
+			fn()
+		}
+	}
+}
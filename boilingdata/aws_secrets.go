@@ -0,0 +1,166 @@
+package boilingdata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/secretsmanager/secretsmanageriface"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/aws/aws-sdk-go/service/ssm/ssmiface"
+)
+
+// InvalidatingProvider is a CredentialsProvider that caches what it fetches.
+// Auth calls Invalidate when a password login is rejected, so credentials
+// rotated since the last fetch are picked up by the next login.
+type InvalidatingProvider interface {
+	CredentialsProvider
+	Invalidate()
+}
+
+// SecretsManagerCredentialsProvider reads credentials from an AWS Secrets
+// Manager secret holding {"username": "...", "password": "..."}.
+//
+// Fetched credentials are reused for RefreshInterval; zero fetches on every
+// login. Client defaults to a client for Region built from the shared AWS
+// configuration.
+type SecretsManagerCredentialsProvider struct {
+	SecretID        string
+	Region          string
+	RefreshInterval time.Duration
+	Client          secretsmanageriface.SecretsManagerAPI
+
+	cache credentialsCache
+}
+
+func (p *SecretsManagerCredentialsProvider) Credentials() (Credentials, error) {
+	return p.cache.get(p.RefreshInterval, func() (Credentials, error) {
+		client := p.Client
+		if client == nil {
+			sess, err := awsSession(p.Region)
+			if err != nil {
+				return Credentials{}, err
+			}
+			client = secretsmanager.New(sess)
+		}
+		output, err := client.GetSecretValue(&secretsmanager.GetSecretValueInput{SecretId: aws.String(p.SecretID)})
+		if err != nil {
+			return Credentials{}, fmt.Errorf("Error reading secret %s: %w", p.SecretID, err)
+		}
+		var secret struct {
+			UserName string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := json.Unmarshal([]byte(aws.StringValue(output.SecretString)), &secret); err != nil {
+			return Credentials{}, fmt.Errorf("Error parsing secret %s: %w", p.SecretID, err)
+		}
+		return Credentials{UserName: secret.UserName, Password: secret.Password}, nil
+	})
+}
+
+// Invalidate drops the cached credentials.
+func (p *SecretsManagerCredentialsProvider) Invalidate() {
+	p.cache.invalidate()
+}
+
+// SSMCredentialsProvider reads credentials from SSM Parameter Store. The
+// password parameter is decrypted, so it may be a SecureString. Without
+// UserNameParameter the username of the Instance is used.
+//
+// RefreshInterval, Region and Client behave as for
+// SecretsManagerCredentialsProvider.
+type SSMCredentialsProvider struct {
+	UserNameParameter string
+	PasswordParameter string
+	Region            string
+	RefreshInterval   time.Duration
+	Client            ssmiface.SSMAPI
+
+	cache credentialsCache
+}
+
+func (p *SSMCredentialsProvider) Credentials() (Credentials, error) {
+	return p.cache.get(p.RefreshInterval, func() (Credentials, error) {
+		client := p.Client
+		if client == nil {
+			sess, err := awsSession(p.Region)
+			if err != nil {
+				return Credentials{}, err
+			}
+			client = ssm.New(sess)
+		}
+		var creds Credentials
+		var err error
+		if p.UserNameParameter != "" {
+			if creds.UserName, err = ssmParameter(client, p.UserNameParameter); err != nil {
+				return Credentials{}, err
+			}
+		}
+		if creds.Password, err = ssmParameter(client, p.PasswordParameter); err != nil {
+			return Credentials{}, err
+		}
+		return creds, nil
+	})
+}
+
+// Invalidate drops the cached credentials.
+func (p *SSMCredentialsProvider) Invalidate() {
+	p.cache.invalidate()
+}
+
+func ssmParameter(client ssmiface.SSMAPI, name string) (string, error) {
+	output, err := client.GetParameter(&ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error reading parameter %s: %w", name, err)
+	}
+	if output.Parameter == nil || aws.StringValue(output.Parameter.Value) == "" {
+		return "", errors.New("parameter " + name + " is empty")
+	}
+	return aws.StringValue(output.Parameter.Value), nil
+}
+
+func awsSession(region string) (*session.Session, error) {
+	config := aws.Config{}
+	if region != "" {
+		config.Region = aws.String(region)
+	}
+	return session.NewSessionWithOptions(session.Options{
+		Config:            config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+}
+
+// credentialsCache holds fetched credentials for a refresh interval.
+type credentialsCache struct {
+	mu          sync.Mutex
+	credentials Credentials
+	fetched     time.Time
+}
+
+func (c *credentialsCache) get(interval time.Duration, fetch func() (Credentials, error)) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.fetched.IsZero() && time.Since(c.fetched) < interval {
+		return c.credentials, nil
+	}
+	creds, err := fetch()
+	if err != nil {
+		return Credentials{}, err
+	}
+	c.credentials, c.fetched = creds, time.Now()
+	return creds, nil
+}
+
+func (c *credentialsCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.credentials, c.fetched = Credentials{}, time.Time{}
+}
@@ -0,0 +1,51 @@
+package boilingdata
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff computes the delay to wait before retry attempt n (starting at 1).
+type Backoff interface {
+	Next(attempt int) time.Duration
+}
+
+// ExponentialBackoff grows the delay by Multiplier on each attempt, capped at
+// Max. Jitter randomizes each delay by up to that fraction (0.2 = ±20%) so
+// that many clients do not retry in lockstep.
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// DefaultBackoff is used for reconnects when no Backoff is configured.
+var DefaultBackoff Backoff = ExponentialBackoff{
+	Initial:    500 * time.Millisecond,
+	Max:        30 * time.Second,
+	Multiplier: 2,
+	Jitter:     0.2,
+}
+
+func (b ExponentialBackoff) Next(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && delay > float64(b.Max) {
+		delay = float64(b.Max)
+	}
+	if b.Jitter > 0 {
+		delay += delay * b.Jitter * (2*rand.Float64() - 1)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay)
+}
@@ -0,0 +1,37 @@
+package boilingdata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffGrowsToMax(t *testing.T) {
+	backoff := ExponentialBackoff{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, want := range want {
+		if got := backoff.Next(i + 1); got != want {
+			t.Errorf("Next(%d) = %v, want %v", i+1, got, want)
+		}
+	}
+	if got := backoff.Next(0); got != time.Second {
+		t.Errorf("Next(0) = %v, want the initial delay", got)
+	}
+	if got := (ExponentialBackoff{Initial: time.Second, Multiplier: 0.5}).Next(3); got != time.Second {
+		t.Errorf("Next with a multiplier below 1 = %v, want the initial delay", got)
+	}
+}
+
+func TestExponentialBackoffJitter(t *testing.T) {
+	backoff := ExponentialBackoff{Initial: time.Second, Max: 4 * time.Second, Multiplier: 2, Jitter: 0.2}
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		got := backoff.Next(3)
+		if got < 3200*time.Millisecond || got > 4800*time.Millisecond {
+			t.Fatalf("Next(3) = %v, want 4s ± 20%%", got)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 2 {
+		t.Error("jittered delays are all equal")
+	}
+}
@@ -0,0 +1,62 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the service while the circuit
+// breaker set up by WithCircuitBreaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open: service unavailable")
+
+// circuitBreaker opens after threshold consecutive failures and fails fast
+// until cooldown has passed. Then a single call is let through as a probe,
+// while others still fail fast; its outcome closes the circuit or opens it
+// again.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: max(threshold, 1), cooldown: cooldown}
+}
+
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return nil
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// record counts the outcome of a call. Server errors for a query and
+// cancellations by the caller prove the service is up and count as success.
+func (b *circuitBreaker) record(err error) {
+	var serverErr *ServerError
+	if err == nil || errors.As(err, &serverErr) || errors.Is(err, context.Canceled) {
+		b.mu.Lock()
+		b.failures = 0
+		b.probing = false
+		b.mu.Unlock()
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
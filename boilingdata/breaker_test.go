@@ -0,0 +1,64 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var errDial = errors.New("dial failed")
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	breaker := newCircuitBreaker(3, time.Hour)
+	for i := 0; i < 2; i++ {
+		breaker.record(errDial)
+		if err := breaker.allow(); err != nil {
+			t.Fatalf("open after %d failures", i+1)
+		}
+	}
+	breaker.record(errDial)
+	if err := breaker.allow(); err != ErrCircuitOpen {
+		t.Fatalf("allow() = %v after 3 failures, want ErrCircuitOpen", err)
+	}
+}
+
+func TestCircuitBreakerServiceUpOutcomes(t *testing.T) {
+	// Successes, SQL errors and cancellations all show the service is up.
+	for _, outcome := range []error{nil, &ServerError{Message: "Parser Error"}, fmt.Errorf("query: %w", context.Canceled)} {
+		breaker := newCircuitBreaker(2, time.Hour)
+		breaker.record(errDial)
+		breaker.record(outcome)
+		breaker.record(errDial)
+		if err := breaker.allow(); err != nil {
+			t.Errorf("after %v: allow() = %v, want the failure count reset", outcome, err)
+		}
+	}
+}
+
+func TestCircuitBreakerSingleProbe(t *testing.T) {
+	breaker := newCircuitBreaker(1, time.Hour)
+	breaker.record(errDial)
+	breaker.openUntil = time.Now().Add(-time.Second)
+
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("probe after the cooldown: %v", err)
+	}
+	if err := breaker.allow(); err != ErrCircuitOpen {
+		t.Fatalf("second call during the probe: %v, want ErrCircuitOpen", err)
+	}
+	breaker.record(errDial)
+	if err := breaker.allow(); err != ErrCircuitOpen {
+		t.Fatalf("after a failed probe: %v, want ErrCircuitOpen", err)
+	}
+
+	breaker.openUntil = time.Now().Add(-time.Second)
+	breaker.allow()
+	breaker.record(nil)
+	for i := 0; i < 2; i++ {
+		if err := breaker.allow(); err != nil {
+			t.Fatalf("after a successful probe: %v", err)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package boilingdata
+
+import (
+	"container/list"
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// CacheStats reports the hit/miss counters of the client-side result cache.
+type CacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+type cacheEntry struct {
+	key       string
+	response  *message.Response
+	expiresAt time.Time
+}
+
+// resultCache keeps query results keyed by normalized SQL text for a fixed
+// TTL, evicting the least recently used entry beyond limit entries. It
+// stores and returns deep copies, so callers may modify their rows.
+type resultCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	limit   int
+	entries map[string]*list.Element
+	order   *list.List
+	hits    uint64
+	misses  uint64
+}
+
+func newResultCache(ttl time.Duration) *resultCache {
+	return &resultCache{
+		ttl:     ttl,
+		limit:   constants.ResultCacheEntries,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *resultCache) get(sql string) (*message.Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[normalizeSQL(sql)]
+	if !ok || time.Now().After(element.Value.(*cacheEntry).expiresAt) {
+		if ok {
+			c.remove(element)
+		}
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return cloneResponse(element.Value.(*cacheEntry).response), true
+}
+
+func (c *resultCache) set(sql string, response *message.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := normalizeSQL(sql)
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	entry := &cacheEntry{key: key, response: cloneResponse(response), expiresAt: time.Now().Add(c.ttl)}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.limit > 0 && c.order.Len() > c.limit {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove drops element; c.mu must be held.
+func (c *resultCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
+
+func (c *resultCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries)}
+}
+
+// cloneResponse returns a deep copy of response, sharing nothing mutable
+// with it.
+func cloneResponse(response *message.Response) *message.Response {
+	if response == nil {
+		return nil
+	}
+	copied := *response
+	if response.Data != nil {
+		copied.Data = make([]map[string]interface{}, len(response.Data))
+		for i, row := range response.Data {
+			copied.Data[i] = cloneValue(row).(map[string]interface{})
+		}
+	}
+	copied.Keys = append([]string(nil), response.Keys...)
+	if response.Stats != nil {
+		stats := *response.Stats
+		copied.Stats = &stats
+	}
+	if response.Extra != nil {
+		copied.Extra = make(map[string]json.RawMessage, len(response.Extra))
+		for key, raw := range response.Extra {
+			copied.Extra[key] = append(json.RawMessage(nil), raw...)
+		}
+	}
+	return &copied
+}
+
+// cloneValue deep-copies a decoded JSON value.
+func cloneValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = cloneValue(item)
+		}
+		return copied
+	case message.Row:
+		return message.Row(cloneValue(map[string]interface{}(v)).(map[string]interface{}))
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = cloneValue(item)
+		}
+		return copied
+	case []byte:
+		return append([]byte(nil), v...)
+	}
+	return value
+}
+
+// normalizeSQL collapses whitespace and drops trailing semicolons so that
+// trivially different spellings of the same statement share a cache entry.
+// Quoted strings, quoted identifiers and comments are kept as written, and a
+// line comment keeps its newline.
+func normalizeSQL(sql string) string {
+	var normalized strings.Builder
+	space := false
+	for i := 0; i < len(sql); i++ {
+		if unicode.IsSpace(rune(sql[i])) {
+			space = true
+			continue
+		}
+		if space && normalized.Len() > 0 {
+			normalized.WriteByte(' ')
+		}
+		space = false
+		end := skipLiteral(sql, i)
+		switch {
+		case end == i:
+			end++
+		case strings.HasPrefix(sql[i:], "--") && end < len(sql):
+			end++
+		}
+		normalized.WriteString(sql[i:end])
+		i = end - 1
+	}
+	return strings.TrimRight(normalized.String(), "; \n")
+}
+
+// ServerCacheStats counts responses by the cache status the server reported
+// in their CacheInfo.
+type ServerCacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Partial uint64
+	Unknown uint64
+}
+
+// serverCacheCounters aggregates ServerCacheStats for an Instance.
+type serverCacheCounters struct {
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	partial atomic.Uint64
+	unknown atomic.Uint64
+}
+
+func (c *serverCacheCounters) record(status message.CacheStatus) {
+	switch status {
+	case message.CacheHit:
+		c.hits.Add(1)
+	case message.CacheMiss:
+		c.misses.Add(1)
+	case message.CachePartial:
+		c.partial.Add(1)
+	default:
+		c.unknown.Add(1)
+	}
+}
+
+func (c *serverCacheCounters) stats() ServerCacheStats {
+	return ServerCacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Partial: c.partial.Load(),
+		Unknown: c.unknown.Load(),
+	}
+}
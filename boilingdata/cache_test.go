@@ -0,0 +1,84 @@
+package boilingdata
+
+import (
+	"testing"
+	"time"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		sql, want string
+	}{
+		{"SELECT  1", "SELECT 1"},
+		{"\n\tSELECT 1 ;; ", "SELECT 1"},
+		{"SELECT * FROM t WHERE x = 'a  b'", "SELECT * FROM t WHERE x = 'a  b'"},
+		{`SELECT "my  col" FROM t`, `SELECT "my  col" FROM t`},
+		{"SELECT 'it''s  ok'", "SELECT 'it''s  ok'"},
+		{"SELECT 1 -- note\n, 2", "SELECT 1 -- note\n, 2"},
+		{"SELECT /* a  b */  1", "SELECT /* a  b */ 1"},
+	}
+	for _, tt := range tests {
+		if got := normalizeSQL(tt.sql); got != tt.want {
+			t.Errorf("normalizeSQL(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestResultCacheLiteralWhitespace(t *testing.T) {
+	cache := newResultCache(time.Hour)
+	cache.set("SELECT * FROM t WHERE x='a  b'", &message.Response{Data: []map[string]interface{}{{"x": "a  b"}}})
+	if _, ok := cache.get("SELECT * FROM t WHERE x='a b'"); ok {
+		t.Error("literals differing in whitespace share a cache entry")
+	}
+	if _, ok := cache.get("SELECT *  FROM t\nWHERE x='a  b';"); !ok {
+		t.Error("respelling the same query missed the cache")
+	}
+	if stats := cache.stats(); stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("stats = %+v, want 1 hit, 1 miss, 1 entry", stats)
+	}
+}
+
+func TestResultCacheEviction(t *testing.T) {
+	cache := newResultCache(time.Hour)
+	cache.limit = 2
+	cache.set("SELECT 1", &message.Response{})
+	cache.set("SELECT 2", &message.Response{})
+	cache.get("SELECT 1")
+	cache.set("SELECT 3", &message.Response{})
+	if _, ok := cache.get("SELECT 2"); ok {
+		t.Error("least recently used entry was kept")
+	}
+	for _, sql := range []string{"SELECT 1", "SELECT 3"} {
+		if _, ok := cache.get(sql); !ok {
+			t.Errorf("%s was evicted", sql)
+		}
+	}
+}
+
+func TestResultCacheExpiry(t *testing.T) {
+	cache := newResultCache(-time.Second)
+	cache.set("SELECT 1", &message.Response{})
+	if _, ok := cache.get("SELECT 1"); ok {
+		t.Error("expired entry was returned")
+	}
+	if stats := cache.stats(); stats.Entries != 0 {
+		t.Errorf("expired entry kept, stats = %+v", stats)
+	}
+}
+
+func TestResultCacheCopies(t *testing.T) {
+	cache := newResultCache(time.Hour)
+	stored := &message.Response{Data: []map[string]interface{}{{"v": 1.0, "list": []interface{}{1.0}}}}
+	cache.set("SELECT 1", stored)
+	stored.Data[0]["v"] = 2.0
+
+	first, _ := cache.get("SELECT 1")
+	first.Data[0]["list"].([]interface{})[0] = 3.0
+
+	second, _ := cache.get("SELECT 1")
+	if second.Data[0]["v"] != 1.0 || second.Data[0]["list"].([]interface{})[0] != 1.0 {
+		t.Errorf("cached row changed to %v", second.Data[0])
+	}
+}
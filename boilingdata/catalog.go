@@ -0,0 +1,175 @@
+package boilingdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// DataSource is a data source (S3 bucket) the signed in user can query.
+type DataSource struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	URI  string `json:"uri"`
+}
+
+// S3Object is an entry returned when listing an S3 location through BoilingData.
+type S3Object struct {
+	Key          string `json:"key"`
+	Size         int64  `json:"size"`
+	LastModified string `json:"lastModified"`
+}
+
+// ListDataSources returns the S3 buckets registered for the user's account.
+func (instance *Instance) ListDataSources() ([]DataSource, error) {
+	objects, err := instance.listS3("s3://")
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]DataSource, 0, len(objects))
+	for _, object := range objects {
+		name := strings.TrimSuffix(strings.TrimPrefix(object.Key, "s3://"), "/")
+		sources = append(sources, DataSource{Name: name, Type: "s3", URI: "s3://" + name + "/"})
+	}
+	return sources, nil
+}
+
+// ListS3 returns the objects and prefixes directly under an s3:// location.
+func (instance *Instance) ListS3(location string) ([]S3Object, error) {
+	if !strings.HasPrefix(location, "s3://") {
+		return nil, fmt.Errorf("invalid S3 location %q, expected s3:// prefix", location)
+	}
+	return instance.listS3(location)
+}
+
+func (instance *Instance) listS3(location string) ([]S3Object, error) {
+	response, err := instance.querySQL("SELECT * FROM list(" + QuoteLiteral(location) + ");")
+	if err != nil {
+		return nil, err
+	}
+	var objects []S3Object
+	if err := decodeRows(response.Data, &objects); err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// querySQL runs sql with a generated request ID.
+func (instance *Instance) querySQL(sql string) (*message.Response, error) {
+	return instance.queryContext(context.Background(), sql)
+}
+
+// queryContext runs sql under a new request ID.
+func (instance *Instance) queryContext(ctx context.Context, sql string) (*message.Response, error) {
+	payload := message.GetPayLoad()
+	payload.SQL = sql
+	payload.RequestID = instance.requestID(ctx)
+	payloadMessage, err := json.Marshal(payload)
+	if err != nil {
+		return &message.Response{}, fmt.Errorf("error marshalling Payload : %w", err)
+	}
+	return instance.QueryContext(ctx, payloadMessage)
+}
+
+// decodeRows converts response rows into the slice pointed to by dest using
+// the json tags of its element type.
+func decodeRows(rows []map[string]interface{}, dest interface{}) error {
+	data, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("error decoding rows : %w", err)
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("error decoding rows : %w", err)
+	}
+	return nil
+}
+
+// TableInfo describes a queryable file found under a data source.
+type TableInfo struct {
+	Name   string
+	Path   string
+	Format string
+	Size   int64
+}
+
+// ColumnMeta describes a single column of a table as reported by DESCRIBE.
+type ColumnMeta struct {
+	Name     string `json:"column_name"`
+	Type     string `json:"column_type"`
+	Nullable string `json:"null"`
+}
+
+// ListTables returns the Parquet, CSV and JSON files directly under source.
+func (instance *Instance) ListTables(source string) ([]TableInfo, error) {
+	objects, err := instance.ListS3(source)
+	if err != nil {
+		return nil, err
+	}
+	var tables []TableInfo
+	for _, object := range objects {
+		format := tableFormat(object.Key)
+		if format == "" {
+			continue
+		}
+		path := object.Key
+		if !strings.HasPrefix(path, "s3://") {
+			path = strings.TrimSuffix(source, "/") + "/" + strings.TrimPrefix(path, "/")
+		}
+		name := path[strings.LastIndex(path, "/")+1:]
+		tables = append(tables, TableInfo{
+			Name:   strings.TrimSuffix(name, "."+format),
+			Path:   path,
+			Format: format,
+			Size:   object.Size,
+		})
+	}
+	return tables, nil
+}
+
+// DescribeTable returns the column names and types of the file at path.
+func (instance *Instance) DescribeTable(path string) ([]ColumnMeta, error) {
+	scan, err := ScanExpression(path)
+	if err != nil {
+		return nil, err
+	}
+	return instance.DescribeQuery("SELECT * FROM " + scan)
+}
+
+// DescribeQuery returns the result column names and types of sql without
+// running it. The types can be passed to DecodeTyped. With WithStatementCache
+// the result is remembered per statement.
+func (instance *Instance) DescribeQuery(sql string) ([]ColumnMeta, error) {
+	if instance.describeCache != nil {
+		if columns, ok := instance.describeCache.get(sql); ok {
+			return columns, nil
+		}
+	}
+	response, err := instance.querySQL("DESCRIBE " + strings.TrimRight(strings.TrimSpace(sql), ";") + ";")
+	if err != nil {
+		return nil, err
+	}
+	var columns []ColumnMeta
+	if err := decodeRows(response.Data, &columns); err != nil {
+		return nil, err
+	}
+	if instance.describeCache != nil {
+		instance.describeCache.set(sql, columns)
+	}
+	return columns, nil
+}
+
+func tableFormat(key string) string {
+	lower := strings.ToLower(key)
+	switch {
+	case strings.HasSuffix(lower, ".parquet"):
+		return "parquet"
+	case strings.HasSuffix(lower, ".csv"):
+		return "csv"
+	case strings.HasSuffix(lower, ".json"):
+		return "json"
+	}
+	return ""
+}
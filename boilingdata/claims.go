@@ -0,0 +1,83 @@
+package boilingdata
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrNotLoggedIn is returned when token details are requested before a
+// successful login.
+var ErrNotLoggedIn = errors.New("Not logged in")
+
+// Claims are the decoded claims of a Cognito ID token. Raw holds every claim,
+// including those without a field here.
+type Claims struct {
+	Email     string
+	Subject   string
+	Issuer    string
+	Groups    []string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	Raw       jwt.MapClaims
+}
+
+// ParseIDToken decodes the claims of an ID token. The signature is not
+// verified: use it to introspect tokens obtained from Cognito, not to
+// authenticate tokens received from elsewhere.
+func ParseIDToken(token string) (*Claims, error) {
+	raw := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, raw); err != nil {
+		return nil, fmt.Errorf("Invalid token claims: %w", err)
+	}
+	claims := &Claims{Raw: raw}
+	claims.Email, _ = raw["email"].(string)
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Issuer, _ = raw["iss"].(string)
+	if groups, ok := raw["cognito:groups"].([]interface{}); ok {
+		for _, group := range groups {
+			if name, ok := group.(string); ok {
+				claims.Groups = append(claims.Groups, name)
+			}
+		}
+	}
+	if iat, ok := raw["iat"].(float64); ok {
+		claims.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	if exp, ok := raw["exp"].(float64); ok {
+		claims.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	return claims, nil
+}
+
+// WhoAmI returns the claims of the current ID token.
+func (auth *Auth) WhoAmI() (*Claims, error) {
+	muLock.Lock()
+	defer muLock.Unlock()
+	return auth.claims()
+}
+
+// claims parses the current ID token. Callers hold muLock.
+func (auth *Auth) claims() (*Claims, error) {
+	if !auth.IsUserLoggedIn() {
+		return nil, ErrNotLoggedIn
+	}
+	return ParseIDToken(*auth.authResult.IdToken)
+}
+
+// TokenExpiresAt returns when the current ID token expires, or the zero time
+// when not logged in.
+func (auth *Auth) TokenExpiresAt() time.Time {
+	muLock.Lock()
+	defer muLock.Unlock()
+	claims, err := auth.claims()
+	if err == nil && !claims.ExpiresAt.IsZero() {
+		return claims.ExpiresAt
+	}
+	if auth.authResult != nil && auth.authResult.ExpiresIn != nil {
+		return auth.timeWhenLastJwtTokenWasRecieved.Add(time.Second * time.Duration(*auth.authResult.ExpiresIn))
+	}
+	return time.Time{}
+}
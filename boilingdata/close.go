@@ -0,0 +1,202 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrInstanceClosed is returned for queries started after Instance.Close.
+var ErrInstanceClosed = errors.New("boilingdata instance is closed")
+
+// lifecycle tracks the queries in flight on an Instance so Close can wait
+// for them. stopped is closed by close to cut reconnect and retry waits
+// short.
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inFlight sync.WaitGroup
+	stopped  chan struct{}
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{stopped: make(chan struct{})}
+}
+
+// begin registers a query, failing once the Instance is closed.
+func (l *lifecycle) begin() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return ErrInstanceClosed
+	}
+	l.inFlight.Add(1)
+	return nil
+}
+
+func (l *lifecycle) end() {
+	l.inFlight.Done()
+}
+
+// close rejects new queries and reports whether it was the first call.
+func (l *lifecycle) close() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	first := !l.closed
+	if first {
+		close(l.stopped)
+	}
+	l.closed = true
+	return first
+}
+
+// wait waits for the queries in flight until ctx is done.
+func (l *lifecycle) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		l.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sleep waits for d, failing early when ctx is done or the Instance is
+// closed.
+func (instance *Instance) sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-instance.lifecycle.stopped:
+		return ErrInstanceClosed
+	}
+}
+
+// Close waits for in-flight queries to finish, closes the connection, stops
+// its idle timer and interrupt handler, and removes the Instance from the
+// per-user instance map. Later queries fail with ErrInstanceClosed. It is
+// safe to call more than once.
+func (instance *Instance) Close() error {
+	if !instance.lifecycle.close() {
+		return nil
+	}
+	instance.phase.set(Draining)
+	instance.lifecycle.inFlight.Wait()
+	instance.Wsc.Close()
+	instance.phase.clear()
+	instance.unregister()
+	return nil
+}
+
+// Shutdown is like Close but bounded by ctx: it stops reconnect and retry
+// waits, waits for in-flight queries until ctx is done, then closes every
+// connection of the Instance and waits for their send and receive
+// goroutines to exit. When ctx is done first, it returns a ShutdownError
+// naming what was still running. It may be called after Close.
+func (instance *Instance) Shutdown(ctx context.Context) error {
+	instance.lifecycle.close()
+	instance.phase.set(Draining)
+	var running []string
+	if err := instance.lifecycle.wait(ctx); err != nil {
+		running = append(running, "queries")
+	}
+	clients := instance.connections()
+	for i, wsc := range clients {
+		var shutdown *ShutdownError
+		if err := wsc.Shutdown(ctx); errors.As(err, &shutdown) {
+			for _, name := range shutdown.Running {
+				running = append(running, fmt.Sprintf("connection %d %s", i, name))
+			}
+		}
+	}
+	instance.phase.clear()
+	instance.unregister()
+	if running != nil {
+		return &ShutdownError{Running: running, Cause: ctx.Err()}
+	}
+	return nil
+}
+
+// unregister removes the Instance from the per-user instance map and from
+// the instances stopped by the package-level Shutdown.
+func (instance *Instance) unregister() {
+	instances.remove(instance)
+	muLock.Lock()
+	defer muLock.Unlock()
+	if current, ok := queryServiceMap.Get(instance.Auth.userName); ok && current == instance {
+		queryServiceMap.Remove(instance.Auth.userName)
+	}
+}
+
+// instanceSet holds the Instances not closed yet.
+type instanceSet struct {
+	mu  sync.Mutex
+	all map[*Instance]struct{}
+}
+
+var instances = &instanceSet{all: make(map[*Instance]struct{})}
+
+func (set *instanceSet) add(instance *Instance) {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	set.all[instance] = struct{}{}
+}
+
+func (set *instanceSet) remove(instance *Instance) {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	delete(set.all, instance)
+}
+
+func (set *instanceSet) list() []*Instance {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	list := make([]*Instance, 0, len(set.all))
+	for instance := range set.all {
+		list = append(list, instance)
+	}
+	return list
+}
+
+// Shutdown shuts down every Instance not closed yet, concurrently, e.g. on
+// service shutdown or at the end of a test. When ctx is done before all of
+// them stopped, it returns a ShutdownError naming, per user, what was still
+// running.
+func Shutdown(ctx context.Context) error {
+	var (
+		mu      sync.Mutex
+		running []string
+		wg      sync.WaitGroup
+	)
+	for _, instance := range instances.list() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var shutdown *ShutdownError
+			if err := instance.Shutdown(ctx); errors.As(err, &shutdown) {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, name := range shutdown.Running {
+					running = append(running, instance.Auth.userName+": "+name)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if running != nil {
+		sort.Strings(running)
+		return &ShutdownError{Running: running, Cause: ctx.Err()}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package boilingdata
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Config configures a client built with NewClient. Zero fields keep the
+// package defaults from the constants package.
+type Config struct {
+	// UserName is the account the client signs in as.
+	UserName string
+	// Credentials supplies the password when a login needs it.
+	Credentials CredentialsProvider
+	// Endpoint is the websocket URL, constants.WssUrl by default.
+	Endpoint string
+	// Region is the AWS region Endpoint is signed for.
+	Region   string
+	Timeouts Timeouts
+	// Options are applied after the settings above and override them.
+	Options []Option
+}
+
+// Timeouts are the timeouts of a Config, also set with WithTimeouts. Each
+// phase of a query has its own budget, failing with a TimeoutError that
+// matches the phase's error, e.g. errors.Is(err, ErrFirstByteTimeout).
+type Timeouts struct {
+	// Idle closes the connection after this long without queries.
+	Idle time.Duration
+	// Auth bounds signing in and signing the dial header.
+	Auth time.Duration
+	// Dial bounds the TCP, TLS and websocket handshake.
+	Dial time.Duration
+	// FirstByte bounds the wait for the first frame of a response.
+	FirstByte time.Duration
+	// Response is how long a query waits for its complete response.
+	Response time.Duration
+	// Read and Write are the websocket read and write deadlines.
+	Read  time.Duration
+	Write time.Duration
+}
+
+// Validate reports the first setting of config that cannot work.
+func (config Config) Validate() error {
+	if config.UserName == "" {
+		return errors.New("invalid config: UserName is required")
+	}
+	if config.Credentials == nil {
+		return errors.New("invalid config: Credentials is required")
+	}
+	if config.Endpoint != "" {
+		u, err := url.Parse(config.Endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid config: Endpoint: %w", err)
+		}
+		if u.Scheme != "wss" && u.Scheme != "ws" || u.Host == "" {
+			return fmt.Errorf("invalid config: Endpoint %q is not a websocket URL", config.Endpoint)
+		}
+	}
+	timeouts := config.Timeouts
+	switch {
+	case timeouts.Idle < 0, timeouts.Auth < 0, timeouts.Dial < 0, timeouts.FirstByte < 0,
+		timeouts.Response < 0, timeouts.Read < 0, timeouts.Write < 0:
+		return errors.New("invalid config: Timeouts must not be negative")
+	}
+	return nil
+}
+
+// options converts config into Instance options.
+func (config Config) options() []Option {
+	var opts []Option
+	if config.Endpoint != "" {
+		opts = append(opts, WithEndpoint(config.Endpoint))
+	}
+	if config.Region != "" {
+		opts = append(opts, WithRegion(config.Region))
+	}
+	opts = append(opts, WithTimeouts(config.Timeouts))
+	return append(opts, config.Options...)
+}
+
+// NewClient validates config and returns a new Instance for it. Unlike
+// GetInstance the Instance is not shared with other callers for the same
+// user.
+func NewClient(config Config) (*Instance, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return NewInstance(config.UserName, config.Credentials, config.options()...), nil
+}
@@ -0,0 +1,181 @@
+package boilingdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// convertAssign stores src, a value decoded from a JSON response, in the
+// variable dest points to.
+func convertAssign(dest interface{}, src interface{}) error {
+	switch d := dest.(type) {
+	case *interface{}:
+		*d = src
+		return nil
+	case *string:
+		switch s := src.(type) {
+		case nil:
+			*d = ""
+		case string:
+			*d = s
+		case float64:
+			*d = strconv.FormatFloat(s, 'f', -1, 64)
+		case json.Number:
+			*d = s.String()
+		case bool:
+			*d = strconv.FormatBool(s)
+		case []byte:
+			*d = string(s)
+		default:
+			data, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			*d = string(data)
+		}
+		return nil
+	case *[]byte:
+		switch s := src.(type) {
+		case nil:
+			*d = nil
+		case string:
+			*d = []byte(s)
+		case []byte:
+			*d = append([]byte(nil), s...)
+		default:
+			data, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			*d = data
+		}
+		return nil
+	case *time.Time:
+		s, ok := src.(string)
+		if !ok {
+			return fmt.Errorf("converting %T to time.Time is unsupported", src)
+		}
+		t, err := parseTime(s)
+		if err != nil {
+			return err
+		}
+		*d = t
+		return nil
+	}
+
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("destination not a pointer")
+	}
+	dv := rv.Elem()
+	if src == nil {
+		dv.Set(reflect.Zero(dv.Type()))
+		return nil
+	}
+	switch dv.Kind() {
+	case reflect.Pointer:
+		value := reflect.New(dv.Type().Elem())
+		if err := convertAssign(value.Interface(), src); err != nil {
+			return err
+		}
+		dv.Set(value)
+		return nil
+	case reflect.Bool:
+		switch s := src.(type) {
+		case bool:
+			dv.SetBool(s)
+			return nil
+		case string:
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return err
+			}
+			dv.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(numberString(src), 10, dv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("converting %v to %s: %w", src, dv.Kind(), err)
+		}
+		dv.SetInt(i)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(numberString(src), 10, dv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("converting %v to %s: %w", src, dv.Kind(), err)
+		}
+		dv.SetUint(u)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(numberString(src), dv.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("converting %v to %s: %w", src, dv.Kind(), err)
+		}
+		dv.SetFloat(f)
+		return nil
+	case reflect.String:
+		var s string
+		if err := convertAssign(&s, src); err != nil {
+			return err
+		}
+		dv.SetString(s)
+		return nil
+	}
+	if sv := reflect.ValueOf(src); sv.Type().AssignableTo(dv.Type()) {
+		dv.Set(sv)
+		return nil
+	}
+	switch dv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		// Nested values such as STRUCT and LIST columns arrive as decoded
+		// JSON; decode them again into the destination type.
+		data, err := json.Marshal(src)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, dest)
+	}
+	return fmt.Errorf("unsupported Scan, storing %T into type %s", src, dv.Type())
+}
+
+// numberString formats a JSON number for strconv parsing. Whole floats are
+// written without exponent so they parse as integers.
+func numberString(src interface{}) string {
+	switch s := src.(type) {
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	case json.Number:
+		return s.String()
+	case string:
+		return s
+	case bool:
+		if s {
+			return "1"
+		}
+		return "0"
+	}
+	return fmt.Sprint(src)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as time", s)
+}
@@ -0,0 +1,111 @@
+package boilingdata
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Credentials are the BoilingData account username and password.
+type Credentials struct {
+	UserName string
+	Password string
+}
+
+// CredentialsProvider supplies credentials when Auth has to log in with a
+// password. It is not consulted while a valid or refreshable token exists, so
+// providers that fetch on demand keep the password out of memory otherwise.
+type CredentialsProvider interface {
+	Credentials() (Credentials, error)
+}
+
+type staticCredentialsProvider struct {
+	credentials Credentials
+}
+
+// NewStaticCredentialsProvider returns a provider for fixed credentials.
+func NewStaticCredentialsProvider(userName string, password string) CredentialsProvider {
+	return &staticCredentialsProvider{credentials: Credentials{UserName: userName, Password: password}}
+}
+
+func (p *staticCredentialsProvider) Credentials() (Credentials, error) {
+	return p.credentials, nil
+}
+
+// EnvCredentialsProvider reads credentials from environment variables,
+// BD_USERNAME and BD_PASSWORD unless other names are set.
+type EnvCredentialsProvider struct {
+	UserNameVar string
+	PasswordVar string
+}
+
+func (p EnvCredentialsProvider) Credentials() (Credentials, error) {
+	userNameVar, passwordVar := p.UserNameVar, p.PasswordVar
+	if userNameVar == "" {
+		userNameVar = "BD_USERNAME"
+	}
+	if passwordVar == "" {
+		passwordVar = "BD_PASSWORD"
+	}
+	creds := Credentials{UserName: os.Getenv(userNameVar), Password: os.Getenv(passwordVar)}
+	if creds.UserName == "" || creds.Password == "" {
+		return Credentials{}, fmt.Errorf("credentials not found in %s/%s", userNameVar, passwordVar)
+	}
+	return creds, nil
+}
+
+// PromptCredentialsProvider asks for credentials on Out and reads them from
+// In, defaulting to the process stdin and stderr. UserName skips the
+// username prompt when set. When In is a terminal the password is read
+// without echo.
+type PromptCredentialsProvider struct {
+	UserName string
+	In       io.Reader
+	Out      io.Writer
+}
+
+func (p PromptCredentialsProvider) Credentials() (Credentials, error) {
+	in, out := p.In, p.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+	reader := bufio.NewReader(in)
+	creds := Credentials{UserName: p.UserName}
+	if creds.UserName == "" {
+		fmt.Fprint(out, "BoilingData username: ")
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Credentials{}, err
+		}
+		creds.UserName = strings.TrimSpace(line)
+	}
+	fmt.Fprint(out, "BoilingData password: ")
+	if file, ok := in.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		// Read from the terminal without echoing the password.
+		password, err := term.ReadPassword(int(file.Fd()))
+		fmt.Fprintln(out)
+		if err != nil {
+			return Credentials{}, err
+		}
+		creds.Password = string(password)
+		clear(password)
+	} else {
+		line, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Credentials{}, err
+		}
+		creds.Password = strings.TrimRight(line, "\r\n")
+	}
+	if creds.UserName == "" || creds.Password == "" {
+		return Credentials{}, errors.New("no credentials entered")
+	}
+	return creds, nil
+}
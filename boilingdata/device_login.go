@@ -0,0 +1,182 @@
+package boilingdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+)
+
+// DeviceLogin signs in with the OAuth 2.0 device authorization grant, for
+// CLIs: the user opens a URL in a browser and enters a short code, and the
+// client picks up the tokens, so the password is never typed into a
+// terminal or stored in a script. The tokens are refreshed from then on
+// like those of a FederatedLogin.
+type DeviceLogin struct {
+	// DeviceAuthorizationURL is the device authorization endpoint.
+	DeviceAuthorizationURL string
+	// TokenURL is the token endpoint polled for the tokens.
+	TokenURL string
+	// ClientID defaults to constants.ClientID.
+	ClientID string
+	// Scope is the space separated list of scopes to request, if any.
+	Scope string
+	// Prompt tells the user where to sign in. By default the URL and code
+	// are printed to standard error.
+	Prompt func(verificationURL string, userCode string)
+}
+
+// deviceAuthorization is the device authorization endpoint response.
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// GetInstanceWithDeviceLogin returns a new Instance for userName signed in
+// with login. It waits until the user has signed in in the browser, the
+// code expires or ctx is done. Like NewInstance, the Instance is not
+// registered for userName.
+func GetInstanceWithDeviceLogin(ctx context.Context, userName string, login DeviceLogin, opts ...Option) (*Instance, error) {
+	instance := NewInstance(userName, nil, opts...)
+	if err := instance.Auth.DeviceLogin(ctx, login); err != nil {
+		instance.Close()
+		return nil, err
+	}
+	return instance, nil
+}
+
+// DeviceLogin runs the device authorization flow of login and signs auth in
+// with the resulting tokens.
+func (auth *Auth) DeviceLogin(ctx context.Context, login DeviceLogin) error {
+	if login.DeviceAuthorizationURL == "" || login.TokenURL == "" {
+		return errors.New("device login needs a device authorization URL and a token URL")
+	}
+	clientID := login.ClientID
+	if clientID == "" {
+		clientID = constants.ClientID
+	}
+	device, err := auth.authorizeDevice(ctx, login.DeviceAuthorizationURL, clientID, login.Scope)
+	if err != nil {
+		return fmt.Errorf("Error starting device login: %w", err)
+	}
+	verificationURL := device.VerificationURIComplete
+	if verificationURL == "" {
+		verificationURL = device.VerificationURI
+	}
+	if login.Prompt != nil {
+		login.Prompt(verificationURL, device.UserCode)
+	} else {
+		fmt.Fprintf(os.Stderr, "To sign in, open %s and enter the code %s\n", verificationURL, device.UserCode)
+	}
+	token, err := auth.pollDeviceToken(ctx, login.TokenURL, clientID, device)
+	if err != nil {
+		return fmt.Errorf("Error completing device login: %w", err)
+	}
+	muLock.Lock()
+	auth.federation = &federation{login: FederatedLogin{TokenURL: login.TokenURL, ClientID: clientID}, used: true}
+	auth.setTokens(token)
+	muLock.Unlock()
+	log.Println("Authentication successful")
+	if auth.onRefresh != nil {
+		auth.onRefresh()
+	}
+	auth.hooks.fire(authEvents{refreshed: true})
+	return nil
+}
+
+func (auth *Auth) authorizeDevice(ctx context.Context, authorizationURL string, clientID string, scope string) (*deviceAuthorization, error) {
+	form := url.Values{"client_id": {clientID}}
+	if scope != "" {
+		form.Set("scope", scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, authorizationURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := auth.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("device authorization endpoint returned %s", resp.Status)
+	}
+	var device deviceAuthorization
+	if err := json.Unmarshal(body, &device); err != nil {
+		return nil, fmt.Errorf("unexpected device authorization response: %w", err)
+	}
+	if device.DeviceCode == "" || device.UserCode == "" {
+		return nil, errors.New("device authorization response has no code")
+	}
+	return &device, nil
+}
+
+// pollDeviceToken polls the token endpoint at the interval the server asks
+// for until the user has signed in. Network and server errors are retried
+// until the device code expires.
+func (auth *Auth) pollDeviceToken(ctx context.Context, tokenURL string, clientID string, device *deviceAuthorization) (*tokenResponse, error) {
+	interval := time.Duration(max(device.Interval, 5)) * time.Second
+	expiresIn := time.Duration(device.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = 15 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(ctx, expiresIn)
+	defer cancel()
+	form := url.Values{
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+		"device_code": {device.DeviceCode},
+		"client_id":   {clientID},
+	}
+	for {
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		token, err := auth.postTokenForm(ctx, tokenURL, form)
+		if err == nil {
+			return token, nil
+		}
+		var tokenErr *tokenError
+		switch {
+		case errors.As(err, &tokenErr) && tokenErr.Code == "authorization_pending":
+		case errors.As(err, &tokenErr) && tokenErr.Code == "slow_down":
+			interval += 5 * time.Second
+		case temporaryTokenError(err):
+			log.Println("Device login poll failed, retrying: " + err.Error())
+		default:
+			return nil, err
+		}
+	}
+}
+
+// temporaryTokenError reports whether err, returned by postTokenForm, may
+// go away on its own: a network error or a server error without an OAuth
+// error code.
+func temporaryTokenError(err error) bool {
+	var tokenErr *tokenError
+	if errors.As(err, &tokenErr) {
+		return tokenErr.Code == "" && (tokenErr.StatusCode >= 500 || tokenErr.StatusCode == http.StatusTooManyRequests)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,29 @@
+package boilingdata
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestTemporaryTokenError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&tokenError{Status: "503 Service Unavailable", StatusCode: 503}, true},
+		{&tokenError{Status: "429 Too Many Requests", StatusCode: 429}, true},
+		{&tokenError{Status: "400 Bad Request", StatusCode: 400, Code: "expired_token"}, false},
+		{&tokenError{Status: "500 Internal Server Error", StatusCode: 500, Code: "access_denied"}, false},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{fmt.Errorf("reading: %w", io.ErrUnexpectedEOF), true},
+		{errors.New("empty ID token"), false},
+	}
+	for _, tt := range tests {
+		if got := temporaryTokenError(tt.err); got != tt.want {
+			t.Errorf("temporaryTokenError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package boilingdata
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"unicode"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// downloadResult fetches the rows of a result the server wrote to S3 and
+// stores them in response.Data. The object is either a JSON array of rows or
+// newline delimited JSON rows.
+func (instance *Instance) downloadResult(ctx context.Context, response *message.Response) error {
+	var body []byte
+	var err error
+	if instance.downloadParts > 1 && instance.downloadPartSize > 0 && response.ResultSize > instance.downloadPartSize {
+		body, err = instance.downloadRanges(ctx, response.ResultURL, response.ResultSize)
+		if errors.Is(err, errRangesIgnored) {
+			log.Println("Result host ignored ranged requests, downloading in one request")
+			body, err = instance.download(ctx, response.ResultURL, "")
+		}
+	} else {
+		body, err = instance.download(ctx, response.ResultURL, "")
+	}
+	if err != nil {
+		return fmt.Errorf("Error downloading result: %w", err)
+	}
+	rows, err := decodeResultRows(body, instance.preciseNumbers)
+	if err != nil {
+		return fmt.Errorf("Error decoding result: %w", err)
+	}
+	response.Data = rows
+	return nil
+}
+
+// errRangesIgnored is returned for a ranged request answered with anything
+// but 206 Partial Content, e.g. the whole object from a host that does not
+// support ranges.
+var errRangesIgnored = errors.New("range request not honored")
+
+// downloadRanges fetches size bytes at url in parallel ranged requests and
+// joins them in order. It fails with errRangesIgnored when the host does
+// not answer with the requested ranges.
+func (instance *Instance) downloadRanges(ctx context.Context, url string, size int64) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	partSize := instance.downloadPartSize
+	count := int((size + partSize - 1) / partSize)
+	parts := make([][]byte, count)
+	errs := make([]error, count)
+	sem := make(chan struct{}, instance.downloadParts)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		start := int64(i) * partSize
+		end := min(start+partSize, size) - 1
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			parts[i], errs[i] = instance.download(ctx, url, "bytes="+strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(end, 10))
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		if errors.Is(err, errRangesIgnored) {
+			return nil, errRangesIgnored
+		}
+		return nil, err
+	}
+	return bytes.Join(parts, nil), nil
+}
+
+func (instance *Instance) download(ctx context.Context, url string, byteRange string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	resp, err := instance.Auth.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if byteRange != "" && resp.StatusCode != http.StatusPartialContent {
+		return nil, errRangesIgnored
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// streamChunkRows is the number of downloaded rows streamResult passes to
+// its FrameFunc at a time.
+const streamChunkRows = 1000
+
+// streamResult downloads a result the server wrote to S3 and passes its rows
+// to fn in chunks as they are read, without holding the result in memory.
+// The object is either a JSON array of rows or newline delimited JSON rows.
+func (instance *Instance) streamResult(ctx context.Context, response *message.Response, fn wsclient.FrameFunc) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, response.ResultURL, nil)
+	if err != nil {
+		return fmt.Errorf("Error downloading result: %w", err)
+	}
+	resp, err := instance.Auth.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("Error downloading result: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Error downloading result: unexpected status %s", resp.Status)
+	}
+	body := bufio.NewReader(resp.Body)
+	decoder := json.NewDecoder(body)
+	if first, err := firstByte(body); err != nil {
+		return fmt.Errorf("Error decoding result: %w", err)
+	} else if first == '[' {
+		if _, err := decoder.Token(); err != nil {
+			return fmt.Errorf("Error decoding result: %w", err)
+		}
+	}
+	header := *response
+	header.ResultURL = ""
+	rows := make([]json.RawMessage, 0, streamChunkRows)
+	for decoder.More() {
+		var row json.RawMessage
+		if err := decoder.Decode(&row); err != nil {
+			return fmt.Errorf("Error decoding result: %w", err)
+		}
+		if header.Keys == nil {
+			header.Keys = rowKeys(row)
+		}
+		if rows = append(rows, row); len(rows) == streamChunkRows {
+			if err := fn(&header, rows); err != nil {
+				return err
+			}
+			rows = rows[:0]
+		}
+	}
+	if len(rows) > 0 {
+		return fn(&header, rows)
+	}
+	return nil
+}
+
+// firstByte returns the first non-space byte of r without consuming it, or
+// 0 when r is empty.
+func firstByte(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.Peek(1)
+		if err == io.EOF {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(rune(b[0])) {
+			return b[0], nil
+		}
+		r.Discard(1)
+	}
+}
+
+// rowKeys returns the keys of a JSON object in their order.
+func rowKeys(row json.RawMessage) []string {
+	decoder := json.NewDecoder(bytes.NewReader(row))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return keys
+		}
+		key, _ := token.(string)
+		keys = append(keys, key)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return keys
+		}
+	}
+	return keys
+}
+
+// newDecoder returns a decoder for data, decoding numbers into json.Number
+// when useNumber is set.
+func newDecoder(data []byte, useNumber bool) *json.Decoder {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if useNumber {
+		decoder.UseNumber()
+	}
+	return decoder
+}
+
+func decodeResultRows(body []byte, useNumber bool) ([]map[string]interface{}, error) {
+	body = bytes.TrimSpace(body)
+	rows := []map[string]interface{}{}
+	decoder := newDecoder(body, useNumber)
+	if len(body) > 0 && body[0] == '[' {
+		err := decoder.Decode(&rows)
+		return rows, err
+	}
+	for decoder.More() {
+		var row map[string]interface{}
+		if err := decoder.Decode(&row); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,116 @@
+package boilingdata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by GetInstanceFromEnv and EnvOptions. Named
+// profiles use the same variables with the profile name inserted; see
+// ProfileVar.
+const (
+	EnvUserName = "BD_USERNAME"
+	EnvPassword = "BD_PASSWORD"
+	EnvEndpoint = "BD_ENDPOINT"
+	EnvRegion   = "BD_REGION"
+	EnvTimeout  = "BD_TIMEOUT"
+	EnvProfile  = "BD_PROFILE"
+)
+
+// EnvOptions converts BD_ENDPOINT, BD_REGION and BD_TIMEOUT into Instance
+// options. BD_TIMEOUT is the response timeout, either a duration such as
+// "90s" or a number of seconds. Unset variables are skipped.
+func EnvOptions() ([]Option, error) {
+	return envOptions(unprofiled)
+}
+
+func envOptions(name func(variable string) string) ([]Option, error) {
+	var opts []Option
+	if endpoint := os.Getenv(name(EnvEndpoint)); endpoint != "" {
+		opts = append(opts, WithEndpoint(endpoint))
+	}
+	if region := os.Getenv(name(EnvRegion)); region != "" {
+		opts = append(opts, WithRegion(region))
+	}
+	if value := os.Getenv(name(EnvTimeout)); value != "" {
+		timeout, err := parseTimeout(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name(EnvTimeout), err)
+		}
+		opts = append(opts, WithResponseTimeout(timeout))
+	}
+	return opts, nil
+}
+
+// GetInstanceFromEnv returns the Instance configured by the BD_* environment
+// variables, or by those of the profile named by BD_PROFILE when it is set.
+// Settings are applied in increasing precedence: package defaults, the
+// environment, then the options given here, so explicit options always win.
+func GetInstanceFromEnv(opts ...Option) (*Instance, error) {
+	if profile := os.Getenv(EnvProfile); profile != "" {
+		return GetInstanceForProfile(profile, opts...)
+	}
+	return instanceFromEnv(unprofiled, opts)
+}
+
+// GetInstanceForProfile is GetInstanceFromEnv for a named profile, so tools
+// can switch between accounts by name:
+//
+//	BD_ANALYTICS_PROD_USERNAME=... BD_ANALYTICS_PROD_PASSWORD=...
+//
+//	instance, err := boilingdata.GetInstanceForProfile("analytics-prod")
+//
+// Only the variables of the profile are read; see ProfileVar.
+func GetInstanceForProfile(profile string, opts ...Option) (*Instance, error) {
+	if profile == "" {
+		return nil, errors.New("profile name is empty")
+	}
+	return instanceFromEnv(func(variable string) string {
+		return ProfileVar(profile, variable)
+	}, opts)
+}
+
+// ProfileVar returns the variable holding the setting of variable, one of
+// the Env constants, for the named profile. The profile name is upper-cased
+// with other characters than letters and digits replaced by underscores:
+// BD_ANALYTICS_PROD_USERNAME holds the username of "analytics-prod".
+func ProfileVar(profile, variable string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, profile)
+	return "BD_" + name + "_" + strings.TrimPrefix(variable, "BD_")
+}
+
+func unprofiled(variable string) string {
+	return variable
+}
+
+func instanceFromEnv(name func(variable string) string, opts []Option) (*Instance, error) {
+	userName := os.Getenv(name(EnvUserName))
+	if userName == "" {
+		return nil, errors.New(name(EnvUserName) + " is not set")
+	}
+	envOpts, err := envOptions(name)
+	if err != nil {
+		return nil, err
+	}
+	provider := EnvCredentialsProvider{UserNameVar: name(EnvUserName), PasswordVar: name(EnvPassword)}
+	return GetInstanceWithProvider(userName, provider, append(envOpts, opts...)...), nil
+}
+
+func parseTimeout(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,37 @@
+package boilingdata
+
+import "testing"
+
+func TestProfileVar(t *testing.T) {
+	if got := ProfileVar("analytics-prod", EnvUserName); got != "BD_ANALYTICS_PROD_USERNAME" {
+		t.Errorf("ProfileVar() = %q", got)
+	}
+	if got := ProfileVar("dev.2", EnvTimeout); got != "BD_DEV_2_TIMEOUT" {
+		t.Errorf("ProfileVar() = %q", got)
+	}
+}
+
+func TestGetInstanceForProfile(t *testing.T) {
+	t.Setenv(EnvUserName, "default@example.com")
+	t.Setenv("BD_STAGING_USERNAME", "staging@example.com")
+	t.Setenv("BD_STAGING_TIMEOUT", "tomorrow")
+	if _, err := GetInstanceForProfile("staging"); err == nil {
+		t.Error("invalid profile timeout was accepted")
+	}
+
+	t.Setenv("BD_STAGING_TIMEOUT", "90")
+	instance, err := GetInstanceForProfile("staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if instance.Auth.userName != "staging@example.com" {
+		t.Errorf("userName = %q", instance.Auth.userName)
+	}
+
+	if _, err := GetInstanceForProfile("missing"); err == nil {
+		t.Error("profile without a username fell back to BD_USERNAME")
+	}
+	if _, err := GetInstanceForProfile(""); err == nil {
+		t.Error("empty profile name was accepted")
+	}
+}
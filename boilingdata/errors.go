@@ -0,0 +1,76 @@
+package boilingdata
+
+import "github.com/boilingdata/go-boilingdata/wsclient"
+
+// ServerError is the error returned when the server fails a query with a
+// LOG_MESSAGE; use errors.As to inspect its RequestID and LogLevel.
+type ServerError = wsclient.ServerError
+
+// ErrMessageTooLarge is returned when a server frame exceeds WithMaxMessageSize.
+var ErrMessageTooLarge = wsclient.ErrMessageTooLarge
+
+// ErrPayloadTooLarge matches the error of queries larger than the maximum
+// payload size; use errors.As with *PayloadTooLargeError for the limit.
+var ErrPayloadTooLarge = wsclient.ErrPayloadTooLarge
+
+// PayloadTooLargeError is returned for a query larger than the maximum
+// payload size.
+type PayloadTooLargeError = wsclient.PayloadTooLargeError
+
+// ErrSubscribeUnsupported is returned by Subscribe when the server has not
+// announced support for subscriptions.
+var ErrSubscribeUnsupported = wsclient.ErrSubscribeUnsupported
+
+// ErrResponseTimeout is returned when a query gets no complete response
+// within the response timeout.
+var ErrResponseTimeout = wsclient.ErrResponseTimeout
+
+// ErrSendQueueFull is returned when a query cannot be queued for sending
+// under the policy set with WithSendQueue.
+var ErrSendQueueFull = wsclient.ErrSendQueueFull
+
+// ErrDuplicateMismatch is returned when a redelivered sub-batch differs from
+// the first delivery under wsclient.DuplicateError.
+var ErrDuplicateMismatch = wsclient.ErrDuplicateMismatch
+
+// ErrConnectionLost matches errors of queries cut off by a lost connection;
+// use errors.As with *ConnectionLostError for details.
+var ErrConnectionLost = wsclient.ErrConnectionLost
+
+// ConnectionLostError fails a query that was pending when the connection
+// dropped.
+type ConnectionLostError = wsclient.ConnectionLostError
+
+// ErrPanic matches errors of queries failed by a panic recovered in the
+// websocket client; use errors.As with *PanicError for the stack.
+var ErrPanic = wsclient.ErrPanic
+
+// PanicError reports a panic recovered in the websocket client.
+type PanicError = wsclient.PanicError
+
+// ErrCorruptBatch is returned when a result batch does not match the row
+// count or checksum the server reported for it.
+var ErrCorruptBatch = wsclient.ErrCorruptBatch
+
+// ErrClosedByServer matches errors of queries cut off because the server
+// closed the connection; use errors.As with *CloseError for the close code
+// and reason.
+var ErrClosedByServer = wsclient.ErrClosedByServer
+
+// ErrThrottled matches the ThrottledError of queries and connections the
+// server throttled; RetryAfter returns the wait it asked for.
+var ErrThrottled = wsclient.ErrThrottled
+
+// ThrottledError is returned when the server signals throttling.
+type ThrottledError = wsclient.ThrottledError
+
+// CloseError holds the close code and reason the server sent when it closed
+// the connection.
+type CloseError = wsclient.CloseError
+
+// ErrShutdownIncomplete matches the ShutdownError returned when a shutdown
+// deadline passes before everything stopped.
+var ErrShutdownIncomplete = wsclient.ErrShutdownIncomplete
+
+// ShutdownError lists what was still running when Shutdown gave up waiting.
+type ShutdownError = wsclient.ShutdownError
@@ -0,0 +1,51 @@
+package boilingdata
+
+import (
+	"strings"
+)
+
+// PlanNode is one row of an EXPLAIN result, e.g. the physical plan.
+type PlanNode struct {
+	Key  string `json:"explain_key"`
+	Plan string `json:"explain_value"`
+}
+
+// QueryPlan is the plan the server would execute for a query.
+type QueryPlan struct {
+	SQL   string
+	Nodes []PlanNode
+}
+
+// String returns the plan text, one section per node.
+func (plan *QueryPlan) String() string {
+	var b strings.Builder
+	for i, node := range plan.Nodes {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if node.Key != "" {
+			b.WriteString(node.Key + ":\n")
+		}
+		b.WriteString(node.Plan)
+	}
+	return b.String()
+}
+
+// Explain returns the plan for sql without running it, so tools can show
+// what a query would scan first. sql must be a single statement; otherwise
+// ErrNotSingleStatement is returned and nothing is sent.
+func (instance *Instance) Explain(sql string) (*QueryPlan, error) {
+	statement, err := singleStatement(sql)
+	if err != nil {
+		return nil, err
+	}
+	response, err := instance.querySQL(explainPrefix + statement + ";")
+	if err != nil {
+		return nil, err
+	}
+	plan := &QueryPlan{SQL: statement}
+	if err := decodeRows(response.Data, &plan.Nodes); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
@@ -0,0 +1,134 @@
+package boilingdata
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// Format is the output format of QueryTo.
+type Format int
+
+const (
+	// FormatNDJSON writes one JSON object per row and line.
+	FormatNDJSON Format = iota
+	// FormatCSV writes a header line followed by one line per row.
+	FormatCSV
+)
+
+// QueryTo runs the query and writes its rows to w as they arrive, without
+// collecting the result in memory, e.g. for large exports. Rows are written
+// in the order frames arrive. The result cache, deduplication and
+// interceptors are bypassed.
+func (instance *Instance) QueryTo(w io.Writer, format Format, payloadMessage []byte) error {
+	return instance.QueryToContext(context.Background(), w, format, payloadMessage)
+}
+
+// QueryToContext is like QueryTo but stops when ctx is done.
+func (instance *Instance) QueryToContext(ctx context.Context, w io.Writer, format Format, payloadMessage []byte) error {
+	writer := newRowWriter(w, format)
+	if err := instance.stream(ctx, payloadMessage, writer.write); err != nil {
+		return err
+	}
+	return writer.flush()
+}
+
+// stream sends the query and passes its frames to fn as they arrive. A
+// result the server wrote to S3 is downloaded and passed on in chunks.
+func (instance *Instance) stream(ctx context.Context, payloadMessage []byte, fn wsclient.FrameFunc) error {
+	var payload message.Payload
+	if err := json.Unmarshal(payloadMessage, &payload); err != nil {
+		return fmt.Errorf("error unmarshalling Payload : %w", err)
+	}
+	end, err := instance.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+	wsc := instance.client(ctx)
+	if wsc.IsWebSocketClosed() {
+		if err := instance.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if err := wsc.SendStreamContext(ctx, payloadMessage, payload, fn); err != nil {
+		return err
+	}
+	response, err := wsc.WaitStream(ctx, payload.RequestID)
+	if err != nil {
+		return err
+	}
+	if response.ResultURL != "" {
+		return instance.streamResult(ctx, response, fn)
+	}
+	return nil
+}
+
+// rowWriter writes streamed rows in a Format.
+type rowWriter struct {
+	format  Format
+	out     *bufio.Writer
+	csv     *csv.Writer
+	columns []string
+	record  []string
+}
+
+func newRowWriter(w io.Writer, format Format) *rowWriter {
+	writer := &rowWriter{format: format, out: bufio.NewWriter(w)}
+	if format == FormatCSV {
+		writer.csv = csv.NewWriter(writer.out)
+	}
+	return writer
+}
+
+func (writer *rowWriter) write(header *message.Response, rows []json.RawMessage) error {
+	if writer.format == FormatNDJSON {
+		for _, row := range rows {
+			if _, err := writer.out.Write(row); err != nil {
+				return err
+			}
+			if err := writer.out.WriteByte('\n'); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if writer.columns == nil && len(header.Keys) > 0 {
+		writer.columns = header.Keys
+		writer.record = make([]string, len(writer.columns))
+		if err := writer.csv.Write(writer.columns); err != nil {
+			return err
+		}
+	}
+	for _, row := range rows {
+		var values map[string]interface{}
+		if err := newDecoder(row, true).Decode(&values); err != nil {
+			return fmt.Errorf("Error parsing JSON: %w", err)
+		}
+		for i, column := range writer.columns {
+			if err := convertAssign(&writer.record[i], values[column]); err != nil {
+				return err
+			}
+		}
+		if err := writer.csv.Write(writer.record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (writer *rowWriter) flush() error {
+	if writer.csv != nil {
+		writer.csv.Flush()
+		if err := writer.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return writer.out.Flush()
+}
@@ -0,0 +1,167 @@
+package boilingdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/boilingdata/go-boilingdata/constants"
+)
+
+// FederatedLogin signs in a user of an external OIDC or SAML identity
+// provider federated with the BoilingData user pool. The user signs in
+// through the user pool's hosted UI, which redirects to RedirectURI with an
+// authorization code; the code is exchanged for user pool tokens at the
+// pool domain's token endpoint and the tokens are refreshed from then on.
+type FederatedLogin struct {
+	// TokenURL is the token endpoint of the user pool domain, e.g.
+	// https://auth.example.com/oauth2/token.
+	TokenURL string
+	// ClientID is the app client the code was issued to; it defaults to
+	// constants.ClientID.
+	ClientID string
+	// RedirectURI is the redirect URI used to obtain Code.
+	RedirectURI string
+	// Code is the authorization code, which can be exchanged only once.
+	Code string
+	// CodeVerifier is the PKCE verifier, when the code was requested with a
+	// code challenge.
+	CodeVerifier string
+}
+
+type federation struct {
+	login FederatedLogin
+	used  bool
+}
+
+// tokenResponse is the token endpoint response.
+type tokenResponse struct {
+	IDToken      string `json:"id_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	TokenType    string `json:"token_type"`
+	Error        string `json:"error"`
+}
+
+// GetInstanceWithFederatedLogin returns a new Instance for userName that
+// signs in with login instead of a password, for SSO only accounts. Like
+// NewInstance, the Instance is not registered for userName.
+func GetInstanceWithFederatedLogin(userName string, login FederatedLogin, opts ...Option) *Instance {
+	instance := NewInstance(userName, nil, opts...)
+	instance.Auth.federation = &federation{login: login}
+	return instance
+}
+
+// federatedToken returns an ID token from the token endpoint, redeeming the
+// authorization code on the first call and the refresh token afterwards.
+// Callers hold muLock.
+func (auth *Auth) federatedToken() (string, error) {
+	login := auth.federation.login
+	if login.TokenURL == "" {
+		return "", errors.New("UnAuthorized")
+	}
+	clientID := login.ClientID
+	if clientID == "" {
+		clientID = constants.ClientID
+	}
+	form := url.Values{"client_id": {clientID}}
+	switch {
+	case auth.authResult != nil && auth.authResult.RefreshToken != nil:
+		log.Println("Token expired, Getting token with refresh token..")
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", *auth.authResult.RefreshToken)
+	case !auth.federation.used && login.Code != "":
+		log.Println("Exchanging authorization code..")
+		form.Set("grant_type", "authorization_code")
+		form.Set("code", login.Code)
+		form.Set("redirect_uri", login.RedirectURI)
+		if login.CodeVerifier != "" {
+			form.Set("code_verifier", login.CodeVerifier)
+		}
+		auth.federation.used = true
+	default:
+		return "", errors.New("UnAuthorized: federated session expired, sign in again")
+	}
+	token, err := auth.postTokenForm(context.Background(), login.TokenURL, form)
+	if err != nil {
+		auth.authResult = nil
+		return "", fmt.Errorf("Error exchanging federated token: %w", err)
+	}
+	auth.setTokens(token)
+	log.Println("Authentication successful")
+	return token.IDToken, nil
+}
+
+// setTokens stores the tokens of a token endpoint response, keeping the
+// current refresh token when the response has none. Callers hold muLock.
+func (auth *Auth) setTokens(token *tokenResponse) {
+	refreshToken := token.RefreshToken
+	if refreshToken == "" && auth.authResult != nil && auth.authResult.RefreshToken != nil {
+		// Refresh grants do not return a new refresh token.
+		refreshToken = *auth.authResult.RefreshToken
+	}
+	auth.timeWhenLastJwtTokenWasRecieved = time.Now()
+	auth.authResult = &cognitoidentityprovider.AuthenticationResultType{
+		IdToken:     aws.String(token.IDToken),
+		AccessToken: aws.String(token.AccessToken),
+		ExpiresIn:   aws.Int64(token.ExpiresIn),
+		TokenType:   aws.String(token.TokenType),
+	}
+	if refreshToken != "" {
+		auth.authResult.RefreshToken = aws.String(refreshToken)
+	}
+}
+
+// tokenError is an error response of the token endpoint; Code is the OAuth
+// error code, such as authorization_pending, and empty when the response
+// had none.
+type tokenError struct {
+	Status     string
+	StatusCode int
+	Code       string
+}
+
+func (e *tokenError) Error() string {
+	return fmt.Sprintf("token endpoint returned %s: %s", e.Status, e.Code)
+}
+
+func (auth *Auth) postTokenForm(ctx context.Context, tokenURL string, form url.Values) (*tokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := auth.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, &tokenError{Status: resp.Status, StatusCode: resp.StatusCode}
+		}
+		return nil, fmt.Errorf("unexpected token response (%s): %w", resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return nil, &tokenError{Status: resp.Status, StatusCode: resp.StatusCode, Code: token.Error}
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("empty ID token")
+	}
+	return &token, nil
+}
@@ -0,0 +1,104 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// Statuses of a HistoryEntry.
+const (
+	StatusOK        = "ok"
+	StatusTruncated = "truncated"
+	StatusCancelled = "cancelled"
+	StatusError     = "error"
+)
+
+// HistoryEntry is a query kept in the history of an Instance.
+type HistoryEntry struct {
+	RequestID string
+	SQL       string
+	Start     time.Time
+	Duration  time.Duration
+	Rows      int
+	// Status is StatusOK, StatusTruncated, StatusCancelled or StatusError,
+	// with Err set for the last two.
+	Status string
+	Err    error
+}
+
+// queryHistory is a ring of the most recent queries.
+type queryHistory struct {
+	mu      sync.Mutex
+	entries []HistoryEntry
+	next    int
+	full    bool
+}
+
+// WithHistory keeps the last size SQL queries in memory, for Instance.History,
+// e.g. to serve an admin endpoint or a REPL \history command. The SQL is
+// passed through the redactor set with WithRedactor.
+func WithHistory(size int) Option {
+	return func(instance *Instance) {
+		if size > 0 {
+			instance.history = &queryHistory{entries: make([]HistoryEntry, size)}
+		}
+	}
+}
+
+func (h *queryHistory) add(entry HistoryEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries[h.next] = entry
+	h.next = (h.next + 1) % len(h.entries)
+	h.full = h.full || h.next == 0
+}
+
+// list returns the entries, oldest first.
+func (h *queryHistory) list() []HistoryEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.full {
+		return append([]HistoryEntry(nil), h.entries[:h.next]...)
+	}
+	return append(append([]HistoryEntry(nil), h.entries[h.next:]...), h.entries[:h.next]...)
+}
+
+// History returns the recent queries kept with WithHistory, oldest first,
+// or nil without it.
+func (instance *Instance) History() []HistoryEntry {
+	if instance.history == nil {
+		return nil
+	}
+	return instance.history.list()
+}
+
+// remember adds the query of payload, started at start, to the history.
+func (instance *Instance) remember(payload message.Payload, start time.Time, response *message.Response, err error) {
+	if instance.history == nil {
+		return
+	}
+	entry := HistoryEntry{
+		RequestID: payload.RequestID,
+		SQL:       instance.redactor.Redact(payload.SQL),
+		Start:     start,
+		Duration:  time.Since(start),
+		Status:    StatusOK,
+		Err:       err,
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		entry.Status = StatusCancelled
+	case err != nil:
+		entry.Status = StatusError
+	case response != nil:
+		entry.Rows = response.RowCount()
+		if response.Truncated {
+			entry.Status = StatusTruncated
+		}
+	}
+	instance.history.add(entry)
+}
@@ -0,0 +1,89 @@
+package boilingdata
+
+import (
+	"sync"
+
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// hooks holds the lifecycle callbacks registered on an Instance. Callbacks
+// run synchronously on the goroutine that observed the event and must not
+// block.
+type hooks struct {
+	mu           sync.RWMutex
+	onConnect    []func()
+	onDisconnect []func(err error)
+	onAuth       []func()
+	onError      []func(err error)
+	onAudit      []func(record AuditRecord)
+}
+
+// OnConnect registers fn to be called whenever a connection is established.
+func (instance *Instance) OnConnect(fn func()) {
+	instance.hooks.mu.Lock()
+	defer instance.hooks.mu.Unlock()
+	instance.hooks.onConnect = append(instance.hooks.onConnect, fn)
+}
+
+// OnDisconnect registers fn to be called whenever a connection closes. err
+// is the error that closed it, or nil when it was closed by the client, for
+// example after the idle timeout.
+func (instance *Instance) OnDisconnect(fn func(err error)) {
+	instance.hooks.mu.Lock()
+	defer instance.hooks.mu.Unlock()
+	instance.hooks.onDisconnect = append(instance.hooks.onDisconnect, fn)
+}
+
+// OnAuthRefresh registers fn to be called whenever a new ID token is
+// obtained, by login, refresh or token exchange.
+func (instance *Instance) OnAuthRefresh(fn func()) {
+	instance.hooks.mu.Lock()
+	defer instance.hooks.mu.Unlock()
+	instance.hooks.onAuth = append(instance.hooks.onAuth, fn)
+}
+
+// OnError registers fn to be called with every query and connection error.
+func (instance *Instance) OnError(fn func(err error)) {
+	instance.hooks.mu.Lock()
+	defer instance.hooks.mu.Unlock()
+	instance.hooks.onError = append(instance.hooks.onError, fn)
+}
+
+// connectionListener forwards connection events of wsc to the hooks.
+func (instance *Instance) connectionListener() wsclient.ConnectionListener {
+	return func(connected bool, err error) {
+		h := instance.hooks
+		h.mu.RLock()
+		defer h.mu.RUnlock()
+		if connected {
+			for _, fn := range h.onConnect {
+				fn()
+			}
+			return
+		}
+		for _, fn := range h.onDisconnect {
+			fn(err)
+		}
+		if err != nil {
+			for _, fn := range h.onError {
+				fn(err)
+			}
+		}
+	}
+}
+
+func (instance *Instance) authRefreshed() {
+	instance.hooks.mu.RLock()
+	defer instance.hooks.mu.RUnlock()
+	for _, fn := range instance.hooks.onAuth {
+		fn()
+	}
+}
+
+func (instance *Instance) reportError(err error) {
+	instance.hooks.mu.RLock()
+	defer instance.hooks.mu.RUnlock()
+	for _, fn := range instance.hooks.onError {
+		fn(err)
+	}
+}
@@ -0,0 +1,114 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// idempotentQuery is the execution of a query under an idempotency key.
+type idempotentQuery struct {
+	done      chan struct{}
+	response  *message.Response
+	err       error
+	expiresAt time.Time
+}
+
+// idempotencyStore is the client-side dedupe layer of WithIdempotencyKeys.
+// It remembers the outcome of every query sent with an idempotency key for
+// ttl, so the query repeated under the same key, e.g. by a caller retrying
+// after an ambiguous failure, is answered from that outcome instead of
+// running again. The key is never sent; only queries sent through the same
+// Instance are deduplicated.
+type idempotencyStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	queries map[string]*idempotentQuery
+}
+
+func newIdempotencyStore(ttl time.Duration) *idempotencyStore {
+	return &idempotencyStore{ttl: ttl, queries: make(map[string]*idempotentQuery)}
+}
+
+// do runs fn unless a query under the key of payload ran within the TTL or
+// is running, in which case it returns a copy of that outcome. Failures
+// that show the query never reached the server are not remembered, so it
+// can be retried under the same key.
+func (s *idempotencyStore) do(ctx context.Context, payload message.Payload, fn func() (*message.Response, error)) (*message.Response, error) {
+	key := payload.IdempotencyKey
+	for {
+		s.mu.Lock()
+		s.expire()
+		query, ok := s.queries[key]
+		if !ok {
+			break
+		}
+		s.mu.Unlock()
+		select {
+		case <-query.done:
+		case <-ctx.Done():
+			return &message.Response{}, ctx.Err()
+		}
+		if unsent(query.err) {
+			continue
+		}
+		response := cloneResponse(query.response)
+		if response != nil {
+			response.RequestID = payload.RequestID
+		}
+		return response, query.err
+	}
+	query := &idempotentQuery{done: make(chan struct{})}
+	s.queries[key] = query
+	s.mu.Unlock()
+
+	response, err := fn()
+	s.mu.Lock()
+	query.response, query.err = cloneResponse(response), err
+	query.expiresAt = time.Now().Add(s.ttl)
+	if unsent(err) {
+		delete(s.queries, key)
+	}
+	s.mu.Unlock()
+	close(query.done)
+	return response, err
+}
+
+// expire forgets the queries whose TTL has passed; s.mu must be held.
+func (s *idempotencyStore) expire() {
+	now := time.Now()
+	for key, query := range s.queries {
+		if !query.expiresAt.IsZero() && now.After(query.expiresAt) {
+			delete(s.queries, key)
+		}
+	}
+}
+
+// unsent reports whether err shows a query failed before it was sent, so
+// running it again cannot execute it twice.
+func unsent(err error) bool {
+	if err == nil || errors.Is(err, ErrConnectionLost) {
+		return false
+	}
+	return errors.Is(err, ErrCircuitOpen) ||
+		errors.Is(err, ErrInstanceClosed) ||
+		errors.Is(err, ErrPayloadTooLarge) ||
+		errors.Is(err, ErrTooManyInFlight) ||
+		errors.Is(err, ErrRateLimited) ||
+		errors.Is(err, ErrSendQueueFull) ||
+		errors.Is(err, ErrThrottled)
+}
+
+// queryOnce runs an SQL query, through the idempotency layer when it is
+// enabled and the payload carries a key.
+func (instance *Instance) queryOnce(ctx context.Context, payloadMessage []byte, payload message.Payload) (*message.Response, error) {
+	if instance.idempotency == nil || payload.IdempotencyKey == "" {
+		return instance.querySQLPayload(ctx, payloadMessage, payload)
+	}
+	return instance.idempotency.do(ctx, payload, func() (*message.Response, error) {
+		return instance.querySQLPayload(ctx, payloadMessage, payload)
+	})
+}
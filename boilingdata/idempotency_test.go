@@ -0,0 +1,72 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+func TestIdempotencyStoreRunsOnce(t *testing.T) {
+	store := newIdempotencyStore(time.Hour)
+	payload := message.Payload{RequestID: "first"}.WithIdempotencyKey("k")
+	runs := 0
+	fn := func() (*message.Response, error) {
+		runs++
+		return &message.Response{RequestID: "first", Data: []map[string]interface{}{{"v": 1.0}}}, ErrConnectionLost
+	}
+	store.do(context.Background(), payload, fn)
+	payload.RequestID = "retry"
+	response, err := store.do(context.Background(), payload, fn)
+	if runs != 1 {
+		t.Fatalf("query ran %d times, want 1", runs)
+	}
+	if !errors.Is(err, ErrConnectionLost) || response.RequestID != "retry" || response.Data[0]["v"] != 1.0 {
+		t.Errorf("retry got %+v, %v, want the first outcome for request retry", response, err)
+	}
+}
+
+func TestIdempotencyStoreForgetsUnsent(t *testing.T) {
+	store := newIdempotencyStore(time.Hour)
+	payload := message.Payload{}.WithIdempotencyKey("k")
+	runs := 0
+	store.do(context.Background(), payload, func() (*message.Response, error) {
+		runs++
+		return &message.Response{}, ErrCircuitOpen
+	})
+	store.do(context.Background(), payload, func() (*message.Response, error) {
+		runs++
+		return &message.Response{}, nil
+	})
+	if runs != 2 {
+		t.Errorf("query ran %d times, want a retry after a failure before sending", runs)
+	}
+}
+
+func TestIdempotencyStoreExpires(t *testing.T) {
+	store := newIdempotencyStore(-time.Second)
+	payload := message.Payload{}.WithIdempotencyKey("k")
+	runs := 0
+	fn := func() (*message.Response, error) {
+		runs++
+		return &message.Response{}, nil
+	}
+	store.do(context.Background(), payload, fn)
+	store.do(context.Background(), payload, fn)
+	if runs != 2 {
+		t.Errorf("query ran %d times, want 2 once the key expired", runs)
+	}
+}
+
+func TestWithoutClientFields(t *testing.T) {
+	payload := message.Payload{MessageType: message.SQLQueryMessage, SQL: "DELETE FROM t"}.WithReadOnly(false).WithIdempotencyKey("k")
+	payloadMessage, err := withoutClientFields([]byte(`{"messageType":"SQL_QUERY","sql":"DELETE FROM t","readOnly":false,"idempotencyKey":"k"}`), payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(payloadMessage); got != `{"messageType":"SQL_QUERY","sql":"DELETE FROM t"}` {
+		t.Errorf("sent %s", got)
+	}
+}
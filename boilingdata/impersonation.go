@@ -0,0 +1,79 @@
+package boilingdata
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
+	"github.com/boilingdata/go-boilingdata/constants"
+)
+
+// ExchangedToken is a user scoped ID token obtained through a TokenExchanger.
+// A zero ExpiresAt is taken from the token's exp claim, or else
+// constants.ExchangedTokenLifetime from now.
+type ExchangedToken struct {
+	IDToken   string
+	ExpiresAt time.Time
+}
+
+// TokenExchanger exchanges a privileged service token for a session scoped
+// to subject, the end user the queries are run on behalf of.
+type TokenExchanger interface {
+	Exchange(serviceToken string, subject string) (ExchangedToken, error)
+}
+
+// TokenExchangerFunc adapts a function to the TokenExchanger interface.
+type TokenExchangerFunc func(serviceToken string, subject string) (ExchangedToken, error)
+
+func (f TokenExchangerFunc) Exchange(serviceToken string, subject string) (ExchangedToken, error) {
+	return f(serviceToken, subject)
+}
+
+type impersonation struct {
+	serviceToken string
+	exchanger    TokenExchanger
+}
+
+// GetInstanceForSubject returns a new Instance that runs queries as subject
+// using tokens obtained by exchanging serviceToken, so no user password is
+// needed. The exchange is repeated whenever the scoped token expires. Like
+// NewInstance, the Instance is not registered for subject.
+func GetInstanceForSubject(subject string, serviceToken string, exchanger TokenExchanger, opts ...Option) *Instance {
+	instance := NewInstance(subject, nil, opts...)
+	instance.Auth.impersonation = &impersonation{serviceToken: serviceToken, exchanger: exchanger}
+	return instance
+}
+
+// exchangeToken obtains a new scoped token. Callers hold muLock.
+func (auth *Auth) exchangeToken() (string, error) {
+	if auth.impersonation.exchanger == nil || auth.impersonation.serviceToken == "" {
+		return "", errors.New("UnAuthorized")
+	}
+	log.Println("Exchanging service token for", auth.userName)
+	token, err := auth.impersonation.exchanger.Exchange(auth.impersonation.serviceToken, auth.userName)
+	if err != nil {
+		auth.authResult = nil
+		return "", fmt.Errorf("Error exchanging service token: %w", err)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("Error exchanging service token: empty ID token")
+	}
+	now := time.Now()
+	expiresAt := token.ExpiresAt
+	if expiresAt.IsZero() {
+		if claims, err := ParseIDToken(token.IDToken); err == nil && !claims.ExpiresAt.IsZero() {
+			expiresAt = claims.ExpiresAt
+		} else {
+			expiresAt = now.Add(constants.ExchangedTokenLifetime)
+		}
+	}
+	auth.timeWhenLastJwtTokenWasRecieved = now
+	auth.authResult = &cognitoidentityprovider.AuthenticationResultType{
+		IdToken:   aws.String(token.IDToken),
+		ExpiresIn: aws.Int64(int64(expiresAt.Sub(now) / time.Second)),
+	}
+	return token.IDToken, nil
+}
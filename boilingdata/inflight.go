@@ -0,0 +1,52 @@
+package boilingdata
+
+import (
+	"context"
+	"sort"
+
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// QueryInfo describes a query waiting for its response.
+type QueryInfo = wsclient.QueryInfo
+
+// connections returns the connections of the Instance.
+func (instance *Instance) connections() []*wsclient.WSSClient {
+	return []*wsclient.WSSClient{instance.Wsc}
+}
+
+// InFlight returns the queries of the Instance still waiting for their
+// response, oldest first, e.g. for a dashboard or to cancel stragglers
+// with CancelQuery.
+func (instance *Instance) InFlight() []QueryInfo {
+	var infos []QueryInfo
+	for _, wsc := range instance.connections() {
+		infos = append(infos, wsc.InFlight()...)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Started.Before(infos[j].Started)
+	})
+	return infos
+}
+
+// QueueDepth returns the number of messages waiting to be sent.
+func (instance *Instance) QueueDepth() int {
+	depth := 0
+	for _, wsc := range instance.connections() {
+		depth += wsc.QueueDepth()
+	}
+	return depth
+}
+
+// CancelQuery fails the in-flight query requestID with context.Canceled and,
+// on servers supporting it, asks the server to stop it. It reports whether
+// the query was found.
+func (instance *Instance) CancelQuery(requestID string) bool {
+	for _, wsc := range instance.connections() {
+		if wsc.HasRequest(requestID) {
+			wsc.CancelRequest(requestID, context.Canceled)
+			return true
+		}
+	}
+	return false
+}
@@ -1,21 +1,58 @@
 package boilingdata
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"sync"
+	"time"
 
 	"github.com/boilingdata/go-boilingdata/constants"
 	message "github.com/boilingdata/go-boilingdata/messages"
 	"github.com/boilingdata/go-boilingdata/wsclient"
-	"github.com/golang-jwt/jwt/v4"
 	cmap "github.com/orcaman/concurrent-map"
 )
 
 type Instance struct {
-	Wsc  *wsclient.WSSClient
-	Auth *Auth
+	Wsc                *wsclient.WSSClient
+	Auth               *Auth
+	cache              *resultCache
+	cacheLimit         *int
+	inflight           *queryGroup
+	idempotency        *idempotencyStore
+	wsOptions          []wsclient.Option
+	backoff            Backoff
+	maxConnectAttempts int
+	authTimeout        time.Duration
+	history            *queryHistory
+	interceptors       []Interceptor
+	downloadParts      int
+	downloadPartSize   int64
+	breaker            *circuitBreaker
+	rest               *restTransport
+	serverCache        *serverCacheCounters
+	sessionOptions     *sessionOptions
+	lifecycle          *lifecycle
+	hooks              *hooks
+	resubmits          int
+	requestIDFunc      RequestIDFunc
+	preciseNumbers     bool
+	describeCache      *describeCache
+	phase              *connectionPhase
+	usage              *usageCounters
+	// offline skips authentication, for replayed sessions.
+	offline       bool
+	inFlightLimit *inFlightLimit
+	slowLog       *slowQueryLog
+	latency       *latencyHistogram
+	redactor      wsclient.Redactor
+	reconnect     *reconnectPolicy
+	signing       *flight[http.Header]
 }
 
 var queryServiceMap = cmap.New()
@@ -24,27 +61,14 @@ var muLock sync.Mutex
 func GetInstanceByToken(token string) (*Instance, error) {
 	muLock.Lock()
 	defer muLock.Unlock()
-	// Parse the token
-	jwtToken, _ := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		// Provide the secret key used to sign the token
-		return []byte(""), nil
-	})
-	// Check for errors
-	// if err != nil {
-	// 	return nil, fmt.Errorf("Error parsing token:", err)
-	// }
-	// Check if the token is valid
-	var userName string
-	if claims, ok := jwtToken.Claims.(jwt.MapClaims); ok {
-		// Access individual claims
-		userName, ok = claims["email"].(string)
-		if !ok {
-			return nil, fmt.Errorf("Failed to convert username claim to string")
-		}
-	} else {
-		return nil, fmt.Errorf("Invalid token claims")
+	claims, err := ParseIDToken(token)
+	if err != nil {
+		return nil, err
+	}
+	userName := claims.Email
+	if userName == "" {
+		return nil, fmt.Errorf("Failed to convert username claim to string")
 	}
-	// End parsing token
 
 	qs, ok := queryServiceMap.Get(userName)
 	if !ok {
@@ -53,52 +77,412 @@ func GetInstanceByToken(token string) (*Instance, error) {
 	return qs.(*Instance), nil
 }
 
-func GetInstance(userName string, password string) *Instance {
+// GetInstance returns the Instance registered for userName, creating it when
+// needed. Options are only applied when a new Instance is created.
+func GetInstance(userName string, password string, opts ...Option) *Instance {
+	var provider CredentialsProvider
+	if password != "" {
+		provider = NewStaticCredentialsProvider(userName, password)
+	}
+	return GetInstanceWithProvider(userName, provider, opts...)
+}
+
+// GetInstanceWithProvider is like GetInstance but asks provider for the
+// password only when a login actually needs it.
+func GetInstanceWithProvider(userName string, provider CredentialsProvider, opts ...Option) *Instance {
 	muLock.Lock()
 	defer muLock.Unlock()
 	qs, ok := queryServiceMap.Get(userName)
 	if !ok {
-		wsclient := wsclient.NewWSSClient(constants.WssUrl, 0, nil)
-		qs = &Instance{Wsc: wsclient, Auth: &Auth{userName: userName, password: password}}
+		qs = NewInstance(userName, provider, opts...)
 		queryServiceMap.Set(userName, qs)
 	}
 	return qs.(*Instance)
 }
 
+// NewInstance returns a new Instance without registering it for userName,
+// e.g. to use the same account against several endpoints.
+func NewInstance(userName string, provider CredentialsProvider, opts ...Option) *Instance {
+	instance := &Instance{
+		Auth:           &Auth{userName: userName, credentials: provider},
+		serverCache:    &serverCacheCounters{},
+		sessionOptions: &sessionOptions{},
+		lifecycle:      newLifecycle(),
+		hooks:          &hooks{},
+		phase:          &connectionPhase{},
+		usage:          &usageCounters{},
+		latency:        &latencyHistogram{},
+		signing:        &flight[http.Header]{},
+	}
+	instance.Auth.onRefresh = instance.authRefreshed
+	for _, opt := range opts {
+		opt(instance)
+	}
+	if instance.cache != nil && instance.cacheLimit != nil {
+		instance.cache.limit = max(*instance.cacheLimit, 0)
+	}
+	if instance.preciseNumbers {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithCodec(wsclient.NumberCodec))
+	}
+	instance.Wsc = wsclient.NewWSSClient(instance.Auth.wssURL(), 0, nil, instance.wsOptions...)
+	instance.Wsc.SetConnectionListener(instance.connectionListener())
+	instance.Wsc.SetHeaderSigner(instance.signHeader)
+	instance.Wsc.SetRotationListener(func() {
+		instance.replaySessionOptions(context.Background(), instance.Wsc)
+	})
+	instances.add(instance)
+	return instance
+}
+
 func RemoveUser(userName string) {
 	queryServiceMap.Remove(userName)
 }
 
+// CacheStats returns the result cache counters. It is zero when the cache is disabled.
+func (instance *Instance) CacheStats() CacheStats {
+	if instance.cache == nil {
+		return CacheStats{}
+	}
+	return instance.cache.stats()
+}
+
+// ServerCacheStats returns how many responses the server reported as served
+// from its cache, fully or partially, since the Instance was created.
+func (instance *Instance) ServerCacheStats() ServerCacheStats {
+	return instance.serverCache.stats()
+}
+
+// Query sends the payload and waits for its response. It is safe to call
+// concurrently; queries sharing the connection are routed by request ID, so
+// request IDs must be unique among in-flight queries.
 func (instance *Instance) Query(payloadMessage []byte) (*message.Response, error) {
-	// If web socket is closed, in case of timeout/user signout/os intruptions etc
-	if instance.Wsc.IsWebSocketClosed() {
-		idToken, err := instance.Auth.Authenticate()
-		if err != nil {
-			return &message.Response{}, fmt.Errorf("Error : " + err.Error())
+	return instance.QueryContext(context.Background(), payloadMessage)
+}
+
+// QueryContext is like Query but stops waiting when ctx is done. Its
+// partially received results are then dropped, and the query is cancelled
+// on servers supporting it.
+// Deduplicated callers share the context of the caller that sent the query.
+func (instance *Instance) QueryContext(ctx context.Context, payloadMessage []byte) (*message.Response, error) {
+	var payload message.Payload
+	if err := json.Unmarshal(payloadMessage, &payload); err != nil {
+		log.Println("error unmarshalling Payload : " + err.Error())
+		return &message.Response{}, fmt.Errorf("error unmarshalling Payload : %w", err)
+	}
+	start := time.Now()
+	if payload.MessageType != message.SQLQueryMessage {
+		response, err := instance.execute(ctx, payloadMessage, payload)
+		instance.observe(payload, start, err)
+		return response, err
+	}
+	response, err := instance.queryOnce(ctx, payloadMessage, payload)
+	instance.observe(payload, start, err)
+	instance.audit(payload, start, response, err)
+	instance.remember(payload, start, response, err)
+	return response, err
+}
+
+// querySQLPayload answers an SQL query from the result cache, an identical
+// in-flight query or the server.
+func (instance *Instance) querySQLPayload(ctx context.Context, payloadMessage []byte, payload message.Payload) (*message.Response, error) {
+	if wsclient.HasRowMapper(ctx) || wsclient.HasProjection(ctx) || pinned(ctx) {
+		// Mapped or projected rows differ from the query result, and the
+		// results of a session depend on its settings, so they are neither
+		// cached nor shared.
+		return instance.execute(ctx, payloadMessage, payload)
+	}
+	if instance.cache != nil && isReadOnly(payload) {
+		if cached, ok := instance.cache.get(payload.SQL); ok {
+			return withRequestID(cached, payload.RequestID), nil
 		}
-		header, err := instance.Auth.GetSignedWssHeader(idToken)
-		if err != nil {
-			return &message.Response{}, fmt.Errorf("Error Signing wssUrl: " + err.Error())
+	}
+	if instance.inflight != nil && isReadOnly(payload) {
+		if key, err := mergeKey(payloadMessage, payload); err == nil {
+			response, err, shared := instance.inflight.do(ctx, key, func() (*message.Response, error) {
+				return instance.execute(ctx, payloadMessage, payload)
+			})
+			if shared && err == nil {
+				response.RequestID = payload.RequestID
+			}
+			return response, err
 		}
-		instance.Wsc.SignedHeader = header
-		instance.Wsc.Connect()
-		if instance.Wsc.IsWebSocketClosed() {
-			return &message.Response{}, fmt.Errorf(instance.Wsc.Error)
+	}
+	return instance.execute(ctx, payloadMessage, payload)
+}
+
+// mergeKey identifies the queries WithQueryDeduplication may merge: the
+// whole payload as sent, with its SQL normalized and without the request
+// ID, so queries sent with different options are not merged.
+func mergeKey(payloadMessage []byte, payload message.Payload) (string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payloadMessage, &fields); err != nil {
+		return "", err
+	}
+	delete(fields, "requestId")
+	for _, field := range clientFields {
+		delete(fields, field)
+	}
+	sql, err := json.Marshal(normalizeSQL(payload.SQL))
+	if err != nil {
+		return "", err
+	}
+	fields["sql"] = sql
+	key, err := json.Marshal(fields)
+	return string(key), err
+}
+
+func (instance *Instance) execute(ctx context.Context, payloadMessage []byte, payload message.Payload) (*message.Response, error) {
+	end, err := instance.begin(ctx)
+	if err != nil {
+		return &message.Response{}, err
+	}
+	defer end()
+	response, err := instance.intercept(ctx, payloadMessage, payload)
+	if err != nil {
+		instance.reportError(err)
+	}
+	return response, err
+}
+
+// intercept runs the round trip through the interceptor chain.
+func (instance *Instance) intercept(ctx context.Context, payloadMessage []byte, payload message.Payload) (*message.Response, error) {
+	if len(instance.interceptors) == 0 {
+		return instance.roundTrip(ctx, payloadMessage, payload)
+	}
+	payloadMessage, fields, payload, err := instance.interceptOutgoing(payloadMessage)
+	if err != nil {
+		return &message.Response{}, err
+	}
+	response, err := instance.roundTrip(ctx, payloadMessage, payload)
+	return instance.interceptIncoming(fields, response, err)
+}
+
+// roundTrip sends the payload through the circuit breaker, if configured.
+func (instance *Instance) roundTrip(ctx context.Context, payloadMessage []byte, payload message.Payload) (*message.Response, error) {
+	if instance.breaker == nil {
+		return instance.send(ctx, payloadMessage, payload)
+	}
+	if err := instance.breaker.allow(); err != nil {
+		return &message.Response{}, err
+	}
+	response, err := instance.send(ctx, payloadMessage, payload)
+	instance.breaker.record(err)
+	return response, err
+}
+
+// send connects if needed and waits for the response to payload. With a
+// REST transport configured the query may go over HTTPS instead. Queries
+// cut off by a lost connection are resubmitted if configured and
+// read-only; throttled queries are resubmitted up to
+// constants.ThrottleRetries times after the wait the server asked for.
+func (instance *Instance) send(ctx context.Context, payloadMessage []byte, payload message.Payload) (*message.Response, error) {
+	payloadMessage, err := withoutClientFields(payloadMessage, payload)
+	if err != nil {
+		return &message.Response{}, err
+	}
+	response, err := instance.sendWebSocket(ctx, payloadMessage, payload)
+	for resubmits, throttles := 0, 0; err != nil; {
+		if errors.Is(err, ErrThrottled) && !errors.Is(err, ErrConnectionLost) {
+			if throttles >= constants.ThrottleRetries {
+				break
+			}
+			// The server rejected the query unexecuted; wait as it asked.
+			throttles++
+			if err := instance.waitThrottle(ctx, err, throttles); err != nil {
+				return &message.Response{}, err
+			}
+			log.Printf("Request %s was throttled, resubmitting (%d/%d)", payload.RequestID, throttles, constants.ThrottleRetries)
+		} else {
+			if resubmits >= instance.resubmits || !ambiguous(ctx, err) || !isReadOnly(payload) {
+				break
+			}
+			resubmits++
+			log.Printf("Request %s failed after it was sent (%v), resubmitting (%d/%d)", payload.RequestID, err, resubmits, instance.resubmits)
 		}
+		response, err = instance.sendWebSocket(ctx, payloadMessage, payload)
 	}
-	var payload message.Payload
-	if err := json.Unmarshal(payloadMessage, &payload); err != nil {
-		log.Println("error unmarshalling Payload : " + err.Error())
-		return &message.Response{}, fmt.Errorf("error unmarshalling Payload : " + err.Error())
-	}
-	instance.Wsc.SendMessage(payloadMessage, payload)
-	response, err := instance.Wsc.GetResponseSync(payload.RequestID)
-	if err != nil || response.Data == nil {
-		errorMessage := ""
-		if err != nil {
-			errorMessage = err.Error()
+	if err != nil {
+		return &message.Response{}, err
+	}
+	if response.IsRemote() {
+		if err := instance.downloadResult(ctx, response); err != nil {
+			return &message.Response{}, err
 		}
-		return &message.Response{}, fmt.Errorf(errorMessage)
+		if response.Data, err = wsclient.MapRows(ctx, response.Data); err != nil {
+			return &message.Response{}, err
+		}
+	}
+	if response.Data == nil {
+		return &message.Response{}, fmt.Errorf("No data in response from server")
+	}
+	instance.serverCache.record(response.CacheStatus())
+	instance.usage.record(response.Stats)
+	if instance.cache != nil && !wsclient.HasRowMapper(ctx) && !wsclient.HasProjection(ctx) && !pinned(ctx) && !response.Truncated && isReadOnly(payload) {
+		instance.cache.set(payload.SQL, response)
 	}
 	return response, nil
 }
+
+func (instance *Instance) sendWebSocket(ctx context.Context, payloadMessage []byte, payload message.Payload) (*message.Response, error) {
+	if instance.rest != nil && instance.rest.mode == RESTAlways {
+		return instance.restQuery(ctx, payloadMessage, payload)
+	}
+	wsc := instance.client(ctx)
+	// If web socket is closed, in case of timeout/user signout/os intruptions etc
+	if wsc.IsWebSocketClosed() {
+		if err := instance.connect(ctx); err != nil {
+			var dialErr *dialError
+			if instance.rest != nil && errors.As(err, &dialErr) {
+				log.Println("Websocket unavailable, falling back to REST:", err)
+				return instance.restQuery(ctx, payloadMessage, payload)
+			}
+			return &message.Response{}, err
+		}
+	}
+	if err := wsc.SendMessageContext(ctx, payloadMessage, payload); err != nil {
+		return &message.Response{}, err
+	}
+	return wsc.GetResponseContext(ctx, payload.RequestID)
+}
+
+// connect authenticates and dials the websocket, retrying failures as the
+// reconnect policy decides, by default those IsRetryable accepts, according
+// to the configured Backoff.
+func (instance *Instance) connect(ctx context.Context) error {
+	wsc := instance.client(ctx)
+	backoff := instance.backoff
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+	attempts := instance.maxConnectAttempts
+	if attempts <= 0 {
+		attempts = constants.MaxConnectAttempts
+	}
+	defer instance.clearPhase(wsc)
+	if wait := time.Until(wsc.ThrottledUntil()); wait > 0 {
+		log.Printf("Throttled by server, reconnecting in %s", wait)
+		if err := instance.sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+	switch instance.reconnect.closeAction(wsc.LastError()) {
+	case ReconnectGiveUp:
+		return fmt.Errorf("Not reconnecting: %w", wsc.LastError())
+	case ReconnectReauthenticate:
+		instance.Auth.expire()
+	}
+	for attempt := 1; ; attempt++ {
+		err := instance.dial(wsc)
+		if err == nil {
+			instance.replaySessionOptions(ctx, wsc)
+			return nil
+		}
+		action := instance.reconnect.dialAction(err, wsc.LastError())
+		if action == ReconnectGiveUp || attempt >= attempts {
+			return err
+		}
+		var delay time.Duration
+		switch action {
+		case ReconnectBackoff:
+			delay = max(backoff.Next(attempt), RetryAfter(err))
+		case ReconnectReauthenticate:
+			instance.Auth.expire()
+		}
+		log.Printf("Connect attempt %d failed, retrying in %s: %v", attempt, delay, err)
+		instance.setPhase(wsc, Reconnecting)
+		if err := instance.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// dial authenticates and makes a single attempt to open wsc.
+func (instance *Instance) dial(wsc *wsclient.WSSClient) error {
+	if !instance.offline {
+		instance.setPhase(wsc, Authenticating)
+		if err := instance.resign(wsc); err != nil {
+			return err
+		}
+		instance.clearPhase(wsc)
+	}
+	if err := wsc.ConnectE(); err != nil {
+		return &dialError{err: err}
+	}
+	return nil
+}
+
+// Resign refreshes the token if needed and signs a new websocket header
+// without touching the open connection, so a later reconnect does not have
+// to wait for authentication.
+func (instance *Instance) Resign() error {
+	return instance.resign(instance.Wsc)
+}
+
+func (instance *Instance) resign(wsc *wsclient.WSSClient) error {
+	header, err := instance.signHeaderWithin()
+	if err != nil {
+		return err
+	}
+	wsc.SetSignedHeader(header)
+	return nil
+}
+
+// signHeader authenticates and signs a websocket dial header. Concurrent
+// callers, e.g. the connections of a pool dialing at once, share one
+// signature.
+func (instance *Instance) signHeader() (http.Header, error) {
+	return instance.signing.do(instance.signHeaderOnce)
+}
+
+func (instance *Instance) signHeaderOnce() (http.Header, error) {
+	idToken, err := instance.Auth.Authenticate()
+	if err != nil {
+		return nil, fmt.Errorf("Error : %w", err)
+	}
+	header, err := instance.Auth.GetSignedWssHeader(idToken)
+	if err != nil {
+		return nil, fmt.Errorf("Error Signing wssUrl: %w", err)
+	}
+	return header, nil
+}
+
+// sendMessage sends a non SQL message and waits for the response to requestID.
+func (instance *Instance) sendMessage(messageType string, requestID string, payload interface{}) (*message.Response, error) {
+	payloadMessage, err := json.Marshal(payload)
+	if err != nil {
+		return &message.Response{}, fmt.Errorf("error marshalling Payload : %w", err)
+	}
+	return instance.execute(context.Background(), payloadMessage, message.Payload{MessageType: messageType, RequestID: requestID})
+}
+
+// withRequestID returns a shallow copy of response answering requestID.
+func withRequestID(response *message.Response, requestID string) *message.Response {
+	copied := *response
+	copied.RequestID = requestID
+	return &copied
+}
+
+// RequestIDFunc derives the request ID of a query the client builds itself,
+// e.g. from the trace and span IDs in ctx, to correlate it with server logs.
+// IDs must be unique among in-flight queries; an empty ID falls back to a
+// random one.
+type RequestIDFunc func(ctx context.Context) string
+
+// requestID returns the request ID for a query sent with ctx.
+func (instance *Instance) requestID(ctx context.Context) string {
+	if instance.requestIDFunc != nil {
+		if id := instance.requestIDFunc(ctx); id != "" {
+			return id
+		}
+	}
+	return newRequestID()
+}
+
+// newRequestID returns a random identifier used to correlate a query with its responses.
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("req-%d", time.Now().UnixNano())
+	}
+	return "req-" + hex.EncodeToString(b)
+}
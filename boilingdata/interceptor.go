@@ -0,0 +1,63 @@
+package boilingdata
+
+import (
+	"encoding/json"
+	"fmt"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// Interceptor observes or rewrites the messages exchanged for a query, for
+// example to inject tags, enforce policies or record audit events.
+//
+// Outgoing runs before the payload is sent, in registration order, and may
+// modify the decoded payload; returning an error aborts the query. Incoming
+// runs after the response (or error) is received, in reverse registration
+// order, and returns the response and error passed on to the caller. Either
+// function may be nil.
+type Interceptor struct {
+	Outgoing func(payload map[string]interface{}) error
+	Incoming func(payload map[string]interface{}, response *message.Response, err error) (*message.Response, error)
+}
+
+// WithInterceptor appends an interceptor to the Instance chain.
+func WithInterceptor(interceptor Interceptor) Option {
+	return func(instance *Instance) {
+		instance.interceptors = append(instance.interceptors, interceptor)
+	}
+}
+
+// interceptOutgoing runs the outgoing chain and returns the rewritten
+// payload with the decoded fields it needs to route the response.
+func (instance *Instance) interceptOutgoing(payloadMessage []byte) ([]byte, map[string]interface{}, message.Payload, error) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal(payloadMessage, &fields); err != nil {
+		return nil, nil, message.Payload{}, fmt.Errorf("error unmarshalling Payload : %w", err)
+	}
+	for _, interceptor := range instance.interceptors {
+		if interceptor.Outgoing == nil {
+			continue
+		}
+		if err := interceptor.Outgoing(fields); err != nil {
+			return nil, nil, message.Payload{}, err
+		}
+	}
+	rewritten, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, message.Payload{}, fmt.Errorf("error marshalling Payload : %w", err)
+	}
+	var payload message.Payload
+	if err := json.Unmarshal(rewritten, &payload); err != nil {
+		return nil, nil, message.Payload{}, fmt.Errorf("error unmarshalling Payload : %w", err)
+	}
+	return rewritten, fields, payload, nil
+}
+
+func (instance *Instance) interceptIncoming(fields map[string]interface{}, response *message.Response, err error) (*message.Response, error) {
+	for i := len(instance.interceptors) - 1; i >= 0; i-- {
+		if incoming := instance.interceptors[i].Incoming; incoming != nil {
+			response, err = incoming(fields, response, err)
+		}
+	}
+	return response, err
+}
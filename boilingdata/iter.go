@@ -0,0 +1,90 @@
+//go:build go1.23
+
+package boilingdata
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// QueryStream runs the query and returns an iterator over its rows as they
+// arrive, without collecting the result in memory:
+//
+//	for row, err := range instance.QueryStream(ctx, payloadMessage) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// The query is sent when iteration starts. A failure ends the iteration with
+// a nil row and the error. Breaking out of the loop cancels the query. Like
+// QueryTo it bypasses the result cache, deduplication and interceptors, and
+// the connection waits while the loop body runs.
+func (instance *Instance) QueryStream(ctx context.Context, payloadMessage []byte) iter.Seq2[message.Row, error] {
+	return func(yield func(message.Row, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		frames := make(chan []message.Row)
+		done := make(chan error, 1)
+		go func() {
+			done <- instance.stream(ctx, payloadMessage, func(header *message.Response, rows []json.RawMessage) error {
+				decoded := make([]message.Row, 0, len(rows))
+				for _, raw := range rows {
+					var row message.Row
+					if err := newDecoder(raw, instance.preciseNumbers).Decode(&row); err != nil {
+						return err
+					}
+					decoded = append(decoded, row)
+				}
+				select {
+				case frames <- decoded:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			})
+		}()
+		for {
+			select {
+			case rows := <-frames:
+				for _, row := range rows {
+					if !yield(row, nil) {
+						return
+					}
+				}
+			case err := <-done:
+				if err != nil {
+					yield(nil, err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the rows of the query once it has
+// completed; it blocks until then. A failed query yields a nil row and its
+// error. Use Instance.QueryStream to iterate while rows arrive.
+func (handle *QueryHandle) All() iter.Seq2[message.Row, error] {
+	return func(yield func(message.Row, error) bool) {
+		response, err := handle.Wait()
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for row, err := range response.All() {
+			if !yield(row, err) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over the rows of the result, for use with range.
+func (result *ResultSet) All() iter.Seq2[message.Row, error] {
+	return result.response.All()
+}
@@ -0,0 +1,74 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTooManyInFlight is returned for a query started while the in-flight
+// limit set with WithMaxInFlight is reached, unless the limit queues.
+var ErrTooManyInFlight = errors.New("too many queries in flight")
+
+// inFlightLimit caps the queries outstanding on an Instance.
+type inFlightLimit struct {
+	slots chan struct{}
+	wait  bool
+}
+
+// acquire takes a slot, waiting for one if the limit queues.
+func (l *inFlightLimit) acquire(ctx context.Context) error {
+	if !l.wait {
+		select {
+		case l.slots <- struct{}{}:
+			return nil
+		default:
+			return ErrTooManyInFlight
+		}
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *inFlightLimit) release() {
+	<-l.slots
+}
+
+// begin registers a query with the lifecycle and takes an in-flight slot
+// and the user's rate limit allowance. The returned end must be called when
+// the query is done.
+func (instance *Instance) begin(ctx context.Context) (end func(), err error) {
+	if err := instance.lifecycle.begin(); err != nil {
+		return nil, err
+	}
+	if instance.inFlightLimit != nil {
+		if err := instance.inFlightLimit.acquire(ctx); err != nil {
+			instance.lifecycle.end()
+			return nil, err
+		}
+	}
+	user := limiterFor(instance.Auth.userName)
+	if user != nil {
+		if err := user.acquire(ctx); err != nil {
+			instance.release()
+			return nil, err
+		}
+	}
+	return func() {
+		if user != nil {
+			user.release()
+		}
+		instance.release()
+	}, nil
+}
+
+// release undoes the lifecycle and in-flight parts of begin.
+func (instance *Instance) release() {
+	if instance.inFlightLimit != nil {
+		instance.inFlightLimit.release()
+	}
+	instance.lifecycle.end()
+}
@@ -0,0 +1,26 @@
+package boilingdata
+
+import (
+	"context"
+
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// RowMapper transforms or drops the rows of a query as they arrive; see
+// wsclient.RowMapper.
+type RowMapper = wsclient.RowMapper
+
+// ContextWithColumns keeps only the named columns of the rows of queries
+// run with ctx, skipping the others while decoding; see
+// wsclient.ContextWithColumns. Such queries bypass the result cache and
+// deduplication.
+func ContextWithColumns(ctx context.Context, columns ...string) context.Context {
+	return wsclient.ContextWithColumns(ctx, columns...)
+}
+
+// ContextWithRowMapper maps the rows of queries run with ctx through
+// mappers, chained after any already in ctx. Such queries bypass the result
+// cache and deduplication.
+func ContextWithRowMapper(ctx context.Context, mappers ...RowMapper) context.Context {
+	return wsclient.ContextWithRowMapper(ctx, mappers...)
+}
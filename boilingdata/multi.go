@@ -0,0 +1,73 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// OriginColumn is the column MultiInstance adds to every row, holding the
+// name of the member the row came from.
+const OriginColumn = "_origin"
+
+// Member is a named Instance of a MultiInstance, e.g. one per region or
+// account. Use NewInstance for members sharing an account, since GetInstance
+// returns the same Instance for a given user.
+type Member struct {
+	Name     string
+	Instance *Instance
+}
+
+// MultiInstance runs the same SQL on several instances in parallel and
+// merges the rows, for data split across regions or accounts.
+type MultiInstance struct {
+	members []Member
+}
+
+// NewMultiInstance returns a MultiInstance over members. Rows are merged in
+// member order.
+func NewMultiInstance(members ...Member) *MultiInstance {
+	return &MultiInstance{members: members}
+}
+
+// Query runs sql on every member and returns the merged rows, each tagged
+// with OriginColumn. When some members fail, the rows of the others are
+// still returned together with an error naming each failed member.
+func (multi *MultiInstance) Query(ctx context.Context, sql string) (*message.Response, error) {
+	responses := make([]*message.Response, len(multi.members))
+	errs := make([]error, len(multi.members))
+	var wg sync.WaitGroup
+	for i, member := range multi.members {
+		wg.Add(1)
+		go func(i int, member Member) {
+			defer wg.Done()
+			responses[i], errs[i] = member.Instance.queryContext(ctx, sql)
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("%s: %w", member.Name, errs[i])
+			}
+		}(i, member)
+	}
+	wg.Wait()
+
+	merged := &message.Response{MessageType: message.DATA.String()}
+	for i, response := range responses {
+		if errs[i] != nil || response == nil {
+			continue
+		}
+		if merged.Keys == nil && len(response.Data) > 0 {
+			merged.Keys = append([]string{OriginColumn}, response.Columns()...)
+		}
+		for _, row := range response.Data {
+			tagged := make(map[string]interface{}, len(row)+1)
+			for key, value := range row {
+				tagged[key] = value
+			}
+			tagged[OriginColumn] = multi.members[i].Name
+			merged.Data = append(merged.Data, tagged)
+		}
+	}
+	return merged, errors.Join(errs...)
+}
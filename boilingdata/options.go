@@ -0,0 +1,416 @@
+package boilingdata
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// Option configures an Instance when it is first created by GetInstance.
+type Option func(*Instance)
+
+// WithResultCache enables the client-side result cache. Results of identical
+// read-only SQL (after whitespace normalization) are served locally for ttl.
+// The cache holds up to constants.ResultCacheEntries results unless
+// WithResultCacheLimit changes it.
+func WithResultCache(ttl time.Duration) Option {
+	return func(instance *Instance) {
+		if ttl > 0 {
+			instance.cache = newResultCache(ttl)
+		}
+	}
+}
+
+// WithResultCacheLimit bounds the result cache to entries results, evicting
+// the least recently used; 0 removes the bound.
+func WithResultCacheLimit(entries int) Option {
+	return func(instance *Instance) {
+		instance.cacheLimit = &entries
+	}
+}
+
+// WithQueryDeduplication coalesces identical read-only queries submitted
+// concurrently on the Instance into a single server request shared by all
+// callers. Statements that may write are always sent on their own.
+func WithQueryDeduplication() Option {
+	return func(instance *Instance) {
+		instance.inflight = newQueryGroup()
+	}
+}
+
+// WithStatementCache remembers per statement, for the last size distinct
+// statements, the result column order and the DescribeQuery metadata, so
+// statements repeated by dashboards do not redo that work. Statements are
+// compared after collapsing whitespace.
+func WithStatementCache(size int) Option {
+	return func(instance *Instance) {
+		if size > 0 {
+			instance.describeCache = newDescribeCache(size)
+			instance.wsOptions = append(instance.wsOptions, wsclient.WithStatementCache(size))
+		}
+	}
+}
+
+// WithPreciseNumbers decodes numbers in results into json.Number instead of
+// float64, so BIGINT IDs above 2^53 and high-precision DECIMAL values are not
+// rounded. Rows.Scan, DecodeValue and the typed helpers accept json.Number.
+// It replaces any codec set with WithCodec, whatever the option order.
+func WithPreciseNumbers() Option {
+	return func(instance *Instance) {
+		instance.preciseNumbers = true
+	}
+}
+
+// WithCodec sets the JSON codec used to decode server responses.
+func WithCodec(codec wsclient.Codec) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithCodec(codec))
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for authentication and uploads,
+// so timeouts, proxies and transports match the rest of the application.
+func WithHTTPClient(client *http.Client) Option {
+	return func(instance *Instance) {
+		instance.Auth.SetHTTPClient(client)
+	}
+}
+
+// WithSubprotocols offers the given websocket subprotocols when connecting.
+// The negotiated value is available from Wsc.Subprotocol().
+func WithSubprotocols(protocols ...string) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithSubprotocols(protocols...))
+	}
+}
+
+// WithNetDialer dials the websocket endpoint with dialer, e.g. one with a
+// custom Resolver for DNS caching or split-horizon DNS.
+func WithNetDialer(dialer *net.Dialer) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithNetDialer(dialer))
+	}
+}
+
+// WithDialContext dials the websocket endpoint with dial, e.g. to force IPv4:
+//
+//	dialer := &net.Dialer{}
+//	boilingdata.WithDialContext(func(ctx context.Context, _ string, addr string) (net.Conn, error) {
+//		return dialer.DialContext(ctx, "tcp4", addr)
+//	})
+func WithDialContext(dial func(ctx context.Context, network string, addr string) (net.Conn, error)) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithDialContext(dial))
+	}
+}
+
+// WithKeepAlive configures websocket pings and the dead-connection timeout.
+func WithKeepAlive(pingInterval time.Duration, pongWait time.Duration) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithKeepAlive(pingInterval, pongWait))
+	}
+}
+
+// WithBackoff sets the delay strategy between reconnect attempts and the
+// maximum number of attempts made before a query fails.
+func WithBackoff(backoff Backoff, maxAttempts int) Option {
+	return func(instance *Instance) {
+		instance.backoff = backoff
+		instance.maxConnectAttempts = maxAttempts
+	}
+}
+
+// WithMaxMessageSize limits the size of a single frame read from the server.
+func WithMaxMessageSize(bytes int64) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithMaxMessageSize(bytes))
+	}
+}
+
+// WithMaxPayloadSize sets the size of the largest query message sent, see
+// wsclient.WithMaxPayloadSize.
+func WithMaxPayloadSize(bytes int) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithMaxPayloadSize(bytes))
+	}
+}
+
+// WithCompression compresses queries of at least threshold bytes when the
+// server supports it, see wsclient.WithCompression.
+func WithCompression(threshold int) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithCompression(threshold))
+	}
+}
+
+// WithDeadlines sets the websocket read and write deadlines.
+func WithDeadlines(read time.Duration, write time.Duration) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithDeadlines(read, write))
+	}
+}
+
+// WithKeepWarm keeps the connection warm with a no-op query after every
+// interval of inactivity.
+func WithKeepWarm(interval time.Duration) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithKeepWarm(interval))
+	}
+}
+
+// WithJitter randomizes the idle timeout and the connection rotation by up
+// to fraction, so that many instances with the same settings do not
+// reconnect at the same moment. Reconnect attempts are spread by the
+// Backoff's own jitter.
+func WithJitter(fraction float64) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithJitter(fraction))
+	}
+}
+
+// WithEndpoint connects to the given websocket URL instead of constants.WssUrl.
+func WithEndpoint(wssURL string) Option {
+	return func(instance *Instance) {
+		instance.Auth.endpoint = wssURL
+	}
+}
+
+// WithRegion sets the AWS region the websocket endpoint is signed for.
+func WithRegion(region string) Option {
+	return func(instance *Instance) {
+		instance.Auth.region = region
+	}
+}
+
+// WithIdleTimeout closes the connection after timeout without activity.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithIdleTimeout(timeout))
+	}
+}
+
+// WithResponseTimeout sets how long a query waits for its response.
+func WithResponseTimeout(timeout time.Duration) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithResponseTimeout(timeout))
+	}
+}
+
+// WithResultDownload downloads results the server wrote to S3 in up to parts
+// parallel ranged requests of partSize bytes. By default they are fetched
+// with a single request.
+func WithResultDownload(parts int, partSize int64) Option {
+	return func(instance *Instance) {
+		instance.downloadParts = parts
+		instance.downloadPartSize = partSize
+	}
+}
+
+// WithPriorityQueue sends queries in order of their Priority, see
+// ContextWithPriority, instead of first come first served.
+func WithPriorityQueue() Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithPriorityScheduling())
+	}
+}
+
+// WithCircuitBreaker fails queries fast with ErrCircuitOpen for cooldown
+// after threshold consecutive connection or query failures, instead of
+// re-dialing a service that is down.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(instance *Instance) {
+		instance.breaker = newCircuitBreaker(threshold, cooldown)
+	}
+}
+
+// WithRESTTransport sends queries as HTTPS POSTs to url, either always or
+// only when the websocket cannot be dialed, for networks that block
+// websockets. Results are returned through the same Query API.
+func WithRESTTransport(url string, mode RESTMode) Option {
+	return func(instance *Instance) {
+		instance.rest = &restTransport{url: url, mode: mode}
+	}
+}
+
+// WithTransport sends queries over transport instead of gorilla/websocket.
+func WithTransport(transport wsclient.Transport) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithTransport(transport))
+	}
+}
+
+// WithMaxInFlight caps the queries outstanding on the Instance at limit.
+// Beyond it, queries wait for a free slot when wait is true, or fail with
+// ErrTooManyInFlight otherwise.
+func WithMaxInFlight(limit int, wait bool) Option {
+	return func(instance *Instance) {
+		if limit > 0 {
+			instance.inFlightLimit = &inFlightLimit{slots: make(chan struct{}, limit), wait: wait}
+		}
+	}
+}
+
+// WithRecorder records every message sent and received to w, see
+// wsclient.WithRecorder.
+func WithRecorder(w io.Writer) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithRecorder(w))
+	}
+}
+
+// WithReplay serves queries from a recording instead of the server, without
+// signing in, so integration tests and bug reports can run offline:
+//
+//	replay, err := wsclient.NewReplayTransport(cassette)
+//	instance := boilingdata.NewInstance("me@example.com", nil, boilingdata.WithReplay(replay))
+func WithReplay(replay *wsclient.ReplayTransport) Option {
+	return WithOfflineTransport(replay)
+}
+
+// WithOfflineTransport sends queries over transport without signing in, for
+// mock servers such as the one of boilingtest/loadgen.
+func WithOfflineTransport(transport wsclient.Transport) Option {
+	return func(instance *Instance) {
+		instance.offline = true
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithTransport(transport))
+	}
+}
+
+// WithRequestIDFunc derives the request IDs of the queries built by the
+// client, such as those of Exec or ListShares, with fn.
+func WithRequestIDFunc(fn RequestIDFunc) Option {
+	return func(instance *Instance) {
+		instance.requestIDFunc = fn
+	}
+}
+
+// WithConnectionTTL sets the maximum connection lifetime enforced by the
+// server, after which connections are rotated; see
+// wsclient.WithConnectionTTL. Zero disables rotation.
+func WithConnectionTTL(ttl time.Duration) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithConnectionTTL(ttl))
+	}
+}
+
+// WithSendQueue bounds the queue of outgoing queries and sets what happens
+// when it is full; see wsclient.SendPolicy. By default queries wait up to
+// constants.SendTimeout for room in a queue of constants.SendQueueSize.
+func WithSendQueue(size int, policy wsclient.SendPolicy, timeout time.Duration) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithSendQueue(size, policy, timeout))
+	}
+}
+
+// WithDuplicatePolicy sets how sub-batches redelivered by the server are
+// handled; see wsclient.DuplicatePolicy.
+func WithDuplicatePolicy(policy wsclient.DuplicatePolicy) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithDuplicatePolicy(policy))
+	}
+}
+
+// WithResubmit resends queries up to attempts times when the connection
+// drops before their response is complete, if they are read-only. Whether a
+// query is read-only is read from its statement unless the payload says so
+// explicitly with Payload.WithReadOnly. Queries that change state are never
+// resent, since they may already have run; callers retrying them can give
+// them an idempotency key (see WithIdempotencyKeys) so a retry cannot run
+// them twice. Other queries fail with the error, e.g. one matching
+// ErrConnectionLost, which tells how many sub-batches had arrived. Queries
+// the server rejected with a throttling message are resubmitted whatever
+// attempts is, up to constants.ThrottleRetries times, after its retry-after
+// hint or else the Backoff delay.
+func WithResubmit(attempts int) Option {
+	return func(instance *Instance) {
+		instance.resubmits = attempts
+	}
+}
+
+// WithIdempotencyKeys runs queries carrying an idempotency key (see
+// Payload.WithIdempotencyKey) at most once per key within ttl: the query
+// sent again under the same key, e.g. when the caller retries it after
+// ErrConnectionLost, returns the outcome of the first attempt instead of
+// running again, and one sent while the first is running waits for it. The
+// deduplication is done by the client and covers only this Instance; the
+// key is not sent to the server. Attempts that failed before the query was
+// sent, e.g. with ErrCircuitOpen, are not remembered, while a query
+// cancelled by its caller counts as run. Without this option keys are
+// ignored.
+func WithIdempotencyKeys(ttl time.Duration) Option {
+	return func(instance *Instance) {
+		instance.idempotency = newIdempotencyStore(ttl)
+	}
+}
+
+// WithUserAgent overrides the User-Agent and x-bd-client headers sent on the
+// websocket dial and on auth and API calls, which identify this library,
+// its version and the Go version by default.
+func WithUserAgent(userAgent string) Option {
+	return func(instance *Instance) {
+		instance.Auth.userAgent = userAgent
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithUserAgent(userAgent))
+	}
+}
+
+// WithHeaders adds extra headers, such as tenant IDs, tracing headers or WAF
+// tokens, to the websocket handshake. Signature-relevant headers
+// (Authorization, Host, X-Amz-*) and the client identification headers are
+// never replaced.
+func WithHeaders(header http.Header) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithHeaders(header))
+	}
+}
+
+// WithRedactor masks sensitive parts of SQL and server messages, e.g. with
+// wsclient.MaskLiterals or wsclient.RedactPatterns, before they are logged
+// or put in an error. Redactors set more than once are applied in order.
+func WithRedactor(redactor wsclient.Redactor) Option {
+	return func(instance *Instance) {
+		instance.redactor = instance.redactor.Chain(redactor)
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithRedactor(redactor))
+	}
+}
+
+// WithDecompressor decodes result frames the server compressed with
+// encoding using fn; see wsclient.WithDecompressor. gzip and deflate are
+// decoded without it.
+func WithDecompressor(encoding string, fn wsclient.Decompressor) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithDecompressor(encoding, fn))
+	}
+}
+
+// WithMessageType registers handler for server frames of messageType that
+// the client does not model; see wsclient.WithMessageType and
+// wsclient.DecodeMessage. Handlers can also be added later with
+// Instance.Wsc.HandleMessageType.
+func WithMessageType(messageType string, handler wsclient.MessageHandler) Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithMessageType(messageType, handler))
+	}
+}
+
+// WithPartialOnTimeout returns the rows received so far, with Truncated
+// set, when a query times out or its context is done, so interactive tools
+// can show the first rows instead of nothing. Truncated results are not
+// cached.
+func WithPartialOnTimeout() Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithPartialOnTimeout())
+	}
+}
+
+// WithKeepOpenWhilePending keeps the idle timeout from closing the
+// connection while a query is still waiting for sub-batches, e.g. a slow
+// query whose frames arrive minutes apart.
+func WithKeepOpenWhilePending() Option {
+	return func(instance *Instance) {
+		instance.wsOptions = append(instance.wsOptions, wsclient.WithKeepOpenWhilePending())
+	}
+}
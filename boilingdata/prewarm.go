@@ -0,0 +1,68 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+)
+
+// Connect signs in and opens the websocket connection unless it is already
+// open, so the first query does not pay for it.
+func (instance *Instance) Connect(ctx context.Context) error {
+	end, err := instance.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+	if !instance.Wsc.IsWebSocketClosed() {
+		return nil
+	}
+	return instance.connect(ctx)
+}
+
+// Prewarm gets the Instance of each user with GetInstance, signs it in,
+// connects it and runs a warm-up query, at most parallelism users at a time
+// (all at once when parallelism <= 0), so the first user-facing query after
+// a deployment skips the sign-in, dial and warm-up latency. The Instances
+// are returned in the order of users; the errors of users that could not be
+// warmed are joined, each naming its user.
+func Prewarm(ctx context.Context, users []Credentials, parallelism int, opts ...Option) ([]*Instance, error) {
+	if parallelism <= 0 {
+		parallelism = len(users)
+	}
+	instances := make([]*Instance, len(users))
+	errs := make([]error, len(users))
+	slots := make(chan struct{}, max(parallelism, 1))
+	var wg sync.WaitGroup
+	for i, user := range users {
+		instances[i] = GetInstance(user.UserName, user.Password, opts...)
+		wg.Add(1)
+		go func(i int, instance *Instance) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("%s: %w", users[i].UserName, ctx.Err())
+				return
+			}
+			defer func() { <-slots }()
+			if err := instance.warm(ctx); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", users[i].UserName, err)
+			}
+		}(i, instances[i])
+	}
+	wg.Wait()
+	return instances, errors.Join(errs...)
+}
+
+// warm connects the Instance and runs the warm-up query.
+func (instance *Instance) warm(ctx context.Context) error {
+	if err := instance.Connect(ctx); err != nil {
+		return err
+	}
+	_, err := instance.queryContext(ctx, constants.KeepWarmSQL)
+	return err
+}
@@ -0,0 +1,22 @@
+package boilingdata
+
+import (
+	"context"
+
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// Priority classes used by WithPriorityQueue.
+type Priority = wsclient.Priority
+
+const (
+	PriorityInteractive = wsclient.PriorityInteractive
+	PriorityBatch       = wsclient.PriorityBatch
+)
+
+// ContextWithPriority marks queries run with ctx as priority, e.g.
+//
+//	instance.QueryContext(boilingdata.ContextWithPriority(ctx, boilingdata.PriorityBatch), payload)
+func ContextWithPriority(ctx context.Context, priority Priority) context.Context {
+	return wsclient.ContextWithPriority(ctx, priority)
+}
@@ -0,0 +1,119 @@
+package boilingdata
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// ProgressEvent reports the DATA frames received so far for a query.
+type ProgressEvent = wsclient.Progress
+
+// QueryHandle tracks a query started with QueryAsync.
+type QueryHandle struct {
+	RequestID string
+	wsc       *wsclient.WSSClient
+	events    chan ProgressEvent
+	done      chan struct{}
+	mu        sync.Mutex
+	closed    bool
+	response  *message.Response
+	err       error
+	last      ProgressEvent
+}
+
+// QueryAsync starts the query and returns immediately with a handle that
+// reports progress and delivers the final response.
+func (instance *Instance) QueryAsync(payloadMessage []byte) (*QueryHandle, error) {
+	var payload message.Payload
+	if err := json.Unmarshal(payloadMessage, &payload); err != nil {
+		log.Println("error unmarshalling Payload : " + err.Error())
+		return nil, fmt.Errorf("error unmarshalling Payload : %w", err)
+	}
+	handle := &QueryHandle{
+		RequestID: payload.RequestID,
+		wsc:       instance.Wsc,
+		events:    make(chan ProgressEvent, 16),
+		done:      make(chan struct{}),
+	}
+	instance.Wsc.SetProgressListener(payload.RequestID, handle.publish)
+	go func() {
+		response, err := instance.Query(payloadMessage)
+		instance.Wsc.RemoveProgressListener(payload.RequestID)
+		handle.finish(response, err)
+	}()
+	return handle, nil
+}
+
+// ProgressEvents returns a channel of progress updates. Updates are dropped
+// when the channel is not drained; it is closed when the query completes.
+func (handle *QueryHandle) ProgressEvents() <-chan ProgressEvent {
+	return handle.events
+}
+
+// Done is closed once the query has completed.
+func (handle *QueryHandle) Done() <-chan struct{} {
+	return handle.done
+}
+
+// Wait blocks until the query completes and returns its result.
+func (handle *QueryHandle) Wait() (*message.Response, error) {
+	<-handle.done
+	return handle.response, handle.err
+}
+
+// Partial returns the rows received so far, e.g. to preview a long query
+// while it streams. Once the query has completed it returns the final
+// response, or an empty one if the query failed.
+func (handle *QueryHandle) Partial() *message.Response {
+	select {
+	case <-handle.done:
+		if handle.err != nil || handle.response == nil {
+			return &message.Response{}
+		}
+		return handle.response
+	default:
+	}
+	if response, ok := handle.wsc.PartialResponse(handle.RequestID); ok {
+		return response
+	}
+	return &message.Response{}
+}
+
+// Progress estimates how much of the query has completed, from 0 to 100,
+// based on the batch counters of the frames received so far. It is 100 once
+// the query has completed.
+func (handle *QueryHandle) Progress() float64 {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	if handle.closed {
+		return 100
+	}
+	return min(handle.last.Percent(), 99)
+}
+
+func (handle *QueryHandle) publish(progress ProgressEvent) {
+	handle.mu.Lock()
+	defer handle.mu.Unlock()
+	if handle.closed {
+		return
+	}
+	handle.last = progress
+	select {
+	case handle.events <- progress:
+	default:
+	}
+}
+
+func (handle *QueryHandle) finish(response *message.Response, err error) {
+	handle.mu.Lock()
+	handle.closed = true
+	close(handle.events)
+	handle.mu.Unlock()
+	handle.response, handle.err = response, err
+	close(handle.done)
+}
@@ -0,0 +1,127 @@
+package boilingdata
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// QueryTyped runs sql and decodes each row into a T. For struct types,
+// columns are matched to exported fields by their bd tag, then their json
+// tag, then the field name, ignoring case; a tag of "-" skips the field and
+// columns without a field are ignored. Values are converted as by
+// Rows.Scan. Other types receive the single column of each row:
+//
+//	type user struct {
+//		ID      int64     `bd:"id"`
+//		Created time.Time `bd:"created_at"`
+//	}
+//	users, err := boilingdata.QueryTyped[user](ctx, instance, "SELECT id, created_at FROM ...")
+func QueryTyped[T any](ctx context.Context, instance *Instance, sql string) ([]T, error) {
+	response, err := instance.queryContext(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTyped[T](response)
+}
+
+func decodeTyped[T any](response *message.Response) ([]T, error) {
+	results := make([]T, len(response.Data))
+	if len(results) == 0 {
+		return results, nil
+	}
+	typ := reflect.TypeOf(results).Elem()
+	if typ.Kind() != reflect.Struct || typ.ConvertibleTo(timeType) {
+		columns := response.Columns()
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("cannot decode %d columns into %s", len(columns), typ)
+		}
+		for i, row := range response.Data {
+			if err := convertAssign(&results[i], row[columns[0]]); err != nil {
+				return nil, fmt.Errorf("row %d, column %q: %w", i, columns[0], err)
+			}
+		}
+		return results, nil
+	}
+	fields := structFields(typ)
+	for i, row := range response.Data {
+		value := reflect.ValueOf(&results[i]).Elem()
+		for column, src := range row {
+			index, ok := fields.lookup(column)
+			if !ok {
+				continue
+			}
+			if err := convertAssign(value.FieldByIndex(index).Addr().Interface(), src); err != nil {
+				return nil, fmt.Errorf("row %d, column %q: %w", i, column, err)
+			}
+		}
+	}
+	return results, nil
+}
+
+// fieldMap maps column names to struct field indexes.
+type fieldMap struct {
+	exact map[string][]int
+	fold  map[string][]int
+}
+
+func (m fieldMap) lookup(column string) ([]int, bool) {
+	if index, ok := m.exact[column]; ok {
+		return index, true
+	}
+	index, ok := m.fold[strings.ToLower(column)]
+	return index, ok
+}
+
+var fieldMaps sync.Map // map[reflect.Type]fieldMap
+
+// structFields returns the column mapping of the struct type typ.
+func structFields(typ reflect.Type) fieldMap {
+	if cached, ok := fieldMaps.Load(typ); ok {
+		return cached.(fieldMap)
+	}
+	fields := fieldMap{exact: make(map[string][]int), fold: make(map[string][]int)}
+	addStructFields(fields, typ, nil)
+	fieldMaps.Store(typ, fields)
+	return fields
+}
+
+func addStructFields(fields fieldMap, typ reflect.Type, parent []int) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		index := append(append([]int(nil), parent...), i)
+		name, tagged := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && !tagged && field.Type.Kind() == reflect.Struct {
+			addStructFields(fields, field.Type, index)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if _, ok := fields.exact[name]; !ok {
+			fields.exact[name] = index
+		}
+		if _, ok := fields.fold[strings.ToLower(name)]; !ok {
+			fields.fold[strings.ToLower(name)] = index
+		}
+	}
+}
+
+// fieldName returns the column name of field and whether it came from a tag.
+func fieldName(field reflect.StructField) (string, bool) {
+	for _, key := range []string{"bd", "json"} {
+		if tag, ok := field.Tag.Lookup(key); ok {
+			if name, _, _ := strings.Cut(tag, ","); name != "" {
+				return name, true
+			}
+		}
+	}
+	return field.Name, false
+}
@@ -0,0 +1,50 @@
+package boilingdata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QuoteLiteral returns s as a SQL string literal, doubling embedded quotes:
+//
+//	"SELECT * FROM t WHERE name = " + boilingdata.QuoteLiteral(name)
+func QuoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// QuoteIdentifier returns s as a quoted SQL identifier such as a column or
+// table name, doubling embedded double quotes.
+func QuoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// S3Path joins bucket and key parts into an s3:// URI, trimming duplicate
+// slashes between the parts.
+func S3Path(bucket string, key ...string) string {
+	parts := []string{strings.Trim(strings.TrimPrefix(bucket, "s3://"), "/")}
+	for _, part := range key {
+		if part = strings.Trim(part, "/"); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return "s3://" + strings.Join(parts, "/")
+}
+
+// ScanExpression returns the table function reading the Parquet, CSV or JSON
+// file at path with the path safely quoted, e.g.
+//
+//	parquet_scan('s3://bucket/data.parquet')
+//
+// so that "SELECT * FROM " + expression queries the file.
+func ScanExpression(path string) (string, error) {
+	quoted := QuoteLiteral(path)
+	switch tableFormat(path) {
+	case "parquet":
+		return "parquet_scan(" + quoted + ")", nil
+	case "csv":
+		return "read_csv_auto(" + quoted + ")", nil
+	case "json":
+		return "read_json_auto(" + quoted + ")", nil
+	}
+	return "", fmt.Errorf("unsupported table format for %q", path)
+}
@@ -0,0 +1,35 @@
+package boilingdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// QueryRaw runs the query and returns its DATA frames as received, in
+// sub-batch order, without decoding any rows, for callers that feed the
+// frames to their own parser. The result cache, deduplication, interceptors
+// and row mappers are bypassed.
+func (instance *Instance) QueryRaw(ctx context.Context, payloadMessage []byte) ([][]byte, error) {
+	var payload message.Payload
+	if err := json.Unmarshal(payloadMessage, &payload); err != nil {
+		return nil, fmt.Errorf("error unmarshalling Payload : %w", err)
+	}
+	end, err := instance.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	wsc := instance.client(ctx)
+	if wsc.IsWebSocketClosed() {
+		if err := instance.connect(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := wsc.SendRawContext(ctx, payloadMessage, payload); err != nil {
+		return nil, err
+	}
+	return wsc.GetRawResponse(ctx, payload.RequestID)
+}
@@ -0,0 +1,150 @@
+package boilingdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// readOnlyKeywords are the statements that never change state, so resending
+// them after an ambiguous failure cannot execute anything twice. EXPLAIN and
+// WITH are judged by the statement they wrap; see statementKeyword.
+var readOnlyKeywords = []string{"SELECT", "DESCRIBE", "SHOW", "SUMMARIZE", "FROM", "VALUES", "TABLE"}
+
+// isReadOnly reports whether payload is a single query that only reads.
+// An explicit Payload.ReadOnly takes precedence over the statement.
+func isReadOnly(payload message.Payload) bool {
+	if payload.MessageType != message.SQLQueryMessage {
+		return false
+	}
+	if payload.ReadOnly != nil {
+		return *payload.ReadOnly
+	}
+	statements := SplitStatements(payload.SQL)
+	if len(statements) != 1 {
+		return false
+	}
+	keyword := statementKeyword(statements[0])
+	for _, readOnly := range readOnlyKeywords {
+		if keyword == readOnly {
+			return true
+		}
+	}
+	return false
+}
+
+// clientFields are the JSON names of the Payload fields only the client
+// uses: ReadOnly and IdempotencyKey.
+var clientFields = []string{"readOnly", "idempotencyKey"}
+
+// withoutClientFields removes the client-side fields from payloadMessage,
+// keeping its other fields, so they are not sent to the server.
+func withoutClientFields(payloadMessage []byte, payload message.Payload) ([]byte, error) {
+	if payload.ReadOnly == nil && payload.IdempotencyKey == "" {
+		return payloadMessage, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payloadMessage, &fields); err != nil {
+		return nil, fmt.Errorf("error unmarshalling Payload : %w", err)
+	}
+	for _, field := range clientFields {
+		delete(fields, field)
+	}
+	payloadMessage, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling Payload : %w", err)
+	}
+	return payloadMessage, nil
+}
+
+// ambiguous reports whether err leaves open if the server executed the
+// query because the connection dropped after it was sent. Queries failing
+// that way are only resubmitted when read-only. A response timeout is not
+// ambiguous: the query may still be running on the live connection, so
+// sending it again could run it twice and mix the frames of both runs.
+func ambiguous(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return errors.Is(err, ErrConnectionLost)
+}
+
+// statementKeyword returns the upper-cased keyword of the statement that
+// runs: EXPLAIN ANALYZE executes the statement it explains, and the common
+// table expressions of WITH can front an INSERT or DELETE.
+func statementKeyword(statement string) string {
+	keyword, rest := firstKeyword(statement)
+	switch keyword = strings.ToUpper(keyword); keyword {
+	case "EXPLAIN":
+		if next, after := firstKeyword(rest); strings.EqualFold(next, "ANALYZE") {
+			rest = after
+		}
+		return statementKeyword(rest)
+	case "WITH":
+		return statementKeyword(afterCTEs(rest))
+	}
+	return keyword
+}
+
+// afterCTEs returns the statement following the common table expressions
+// that open rest, the text after WITH. Each expression ends with a closing
+// parenthesis at the outer level that is not followed by a comma, or by AS
+// when it closed a column list.
+func afterCTEs(rest string) string {
+	depth := 0
+	for i := 0; i < len(rest); i++ {
+		if end := skipLiteral(rest, i); end > i {
+			i = end - 1
+			continue
+		}
+		switch rest[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			next := skipSpace(rest[i+1:])
+			if strings.HasPrefix(next, ",") {
+				continue
+			}
+			if keyword, _ := firstKeyword(next); strings.EqualFold(keyword, "AS") {
+				continue
+			}
+			return next
+		}
+	}
+	return ""
+}
+
+// firstKeyword returns the first word of statement after comments and
+// opening parentheses, and the text following it.
+func firstKeyword(statement string) (keyword, rest string) {
+	statement = skipSpace(statement)
+	for strings.HasPrefix(statement, "(") {
+		statement = skipSpace(statement[1:])
+	}
+	end := strings.IndexFunc(statement, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_')
+	})
+	if end < 0 {
+		return statement, ""
+	}
+	return statement[:end], statement[end:]
+}
+
+// skipSpace returns statement without its leading whitespace and comments.
+func skipSpace(statement string) string {
+	for {
+		statement = strings.TrimLeft(statement, " \t\r\n")
+		if !strings.HasPrefix(statement, "--") && !strings.HasPrefix(statement, "/*") {
+			return statement
+		}
+		statement = statement[skipLiteral(statement, 0):]
+	}
+}
@@ -0,0 +1,44 @@
+package boilingdata
+
+import (
+	"testing"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+func TestIsReadOnly(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT 1", true},
+		{"-- report\n(SELECT * FROM t)", true},
+		{"DESCRIBE t", true},
+		{"INSERT INTO t VALUES (1)", false},
+		{"SELECT 1; DELETE FROM t", false},
+		{"EXPLAIN SELECT * FROM t", true},
+		{"EXPLAIN ANALYZE SELECT * FROM t", true},
+		{"EXPLAIN INSERT INTO t VALUES (1)", false},
+		{"explain analyze DELETE FROM t", false},
+		{"WITH a AS (SELECT 1) SELECT * FROM a", true},
+		{"WITH a(x) AS (SELECT 1), b AS MATERIALIZED (SELECT ')' FROM a) SELECT * FROM b", true},
+		{"WITH a AS (SELECT 1) INSERT INTO t SELECT * FROM a", false},
+		{"WITH a AS (SELECT 1) /* purge */ DELETE FROM t WHERE x IN (SELECT * FROM a)", false},
+		{"EXPLAIN WITH a AS (SELECT 1) DELETE FROM t", false},
+		{"WITH a AS (SELECT 1", false},
+	}
+	for _, tt := range tests {
+		payload := message.Payload{MessageType: message.SQLQueryMessage, SQL: tt.sql}
+		if got := isReadOnly(payload); got != tt.want {
+			t.Errorf("isReadOnly(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestIsReadOnlyExplicit(t *testing.T) {
+	readOnly := true
+	payload := message.Payload{MessageType: message.SQLQueryMessage, SQL: "DELETE FROM t", ReadOnly: &readOnly}
+	if !isReadOnly(payload) {
+		t.Error("explicit ReadOnly was ignored")
+	}
+}
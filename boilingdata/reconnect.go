@@ -0,0 +1,125 @@
+package boilingdata
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ReconnectAction tells the reconnect logic how to proceed after the
+// connection closed or a dial failed.
+type ReconnectAction int
+
+const (
+	// ReconnectBackoff retries after the delay of the configured Backoff.
+	ReconnectBackoff ReconnectAction = iota
+	// ReconnectNow retries without waiting, e.g. after 1001 going away
+	// during a server deployment.
+	ReconnectNow
+	// ReconnectReauthenticate refreshes the token and AWS credentials
+	// before retrying, e.g. after a policy violation on a stale signature.
+	ReconnectReauthenticate
+	// ReconnectGiveUp fails with the error instead of reconnecting.
+	ReconnectGiveUp
+)
+
+func (action ReconnectAction) String() string {
+	switch action {
+	case ReconnectBackoff:
+		return "backoff"
+	case ReconnectNow:
+		return "now"
+	case ReconnectReauthenticate:
+		return "reauthenticate"
+	case ReconnectGiveUp:
+		return "give up"
+	}
+	return fmt.Sprintf("ReconnectAction(%d)", int(action))
+}
+
+// ReconnectPolicy chooses the ReconnectAction for err, the reason the
+// previous connection closed or the error of the last dial attempt.
+type ReconnectPolicy func(err error) ReconnectAction
+
+// DefaultReconnectPolicy retries the errors IsRetryable accepts with
+// backoff and gives up on the others.
+func DefaultReconnectPolicy(err error) ReconnectAction {
+	if IsRetryable(err) {
+		return ReconnectBackoff
+	}
+	return ReconnectGiveUp
+}
+
+// CloseCodePolicy returns a ReconnectPolicy choosing the action by the
+// close code of a CloseError, and by fallback, or DefaultReconnectPolicy
+// when nil, for other errors and unlisted codes:
+//
+//	boilingdata.CloseCodePolicy(map[int]boilingdata.ReconnectAction{
+//		1001: boilingdata.ReconnectNow,
+//		1008: boilingdata.ReconnectReauthenticate,
+//		4401: boilingdata.ReconnectGiveUp,
+//	}, nil)
+func CloseCodePolicy(codes map[int]ReconnectAction, fallback ReconnectPolicy) ReconnectPolicy {
+	if fallback == nil {
+		fallback = DefaultReconnectPolicy
+	}
+	return func(err error) ReconnectAction {
+		var closeErr *CloseError
+		if errors.As(err, &closeErr) {
+			if action, ok := codes[closeErr.Code]; ok {
+				return action
+			}
+		}
+		return fallback(err)
+	}
+}
+
+// reconnectPolicy is the policy set with WithReconnectPolicy.
+type reconnectPolicy struct {
+	policy ReconnectPolicy
+	mu     sync.Mutex
+	// handled is the close reason already acted on, so a connection the
+	// policy gave up on is dialed again by the next query.
+	handled error
+}
+
+// WithReconnectPolicy decides per failure how the Instance reconnects: the
+// reason the previous connection closed is consulted before dialing again,
+// and every failed dial before retrying. When the policy gives up on a
+// closed connection, the query that found it closed fails with the close
+// reason and later queries dial again. Without a policy the connection is
+// dialed again at once and failed dials are retried as IsRetryable decides.
+func WithReconnectPolicy(policy ReconnectPolicy) Option {
+	return func(instance *Instance) {
+		if policy != nil {
+			instance.reconnect = &reconnectPolicy{policy: policy}
+		}
+	}
+}
+
+// closeAction returns the action for reason, the error the previous
+// connection closed with, consuming it.
+func (r *reconnectPolicy) closeAction(reason error) ReconnectAction {
+	if r == nil || reason == nil {
+		return ReconnectBackoff
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if reason == r.handled {
+		return ReconnectBackoff
+	}
+	r.handled = reason
+	return r.policy(reason)
+}
+
+// dialAction returns the action for err, the error of a failed dial, and
+// marks reason, the error the dial left on the connection, as handled.
+func (r *reconnectPolicy) dialAction(err error, reason error) ReconnectAction {
+	if r == nil {
+		return DefaultReconnectPolicy(err)
+	}
+	r.mu.Lock()
+	r.handled = reason
+	r.mu.Unlock()
+	return r.policy(err)
+}
@@ -0,0 +1,141 @@
+package boilingdata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// RESTMode selects when queries go over HTTPS instead of the websocket.
+type RESTMode int
+
+const (
+	// RESTFallback uses HTTPS only when the websocket cannot be dialed, e.g.
+	// behind proxies that block websockets.
+	RESTFallback RESTMode = iota
+	// RESTAlways never opens a websocket.
+	RESTAlways
+)
+
+// restTransport posts query payloads to an HTTPS endpoint. The endpoint
+// answers with the frames the websocket would have sent, as a JSON array or
+// a single object.
+type restTransport struct {
+	url  string
+	mode RESTMode
+}
+
+// dialError marks connect failures where authentication succeeded but the
+// websocket could not be opened.
+type dialError struct {
+	err error
+}
+
+func (e *dialError) Error() string { return e.err.Error() }
+func (e *dialError) Unwrap() error { return e.err }
+
+// restQuery sends payloadMessage over HTTPS and assembles the response.
+func (instance *Instance) restQuery(ctx context.Context, payloadMessage []byte, payload message.Payload) (*message.Response, error) {
+	idToken, err := instance.Auth.Authenticate()
+	if err != nil {
+		return &message.Response{}, fmt.Errorf("Error : %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, instance.rest.url, bytes.NewReader(payloadMessage))
+	if err != nil {
+		return &message.Response{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", idToken)
+	resp, err := instance.Auth.client().Do(req)
+	if err != nil {
+		return &message.Response{}, fmt.Errorf("Error sending REST query: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &message.Response{}, fmt.Errorf("Error reading REST response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return &message.Response{}, fmt.Errorf("Error sending REST query: unexpected status %s", resp.Status)
+	}
+	response, err := assembleFrames(body, payload.RequestID, instance.preciseNumbers, instance.redactor)
+	if err != nil {
+		return response, err
+	}
+	if response.Data, err = wsclient.MapRows(ctx, response.Data); err != nil {
+		return &message.Response{}, err
+	}
+	return response, nil
+}
+
+// assembleFrames merges the DATA frames in body in sub-batch order, failing
+// on an ERROR log message like the websocket client does.
+func assembleFrames(body []byte, requestID string, useNumber bool, redactor wsclient.Redactor) (*message.Response, error) {
+	var frames []json.RawMessage
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		if err := json.Unmarshal(body, &frames); err != nil {
+			return &message.Response{}, fmt.Errorf("Error parsing JSON: %w", err)
+		}
+	} else {
+		frames = []json.RawMessage{body}
+	}
+	var batches []*message.Response
+	for _, frame := range frames {
+		var logMessage message.LogMessage
+		if err := json.Unmarshal(frame, &logMessage); err != nil {
+			return &message.Response{}, fmt.Errorf("Error parsing JSON: %w", err)
+		}
+		if logMessage.MessageType == message.LOG_MESSAGE.String() {
+			if logMessage.LogLevel == "ERROR" {
+				return &message.Response{}, &ServerError{RequestID: requestID, LogLevel: logMessage.LogLevel, Message: redactor.Redact(logMessage.LogMessage)}
+			}
+			continue
+		}
+		var response message.Response
+		if err := newDecoder(frame, useNumber).Decode(&response); err != nil {
+			return &message.Response{}, fmt.Errorf("Error parsing JSON: %w", err)
+		}
+		if err := wsclient.VerifyFrame(&response, frame); err != nil {
+			return &message.Response{}, err
+		}
+		response.Extra, _ = message.ExtraFields(frame)
+		batches = append(batches, &response)
+	}
+	if len(batches) == 0 {
+		return &message.Response{}, errors.New("No data in response from server")
+	}
+	sort.SliceStable(batches, func(i, j int) bool { return batches[i].SubBatchSerial < batches[j].SubBatchSerial })
+	result := *batches[len(batches)-1]
+	total := 0
+	for _, batch := range batches {
+		total += len(batch.Data)
+	}
+	result.Data = nil
+	if total > 0 {
+		result.Data = make([]map[string]interface{}, 0, total)
+	}
+	for _, batch := range batches {
+		result.Data = append(result.Data, batch.Data...)
+		if result.ResultURL == "" {
+			result.ResultURL = batch.ResultURL
+		}
+		if result.Stats == nil {
+			result.Stats = batch.Stats
+		}
+	}
+	if result.TotalRows != nil && result.ResultURL == "" && total != *result.TotalRows {
+		return &message.Response{}, fmt.Errorf("%w: request %s assembled %d rows, server reported %d",
+			ErrCorruptBatch, requestID, total, *result.TotalRows)
+	}
+	result.RequestID = requestID
+	return &result, nil
+}
@@ -0,0 +1,327 @@
+package boilingdata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// ResultSet is the result of a query. It wraps the wire level
+// message.Response and is the one place to read columns, iterate, scan and
+// export rows:
+//
+//	result, err := instance.QueryResult(ctx, "SELECT name, count FROM ...")
+//	rows := result.Rows()
+//	for rows.Next() {
+//		err := rows.Scan(&name, &count)
+//	}
+type ResultSet struct {
+	response *message.Response
+}
+
+// NewResultSet wraps response, e.g. one returned by Query.
+func NewResultSet(response *message.Response) *ResultSet {
+	if response == nil {
+		response = &message.Response{}
+	}
+	return &ResultSet{response: response}
+}
+
+// QueryResult runs sql and returns its ResultSet.
+func (instance *Instance) QueryResult(ctx context.Context, sql string) (*ResultSet, error) {
+	response, err := instance.queryContext(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	return NewResultSet(response), nil
+}
+
+// Response returns the wrapped response.
+func (result *ResultSet) Response() *message.Response {
+	return result.response
+}
+
+// Columns returns the column names in server order.
+func (result *ResultSet) Columns() []string {
+	return result.response.Columns()
+}
+
+// Len returns the number of rows.
+func (result *ResultSet) Len() int {
+	return len(result.response.Data)
+}
+
+// Row returns row i.
+func (result *ResultSet) Row(i int) message.Row {
+	return result.response.Data[i]
+}
+
+// Values returns the rows as a matrix with columns in server order.
+func (result *ResultSet) Values() [][]interface{} {
+	_, rows := result.response.Rows()
+	return rows
+}
+
+// Rows returns a Rows iterating over the result in the style of
+// database/sql.
+func (result *ResultSet) Rows() *Rows {
+	return NewRows(result.response)
+}
+
+// ScanRow copies the columns of row i into dest, which must contain one
+// pointer per column, converting values as Rows.Scan does.
+func (result *ResultSet) ScanRow(i int, dest ...interface{}) error {
+	if i < 0 || i >= result.Len() {
+		return fmt.Errorf("row %d out of range [0, %d)", i, result.Len())
+	}
+	rows := result.Rows()
+	rows.index = i
+	return rows.Scan(dest...)
+}
+
+// Decode converts the rows into the slice pointed to by dest using the json
+// tags of its element type. ResultSetOf maps columns as QueryTyped does.
+func (result *ResultSet) Decode(dest interface{}) error {
+	return decodeRows(result.response.Data, dest)
+}
+
+// ResultSetOf decodes the rows of result into values of type T, matching
+// columns to fields as QueryTyped does.
+func ResultSetOf[T any](result *ResultSet) ([]T, error) {
+	return decodeTyped[T](result.response)
+}
+
+// Stats returns the execution statistics the server reported, or nil.
+func (result *ResultSet) Stats() *message.Stats {
+	return result.response.Stats
+}
+
+// CacheStatus returns the cache status the server reported.
+func (result *ResultSet) CacheStatus() message.CacheStatus {
+	return result.response.CacheStatus()
+}
+
+// Export writes the rows to w in format, with columns in server order.
+func (result *ResultSet) Export(w io.Writer, format Format) error {
+	writer := newRowWriter(w, format)
+	header := &message.Response{Keys: result.Columns()}
+	rows := make([]json.RawMessage, 0, len(result.response.Data))
+	for _, row := range result.response.Data {
+		encoded, err := encodeRow(header.Keys, row)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, encoded)
+	}
+	if err := writer.write(header, rows); err != nil {
+		return err
+	}
+	return writer.flush()
+}
+
+// SortBy returns a copy of the result with its rows stably sorted by
+// column, ascending. Nulls sort first, numbers numerically, then booleans
+// and strings; values of other types keep their order.
+func (result *ResultSet) SortBy(column string) *ResultSet {
+	data := append([]map[string]interface{}(nil), result.response.Data...)
+	sort.SliceStable(data, func(i, j int) bool {
+		return compareValues(data[i][column], data[j][column]) < 0
+	})
+	return result.with(data)
+}
+
+// Limit returns a copy of the result with at most its first n rows.
+func (result *ResultSet) Limit(n int) *ResultSet {
+	data := result.response.Data
+	if n < len(data) {
+		data = data[:max(n, 0):max(n, 0)]
+	}
+	return result.with(data)
+}
+
+// Filter returns a copy of the result with only the rows keep accepts.
+func (result *ResultSet) Filter(keep func(row message.Row) bool) *ResultSet {
+	var data []map[string]interface{}
+	for _, row := range result.response.Data {
+		if keep(row) {
+			data = append(data, row)
+		}
+	}
+	return result.with(data)
+}
+
+// Flatten returns a copy of the result with nested objects and arrays
+// expanded into columns named by their path joined with separator, e.g.
+// address.city or tags.0, for tabular sinks. The separator defaults to a
+// dot. Object keys are expanded in sorted order and empty objects and
+// arrays are kept as they are.
+func (result *ResultSet) Flatten(separator string) *ResultSet {
+	if separator == "" {
+		separator = "."
+	}
+	original := result.Columns()
+	nested := make([][]string, len(original))
+	seen := make(map[string]bool)
+	data := make([]map[string]interface{}, 0, len(result.response.Data))
+	for _, row := range result.response.Data {
+		flat := make(map[string]interface{}, len(row))
+		for i, column := range original {
+			value, ok := row[column]
+			if !ok {
+				continue
+			}
+			flattenValue(flat, column, value, separator, func(key string) {
+				if !seen[key] {
+					seen[key] = true
+					nested[i] = append(nested[i], key)
+				}
+			})
+		}
+		data = append(data, flat)
+	}
+	// Keep the original column order, each expanded in path order.
+	var columns []string
+	for _, keys := range nested {
+		sort.Slice(keys, func(i, j int) bool {
+			return comparePaths(keys[i], keys[j], separator) < 0
+		})
+		columns = append(columns, keys...)
+	}
+	flattened := result.with(data)
+	flattened.response.Keys = columns
+	return flattened
+}
+
+// flattenValue stores value in row under prefix, expanding objects and
+// arrays, and reports each column it sets to add.
+func flattenValue(row map[string]interface{}, prefix string, value interface{}, separator string, add func(string)) {
+	switch nested := value.(type) {
+	case map[string]interface{}:
+		if len(nested) == 0 {
+			break
+		}
+		for key, child := range nested {
+			flattenValue(row, prefix+separator+key, child, separator, add)
+		}
+		return
+	case []interface{}:
+		if len(nested) == 0 {
+			break
+		}
+		for i, child := range nested {
+			flattenValue(row, prefix+separator+strconv.Itoa(i), child, separator, add)
+		}
+		return
+	}
+	row[prefix] = value
+	add(prefix)
+}
+
+// comparePaths orders flattened column names segment by segment, numeric
+// segments (array indexes) numerically.
+func comparePaths(a string, b string, separator string) int {
+	segmentsA, segmentsB := strings.Split(a, separator), strings.Split(b, separator)
+	for i := 0; i < len(segmentsA) && i < len(segmentsB); i++ {
+		x, errX := strconv.Atoi(segmentsA[i])
+		y, errY := strconv.Atoi(segmentsB[i])
+		switch {
+		case errX == nil && errY == nil && x != y:
+			return x - y
+		case segmentsA[i] != segmentsB[i]:
+			return strings.Compare(segmentsA[i], segmentsB[i])
+		}
+	}
+	return len(segmentsA) - len(segmentsB)
+}
+
+// with returns a ResultSet of the same response with rows data. The rows
+// are shared, not copied.
+func (result *ResultSet) with(data []map[string]interface{}) *ResultSet {
+	response := *result.response
+	response.Data = data
+	if response.Keys == nil {
+		// Keep the columns of the original rows when none are left.
+		response.Keys = result.Columns()
+	}
+	return &ResultSet{response: &response}
+}
+
+// compareValues orders decoded JSON values: nil, numbers, booleans, strings,
+// then anything else as equal.
+func compareValues(a interface{}, b interface{}) int {
+	rankA, rankB := valueRank(a), valueRank(b)
+	if rankA != rankB {
+		return rankA - rankB
+	}
+	switch rankA {
+	case 1:
+		x, _ := strconv.ParseFloat(numberString(a), 64)
+		y, _ := strconv.ParseFloat(numberString(b), 64)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case 2:
+		if a.(bool) != b.(bool) {
+			if b.(bool) {
+				return -1
+			}
+			return 1
+		}
+	case 3:
+		switch x, y := a.(string), b.(string); {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+func valueRank(value interface{}) int {
+	switch value.(type) {
+	case nil:
+		return 0
+	case float64, json.Number:
+		return 1
+	case bool:
+		return 2
+	case string:
+		return 3
+	}
+	return 4
+}
+
+// encodeRow encodes row as a JSON object with its keys in columns order.
+func encodeRow(columns []string, row map[string]interface{}) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(column)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(row[column])
+		if err != nil {
+			return nil, fmt.Errorf("Error encoding column %q: %w", column, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,96 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+	"github.com/gorilla/websocket"
+)
+
+// IsRetryable reports whether the operation that failed with err may succeed
+// when retried: network failures, timeouts, lost connections and throttling
+// are retryable; SQL errors, rejected credentials, cancellation and client
+// side limits are not. The reconnect loop uses the same classification.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch {
+	case errors.Is(err, context.Canceled),
+		errors.Is(err, ErrCircuitOpen),
+		errors.Is(err, ErrInstanceClosed),
+		errors.Is(err, ErrMessageTooLarge),
+		errors.Is(err, ErrPayloadTooLarge),
+		errors.Is(err, ErrTooManyInFlight),
+		errors.Is(err, ErrRateLimited):
+		return false
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrResponseTimeout),
+		errors.Is(err, ErrThrottled),
+		errors.Is(err, ErrAuthTimeout),
+		errors.Is(err, ErrDialTimeout),
+		errors.Is(err, ErrWriteTimeout),
+		errors.Is(err, wsclient.ErrConnectionClosed),
+		errors.Is(err, ErrConnectionLost),
+		errors.Is(err, wsclient.ErrNotConnected),
+		errors.Is(err, ErrSendQueueFull),
+		errors.Is(err, websocket.ErrBadHandshake):
+		return true
+	}
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		return wsclient.IsThrottleMessage(serverErr.Message)
+	}
+	var awsErr awserr.Error
+	if errors.As(err, &awsErr) {
+		switch awsErr.Code() {
+		case "TooManyRequestsException", "ThrottlingException", "LimitExceededException",
+			"InternalErrorException", "ServiceUnavailable", "RequestError":
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var closeErr *CloseError
+	if errors.As(err, &closeErr) {
+		return closeErr.Code != websocket.ClosePolicyViolation
+	}
+	var wsCloseErr *websocket.CloseError
+	if errors.As(err, &wsCloseErr) {
+		return wsCloseErr.Code != websocket.ClosePolicyViolation
+	}
+	return false
+}
+
+// RetryAfter returns the wait the server asked for when it throttled the
+// operation that failed with err, or zero.
+func RetryAfter(err error) time.Duration {
+	var throttle *ThrottledError
+	if errors.As(err, &throttle) {
+		return throttle.RetryAfter
+	}
+	return 0
+}
+
+// waitThrottle waits before resubmitting a throttled query: the server's
+// hint, or the reconnect backoff for attempt when it gave none.
+func (instance *Instance) waitThrottle(ctx context.Context, err error, attempt int) error {
+	wait := RetryAfter(err)
+	if wait <= 0 {
+		backoff := instance.backoff
+		if backoff == nil {
+			backoff = DefaultBackoff
+		}
+		wait = backoff.Next(attempt)
+	}
+	log.Printf("Throttled by server, retrying in %s", wait)
+	return instance.sleep(ctx, wait)
+}
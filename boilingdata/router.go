@@ -0,0 +1,152 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// ErrNoRoute is returned when a Router cannot tell which route a query
+// belongs to.
+var ErrNoRoute = errors.New("no route for query")
+
+// s3Reference matches the s3:// locations referenced in SQL.
+var s3Reference = regexp.MustCompile(`s3://[^'"\s,)]+`)
+
+// Route is a set of credentials, e.g. those of one data-owning account, and
+// the data sources queried with them. Sources are s3:// prefixes or bucket
+// names.
+type Route struct {
+	Name        string
+	Credentials Credentials
+	Sources     []string
+	// Default routes the queries that reference no known source.
+	Default bool
+	// Instance is used instead of signing in with Credentials, when set. It
+	// stays owned by the caller and is not closed by Router.Close.
+	Instance *Instance
+}
+
+// Router sends each query to the Instance of the route owning the data
+// sources it references, or to the route named with ContextWithRoute, so an
+// application spanning datasets of several accounts does not manage one
+// client per account itself.
+type Router struct {
+	routes []Route
+	// owned are the routes whose Instance NewRouter created, which Close
+	// closes.
+	owned []Route
+}
+
+// NewRouter returns a Router over routes. Each route without an Instance
+// gets a new one of its own, created with NewInstance and opts, which is
+// not shared with GetInstance callers.
+func NewRouter(routes []Route, opts ...Option) *Router {
+	router := &Router{routes: make([]Route, len(routes))}
+	for i, route := range routes {
+		if route.Instance == nil {
+			var provider CredentialsProvider
+			if route.Credentials.Password != "" {
+				provider = NewStaticCredentialsProvider(route.Credentials.UserName, route.Credentials.Password)
+			}
+			route.Instance = NewInstance(route.Credentials.UserName, provider, opts...)
+			router.owned = append(router.owned, route)
+		}
+		router.routes[i] = route
+	}
+	return router
+}
+
+type routeKey struct{}
+
+// ContextWithRoute sends the queries run with ctx through the Router to the
+// route named name, whatever data sources they reference.
+func ContextWithRoute(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, routeKey{}, name)
+}
+
+// Route returns the Instance a query running sql with ctx is sent to. Every
+// s3:// location in sql must belong to the same route; the longest matching
+// source wins.
+func (router *Router) Route(ctx context.Context, sql string) (*Instance, error) {
+	if name, ok := ctx.Value(routeKey{}).(string); ok {
+		for _, route := range router.routes {
+			if route.Name == name {
+				return route.Instance, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: unknown route %q", ErrNoRoute, name)
+	}
+	var chosen *Route
+	for _, location := range s3Reference.FindAllString(sql, -1) {
+		route := router.match(location)
+		if route == nil {
+			continue
+		}
+		if chosen != nil && chosen != route {
+			return nil, fmt.Errorf("%w: query spans routes %q and %q", ErrNoRoute, chosen.Name, route.Name)
+		}
+		chosen = route
+	}
+	if chosen != nil {
+		return chosen.Instance, nil
+	}
+	for _, route := range router.routes {
+		if route.Default {
+			return route.Instance, nil
+		}
+	}
+	return nil, ErrNoRoute
+}
+
+// match returns the route with the longest source containing location.
+func (router *Router) match(location string) *Route {
+	var best *Route
+	longest := 0
+	for i, route := range router.routes {
+		for _, source := range route.Sources {
+			prefix := source
+			if !strings.HasPrefix(prefix, "s3://") {
+				prefix = "s3://" + strings.TrimSuffix(prefix, "/") + "/"
+			}
+			if (location == strings.TrimSuffix(prefix, "/") || strings.HasPrefix(location, prefix)) && len(prefix) > longest {
+				best, longest = &router.routes[i], len(prefix)
+			}
+		}
+	}
+	return best
+}
+
+// Query runs sql on the Instance its route selects.
+func (router *Router) Query(ctx context.Context, sql string) (*message.Response, error) {
+	instance, err := router.Route(ctx, sql)
+	if err != nil {
+		return &message.Response{}, err
+	}
+	return instance.queryContext(ctx, sql)
+}
+
+// QueryResult is like Query but returns a ResultSet.
+func (router *Router) QueryResult(ctx context.Context, sql string) (*ResultSet, error) {
+	response, err := router.Query(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	return NewResultSet(response), nil
+}
+
+// Close closes the Instances NewRouter created. Instances given in
+// Route.Instance are left open.
+func (router *Router) Close() error {
+	var errs []error
+	for _, route := range router.owned {
+		if err := route.Instance.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", route.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
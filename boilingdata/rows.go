@@ -0,0 +1,100 @@
+package boilingdata
+
+import (
+	"errors"
+	"fmt"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// Rows iterates over the rows of a response in the style of database/sql:
+//
+//	rows := boilingdata.NewRows(response)
+//	defer rows.Close()
+//	for rows.Next() {
+//		err := rows.Scan(&name, &count)
+//	}
+type Rows struct {
+	columns []string
+	data    []map[string]interface{}
+	index   int
+	closed  bool
+	err     error
+}
+
+// NullValue is a Scan destination that keeps an explicit NULL apart from a
+// column missing from the row. Valid is false for both; Present tells them
+// apart.
+type NullValue struct {
+	Value   interface{}
+	Valid   bool
+	Present bool
+}
+
+// NewRows wraps response for row by row iteration.
+func NewRows(response *message.Response) *Rows {
+	rows := &Rows{index: -1}
+	if response == nil {
+		return rows
+	}
+	rows.data = response.Data
+	rows.columns = response.Columns()
+	return rows
+}
+
+// Columns returns the column names in server order.
+func (rows *Rows) Columns() []string {
+	return rows.columns
+}
+
+// Next advances to the next row. It returns false when no rows remain or the
+// Rows has been closed.
+func (rows *Rows) Next() bool {
+	if rows.closed {
+		return false
+	}
+	rows.index++
+	if rows.index >= len(rows.data) {
+		rows.Close()
+		return false
+	}
+	return true
+}
+
+// Scan copies the columns of the current row into dest, which must contain
+// one pointer per column.
+func (rows *Rows) Scan(dest ...interface{}) error {
+	if rows.closed {
+		return errors.New("Rows are closed")
+	}
+	if rows.index < 0 {
+		return errors.New("Scan called without calling Next")
+	}
+	if len(dest) != len(rows.columns) {
+		return fmt.Errorf("expected %d destination arguments in Scan, not %d", len(rows.columns), len(dest))
+	}
+	row := rows.data[rows.index]
+	for i, column := range rows.columns {
+		if null, ok := dest[i].(*NullValue); ok {
+			value, present := row[column]
+			*null = NullValue{Value: value, Valid: value != nil, Present: present}
+			continue
+		}
+		if err := convertAssign(dest[i], row[column]); err != nil {
+			return fmt.Errorf("Scan error on column %q: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// Err returns the error, if any, encountered during iteration.
+func (rows *Rows) Err() error {
+	return rows.err
+}
+
+// Close releases the rows. It is safe to call more than once.
+func (rows *Rows) Close() error {
+	rows.closed = true
+	rows.data = nil
+	return nil
+}
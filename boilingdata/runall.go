@@ -0,0 +1,111 @@
+package boilingdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// RunOption configures RunAll.
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	concurrency int
+	collectAll  bool
+}
+
+// RunConcurrency runs at most n of the queries of RunAll at a time. The
+// default, or n <= 0, runs them all at once.
+func RunConcurrency(n int) RunOption {
+	return func(config *runConfig) {
+		config.concurrency = n
+	}
+}
+
+// RunCollectAll makes RunAll run every query even when some fail, and
+// return the errors of all of them joined. By default the first error
+// cancels the queries still running and is returned alone.
+func RunCollectAll() RunOption {
+	return func(config *runConfig) {
+		config.collectAll = true
+	}
+}
+
+// RunAll runs queries on instance concurrently. The responses are in the
+// order of queries; the entry of a query that failed or did not run is nil.
+// Queries without a request ID get one.
+func RunAll(ctx context.Context, instance *Instance, queries []message.Payload, opts ...RunOption) ([]*message.Response, error) {
+	var config runConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make([]*message.Response, len(queries))
+	errs := make([]error, len(queries))
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	concurrency := config.concurrency
+	if concurrency <= 0 {
+		concurrency = len(queries)
+	}
+	slots := make(chan struct{}, max(concurrency, 1))
+	for i, payload := range queries {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			errs[i] = fmt.Errorf("query %d: %w", i, ctx.Err())
+			continue
+		}
+		wg.Add(1)
+		go func(i int, payload message.Payload) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			response, err := instance.runPayload(ctx, payload)
+			if err != nil {
+				errs[i] = fmt.Errorf("query %d: %w", i, err)
+				if !config.collectAll {
+					once.Do(func() {
+						firstErr = errs[i]
+						cancel()
+					})
+				}
+				return
+			}
+			responses[i] = response
+		}(i, payload)
+	}
+	wg.Wait()
+	if !config.collectAll {
+		if firstErr != nil {
+			return responses, firstErr
+		}
+		return responses, ctx.Err()
+	}
+	return responses, errors.Join(errs...)
+}
+
+// runPayload sends payload through QueryContext.
+func (instance *Instance) runPayload(ctx context.Context, payload message.Payload) (*message.Response, error) {
+	if payload.MessageType == "" {
+		payload.MessageType = message.SQLQueryMessage
+	}
+	if payload.RequestID == "" {
+		payload.RequestID = instance.requestID(ctx)
+	}
+	payloadMessage, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling Payload : %w", err)
+	}
+	return instance.QueryContext(ctx, payloadMessage)
+}
@@ -0,0 +1,105 @@
+package boilingdata
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// StatementResult is the outcome of one statement run by ExecScript.
+type StatementResult struct {
+	SQL      string
+	Response *message.Response
+	Err      error
+}
+
+// ExecScript splits script into statements and runs them in order over the
+// Instance's connection. It stops at the first failing statement; the
+// returned slice holds a result for every statement that ran, and the error
+// identifies the statement that failed.
+func (instance *Instance) ExecScript(script string) ([]StatementResult, error) {
+	statements := SplitStatements(script)
+	results := make([]StatementResult, 0, len(statements))
+	for i, statement := range statements {
+		response, err := instance.querySQL(statement + ";")
+		results = append(results, StatementResult{SQL: statement, Response: response, Err: err})
+		if err != nil {
+			return results, fmt.Errorf("Error in statement %d: %w", i+1, err)
+		}
+	}
+	return results, nil
+}
+
+// SplitStatements splits a SQL script on semicolons, ignoring those inside
+// quoted strings, quoted identifiers and comments. Statements are returned
+// trimmed and without the trailing semicolon; empty statements are dropped.
+func SplitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	hasCode := false
+	flush := func() {
+		if hasCode {
+			statements = append(statements, strings.TrimSpace(current.String()))
+		}
+		current.Reset()
+		hasCode = false
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		if end := skipLiteral(script, i); end > i {
+			current.WriteString(script[i:end])
+			hasCode = hasCode || c == '\'' || c == '"'
+			i = end - 1
+			continue
+		}
+		switch {
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+			hasCode = hasCode || !unicode.IsSpace(rune(c))
+		}
+	}
+	flush()
+	return statements
+}
+
+// skipLiteral returns the index just past the quoted string, quoted
+// identifier or comment starting at script[i], or i when none starts there.
+// A line comment ends before its newline.
+func skipLiteral(script string, i int) int {
+	switch {
+	case script[i] == '\'' || script[i] == '"':
+		return closingQuote(script, i)
+	case strings.HasPrefix(script[i:], "--"):
+		if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+			return i + end
+		}
+		return len(script)
+	case strings.HasPrefix(script[i:], "/*"):
+		if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+			return i + 2 + end + 2
+		}
+		return len(script)
+	}
+	return i
+}
+
+// closingQuote returns the index just past the quote closing the one at
+// start. A doubled quote character is an escaped quote.
+func closingQuote(script string, start int) int {
+	quote := script[start]
+	for i := start + 1; i < len(script); i++ {
+		if script[i] != quote {
+			continue
+		}
+		if i+1 < len(script) && script[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(script)
+}
@@ -0,0 +1,37 @@
+package boilingdata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	script := `
+		CREATE TABLE t AS SELECT 'a;b' AS s;  -- first; statement
+		/* a ; comment */ INSERT INTO t VALUES ('it''s;');
+		SELECT "x;y" FROM t;;
+	`
+	want := []string{
+		"CREATE TABLE t AS SELECT 'a;b' AS s",
+		"-- first; statement\n\t\t/* a ; comment */ INSERT INTO t VALUES ('it''s;')",
+		`SELECT "x;y" FROM t`,
+	}
+	if got := SplitStatements(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitStatements() = %q, want %q", got, want)
+	}
+}
+
+func TestSplitStatementsEmpty(t *testing.T) {
+	for _, script := range []string{"", " ; ;\n", "-- only a comment", "/* only; a comment */;"} {
+		if got := SplitStatements(script); got != nil {
+			t.Errorf("SplitStatements(%q) = %q, want none", script, got)
+		}
+	}
+}
+
+func TestSplitStatementsUnterminated(t *testing.T) {
+	want := []string{"SELECT 1", "SELECT 'a; b"}
+	if got := SplitStatements("SELECT 1; SELECT 'a; b"); !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitStatements() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,36 @@
+package boilingdata
+
+import (
+	"context"
+
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// ServerInfo holds the protocol, server and engine versions and the
+// capabilities the connected backend announced.
+type ServerInfo = wsclient.ServerInfo
+
+// ServerInfo returns what the connected backend announced about itself, so
+// features such as Arrow output can be gated on its capabilities:
+//
+//	if instance.ServerInfo().Supports("arrow") { ... }
+//
+// It is empty until the server has sent an INFO frame.
+func (instance *Instance) ServerInfo() ServerInfo {
+	return instance.Wsc.ServerInfo()
+}
+
+// requireCapability connects if needed and returns unsupported unless the
+// server announced capability, for message types that are not part of the
+// protocol every server speaks.
+func (instance *Instance) requireCapability(ctx context.Context, capability string, unsupported error) error {
+	if instance.client(ctx).IsWebSocketClosed() {
+		if err := instance.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if !instance.client(ctx).ServerInfo().Supports(capability) {
+		return unsupported
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package boilingdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// Session is a logical session pinned to its own websocket connection, so
+// session state set by statements such as SET or PRAGMA applies to every
+// later query of the session and to no query outside it. Session queries
+// bypass the result cache and query deduplication.
+type Session struct {
+	instance *Instance
+	wsc      *wsclient.WSSClient
+}
+
+// Session opens a new session. It connects on its first query and must be
+// closed when no longer needed. Its connection is not rotated, since that
+// would lose the session state; it ends at the server's connection TTL.
+func (instance *Instance) Session() *Session {
+	wsc := wsclient.NewWSSClient(instance.Auth.wssURL(), 0, nil, instance.wsOptions...)
+	wsc.SetConnectionListener(instance.connectionListener())
+	return &Session{instance: instance, wsc: wsc}
+}
+
+// Query sends the payload on the session's connection and waits for its
+// response.
+func (session *Session) Query(payloadMessage []byte) (*message.Response, error) {
+	return session.QueryContext(context.Background(), payloadMessage)
+}
+
+// QueryContext is like Query but stops waiting when ctx is done.
+func (session *Session) QueryContext(ctx context.Context, payloadMessage []byte) (*message.Response, error) {
+	var payload message.Payload
+	if err := json.Unmarshal(payloadMessage, &payload); err != nil {
+		return &message.Response{}, fmt.Errorf("error unmarshalling Payload : %w", err)
+	}
+	return session.instance.execute(withClient(ctx, session.wsc), payloadMessage, payload)
+}
+
+// Exec runs sql in the session.
+func (session *Session) Exec(sql string) (*message.Response, error) {
+	payload := message.GetPayLoad()
+	payload.SQL = sql
+	payload.RequestID = session.instance.requestID(context.Background())
+	payloadMessage, err := json.Marshal(payload)
+	if err != nil {
+		return &message.Response{}, fmt.Errorf("error marshalling Payload : %w", err)
+	}
+	return session.Query(payloadMessage)
+}
+
+// Close closes the session's connection, discarding its session state.
+func (session *Session) Close() error {
+	session.wsc.Close()
+	return nil
+}
+
+type clientKey struct{}
+
+// withClient pins the queries run with ctx to wsc.
+func withClient(ctx context.Context, wsc *wsclient.WSSClient) context.Context {
+	return context.WithValue(ctx, clientKey{}, wsc)
+}
+
+// pinned reports whether ctx pins its queries to a session, whose SET and
+// PRAGMA state may change their results.
+func pinned(ctx context.Context) bool {
+	_, ok := ctx.Value(clientKey{}).(*wsclient.WSSClient)
+	return ok
+}
+
+// client returns the connection queries run with ctx use.
+func (instance *Instance) client(ctx context.Context) *wsclient.WSSClient {
+	if wsc, ok := ctx.Value(clientKey{}).(*wsclient.WSSClient); ok {
+		return wsc
+	}
+	return instance.Wsc
+}
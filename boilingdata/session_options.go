@@ -0,0 +1,87 @@
+package boilingdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+var sessionOptionKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// sessionOptions remembers the options set with SetSessionOption in the
+// order they were first set.
+type sessionOptions struct {
+	mu     sync.Mutex
+	keys   []string
+	values map[string]string
+}
+
+func (o *sessionOptions) set(key string, value string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.values == nil {
+		o.values = make(map[string]string)
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o *sessionOptions) statements() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	statements := make([]string, 0, len(o.keys))
+	for _, key := range o.keys {
+		statements = append(statements, setStatement(key, o.values[key]))
+	}
+	return statements
+}
+
+func setStatement(key string, value string) string {
+	return "SET " + key + " = " + QuoteLiteral(value) + ";"
+}
+
+// SetSessionOption sets an engine setting such as TimeZone for the
+// connection and remembers it, so it is set again whenever the connection
+// is re-established.
+func (instance *Instance) SetSessionOption(key string, value string) error {
+	if !sessionOptionKey.MatchString(key) {
+		return fmt.Errorf("invalid session option name %q", key)
+	}
+	payload := message.GetPayLoad()
+	payload.SQL = setStatement(key, value)
+	payload.RequestID = instance.requestID(context.Background())
+	payloadMessage, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshalling Payload : %w", err)
+	}
+	if _, err := instance.execute(context.Background(), payloadMessage, payload); err != nil {
+		return err
+	}
+	instance.sessionOptions.set(key, value)
+	return nil
+}
+
+// replaySessionOptions sets the remembered session options on a new
+// connection.
+func (instance *Instance) replaySessionOptions(ctx context.Context, wsc *wsclient.WSSClient) {
+	for _, statement := range instance.sessionOptions.statements() {
+		payload := message.GetPayLoad()
+		payload.SQL = statement
+		payload.RequestID = instance.requestID(ctx)
+		payloadMessage, err := json.Marshal(payload)
+		if err == nil {
+			_, err = instance.sendWebSocket(withClient(ctx, wsc), payloadMessage, payload)
+		}
+		if err != nil {
+			log.Printf("Error restoring session option (%s): %v", instance.redactor.Redact(statement), err)
+		}
+	}
+}
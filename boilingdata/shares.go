@@ -0,0 +1,78 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// ErrSharesUnsupported is returned by the share calls when the server has
+// not announced the constants.SharesCapability capability.
+var ErrSharesUnsupported = errors.New("server does not support data share messages")
+
+// DataShare is a data share created by, or shared with, the signed in user.
+type DataShare struct {
+	Name        string `json:"shareName"`
+	Owner       string `json:"ownerUserName"`
+	Target      string `json:"targetUserName"`
+	SQL         string `json:"sql"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+}
+
+// CreateShare shares the result set of sql with targetUserName under
+// shareName. Like ListShares and AcceptShare it fails with
+// ErrSharesUnsupported unless the server announced share support.
+func (instance *Instance) CreateShare(shareName string, sql string, targetUserName string, description string) (*DataShare, error) {
+	payload := message.GetSharePayload(message.CreateShareMessage)
+	payload.ShareName = shareName
+	payload.SQL = sql
+	payload.TargetUserName = targetUserName
+	payload.Description = description
+	shares, err := instance.shareRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no share returned for %q", shareName)
+	}
+	return &shares[0], nil
+}
+
+// ListShares returns the shares owned by, or offered to, the signed in user.
+func (instance *Instance) ListShares() ([]DataShare, error) {
+	return instance.shareRequest(message.GetSharePayload(message.ListSharesMessage))
+}
+
+// AcceptShare accepts a share offered to the signed in user.
+func (instance *Instance) AcceptShare(shareName string) (*DataShare, error) {
+	payload := message.GetSharePayload(message.AcceptShareMessage)
+	payload.ShareName = shareName
+	shares, err := instance.shareRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no share returned for %q", shareName)
+	}
+	return &shares[0], nil
+}
+
+func (instance *Instance) shareRequest(payload message.SharePayload) ([]DataShare, error) {
+	if err := instance.requireCapability(context.Background(), constants.SharesCapability, ErrSharesUnsupported); err != nil {
+		return nil, err
+	}
+	payload.RequestID = instance.requestID(context.Background())
+	response, err := instance.sendMessage(payload.MessageType, payload.RequestID, payload)
+	if err != nil {
+		return nil, err
+	}
+	var shares []DataShare
+	if err := decodeRows(response.Data, &shares); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
@@ -0,0 +1,101 @@
+package boilingdata
+
+import (
+	"context"
+	"sync"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+type inflightQuery struct {
+	done     chan struct{}
+	response *message.Response
+	err      error
+	// cancelled is set when the execution failed because the context of
+	// the caller running it ended, which says nothing about the query.
+	cancelled bool
+}
+
+// queryGroup coalesces concurrent executions of the same SQL into a single
+// server request whose result is shared by every caller.
+type queryGroup struct {
+	mu    sync.Mutex
+	calls map[string]*inflightQuery
+}
+
+func newQueryGroup() *queryGroup {
+	return &queryGroup{calls: make(map[string]*inflightQuery)}
+}
+
+// do runs fn once per key at a time. shared reports whether the result was
+// produced by another caller's execution; such callers each receive their
+// own deep copy of the response. Callers waiting on another execution give
+// up when ctx ends, and run the query again when that execution was cut
+// short by its own caller's context.
+func (g *queryGroup) do(ctx context.Context, key string, fn func() (*message.Response, error)) (response *message.Response, err error, shared bool) {
+	for {
+		g.mu.Lock()
+		call, ok := g.calls[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return &message.Response{}, ctx.Err(), false
+		}
+		if !call.cancelled {
+			return cloneResponse(call.response), call.err, true
+		}
+	}
+	call := &inflightQuery{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	response, err = fn()
+	// Keep a private copy to hand out, as the caller may modify response
+	// while others are still copying it.
+	call.response, call.err = cloneResponse(response), err
+	call.cancelled = err != nil && ctx.Err() != nil
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(call.done)
+	return response, err, false
+}
+
+// flight coalesces concurrent calls of an operation, such as signing in,
+// into one execution whose result and error every caller receives.
+type flight[T any] struct {
+	mu   sync.Mutex
+	call *flightCall[T]
+}
+
+type flightCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// do runs fn unless a call is already in flight, in which case it waits for
+// that call and returns its result.
+func (f *flight[T]) do(fn func() (T, error)) (T, error) {
+	f.mu.Lock()
+	if call := f.call; call != nil {
+		f.mu.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+	call := &flightCall[T]{done: make(chan struct{})}
+	f.call = call
+	f.mu.Unlock()
+
+	call.value, call.err = fn()
+	f.mu.Lock()
+	f.call = nil
+	f.mu.Unlock()
+	close(call.done)
+	return call.value, call.err
+}
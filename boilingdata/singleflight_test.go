@@ -0,0 +1,50 @@
+package boilingdata
+
+import (
+	"context"
+	"testing"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+func TestQueryGroupCopiesSharedResults(t *testing.T) {
+	group := newQueryGroup()
+	call := &inflightQuery{
+		done:     make(chan struct{}),
+		response: &message.Response{Data: []map[string]interface{}{{"v": 1.0}}},
+	}
+	group.calls["key"] = call
+	close(call.done)
+
+	run := func() (*message.Response, error) {
+		t.Fatal("query ran again while another execution was in flight")
+		return nil, nil
+	}
+	first, _, shared := group.do(context.Background(), "key", run)
+	if !shared {
+		t.Fatal("result not shared")
+	}
+	first.Data[0]["v"] = 2.0
+	second, _, _ := group.do(context.Background(), "key", run)
+	if second.Data[0]["v"] != 1.0 {
+		t.Errorf("caller sees the row modified by another caller: %v", second.Data[0])
+	}
+}
+
+func TestMergeKey(t *testing.T) {
+	key := func(payloadMessage string) string {
+		t.Helper()
+		key, err := mergeKey([]byte(payloadMessage), message.Payload{SQL: "SELECT  1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	base := key(`{"messageType":"SQL_QUERY","sql":"SELECT  1","requestId":"a"}`)
+	if other := key(`{"messageType":"SQL_QUERY","sql":"SELECT  1","requestId":"b"}`); other != base {
+		t.Errorf("request IDs split the key: %s != %s", other, base)
+	}
+	if other := key(`{"messageType":"SQL_QUERY","sql":"SELECT  1","requestId":"b","engine":"x"}`); other == base {
+		t.Errorf("payload options do not change the key %s", base)
+	}
+}
@@ -0,0 +1,152 @@
+package boilingdata
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// SlowQuery describes a query that took longer than the threshold set with
+// WithSlowQueryLog.
+type SlowQuery struct {
+	RequestID string
+	SQL       string
+	Duration  time.Duration
+	Err       error
+}
+
+// slowQueryLog reports queries slower than threshold.
+type slowQueryLog struct {
+	threshold time.Duration
+	maxSQL    int
+	fn        func(query SlowQuery)
+}
+
+// WithSlowQueryLog reports every query taking threshold or longer to fn, or
+// to the standard logger when fn is nil. SQL longer than maxSQL bytes is
+// truncated; zero keeps it whole. The SQL is redacted with the redactors set
+// by WithRedactor.
+func WithSlowQueryLog(threshold time.Duration, maxSQL int, fn func(query SlowQuery)) Option {
+	return func(instance *Instance) {
+		if fn == nil {
+			fn = func(query SlowQuery) {
+				log.Printf("Slow query %s took %v: %s", query.RequestID, query.Duration, query.SQL)
+			}
+		}
+		instance.slowLog = &slowQueryLog{threshold: threshold, maxSQL: maxSQL, fn: fn}
+	}
+}
+
+func (l *slowQueryLog) report(payload message.Payload, duration time.Duration, err error, redactor wsclient.Redactor) {
+	if l == nil || duration < l.threshold {
+		return
+	}
+	sql := redactor.Redact(payload.SQL)
+	if l.maxSQL > 0 && len(sql) > l.maxSQL {
+		n := l.maxSQL
+		for n > 0 && !utf8.RuneStart(sql[n]) {
+			n--
+		}
+		sql = sql[:n] + "..."
+	}
+	l.fn(SlowQuery{RequestID: payload.RequestID, SQL: sql, Duration: duration, Err: err})
+}
+
+const (
+	// latencyBase is the upper bound of the first histogram bucket.
+	latencyBase = time.Millisecond
+	// latencyGrowth is the ratio between the bounds of adjacent buckets,
+	// bounding the error of a reported percentile to about 19%.
+	latencyGrowth = 1.1892071150027210 // 2^(1/4)
+	// latencyBuckets covers up to about 18 minutes.
+	latencyBuckets = 81
+)
+
+// latencyHistogram counts query durations in exponentially growing buckets.
+type latencyHistogram struct {
+	mu     sync.Mutex
+	counts [latencyBuckets]uint64
+	total  uint64
+	sum    time.Duration
+	max    time.Duration
+}
+
+func latencyBucket(d time.Duration) int {
+	if d <= latencyBase {
+		return 0
+	}
+	i := int(math.Ceil(math.Log(float64(d)/float64(latencyBase)) / math.Log(latencyGrowth)))
+	return min(i, latencyBuckets-1)
+}
+
+func latencyBound(i int) time.Duration {
+	return time.Duration(float64(latencyBase) * math.Pow(latencyGrowth, float64(i)))
+}
+
+func (h *latencyHistogram) record(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.counts[latencyBucket(d)]++
+	h.total++
+	h.sum += d
+	h.max = max(h.max, d)
+}
+
+func (h *latencyHistogram) snapshot() LatencyStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return LatencyStats{Count: h.total, Sum: h.sum, Max: h.max, counts: h.counts}
+}
+
+// LatencyStats is a snapshot of the durations of the queries of an
+// Instance.
+type LatencyStats struct {
+	Count  uint64
+	Sum    time.Duration
+	Max    time.Duration
+	counts [latencyBuckets]uint64
+}
+
+// Mean returns the average query duration.
+func (s LatencyStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Sum / time.Duration(s.Count)
+}
+
+// Percentile returns an upper estimate of the duration below which the
+// fraction p (0 to 1) of the queries finished, e.g. Percentile(0.99). It is
+// never more than Max.
+func (s LatencyStats) Percentile(p float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(min(max(p, 0), 1) * float64(s.Count)))
+	var seen uint64
+	for i, count := range s.counts {
+		seen += count
+		if seen >= max(rank, 1) {
+			return min(latencyBound(i), s.Max)
+		}
+	}
+	return s.Max
+}
+
+// Latency returns the distribution of the durations of the queries run
+// through Query and QueryContext since the Instance was created.
+func (instance *Instance) Latency() LatencyStats {
+	return instance.latency.snapshot()
+}
+
+// observe records the duration of a query and reports it when slow.
+func (instance *Instance) observe(payload message.Payload, start time.Time, err error) {
+	duration := time.Since(start)
+	instance.latency.record(duration)
+	instance.slowLog.report(payload, duration, err, instance.redactor)
+}
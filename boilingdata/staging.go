@@ -0,0 +1,86 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+type stagingTarget struct {
+	UploadURL string `json:"uploadUrl"`
+	S3URI     string `json:"s3Uri"`
+}
+
+// ErrStagingUnsupported is returned by StageFile when the server has not
+// announced the constants.StagingCapability capability.
+var ErrStagingUnsupported = errors.New("server does not support staging uploads")
+
+// StageFile uploads a local CSV, Parquet or JSON file to the user's staging
+// area and returns the s3:// URI that can be used in queries. It fails with
+// ErrStagingUnsupported unless the server announced staging support.
+func (instance *Instance) StageFile(path string) (string, error) {
+	format := tableFormat(path)
+	if format == "" {
+		return "", fmt.Errorf("unsupported file format for %q", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if err := instance.requireCapability(context.Background(), constants.StagingCapability, ErrStagingUnsupported); err != nil {
+		return "", err
+	}
+	payload := message.GetStagePayload()
+	payload.RequestID = instance.requestID(context.Background())
+	payload.FileName = filepath.Base(path)
+	payload.ContentType = stagingContentType(format)
+	response, err := instance.sendMessage(payload.MessageType, payload.RequestID, payload)
+	if err != nil {
+		return "", err
+	}
+	var targets []stagingTarget
+	if err := decodeRows(response.Data, &targets); err != nil {
+		return "", err
+	}
+	if len(targets) == 0 || targets[0].UploadURL == "" {
+		return "", fmt.Errorf("no staging upload URL returned for %q", payload.FileName)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, targets[0].UploadURL, file)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", payload.ContentType)
+	resp, err := instance.Auth.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Error uploading file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("Error uploading file: unexpected status %s", resp.Status)
+	}
+	return targets[0].S3URI, nil
+}
+
+func stagingContentType(format string) string {
+	switch format {
+	case "csv":
+		return "text/csv"
+	case "json":
+		return "application/json"
+	}
+	return "application/octet-stream"
+}
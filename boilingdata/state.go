@@ -0,0 +1,76 @@
+package boilingdata
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// ConnectionState is the phase of an Instance's connection.
+type ConnectionState = wsclient.ConnectionState
+
+const (
+	Disconnected   = wsclient.Disconnected
+	Connecting     = wsclient.Connecting
+	Authenticating = wsclient.Authenticating
+	Connected      = wsclient.Connected
+	Draining       = wsclient.Draining
+	Reconnecting   = wsclient.Reconnecting
+)
+
+// connectionPhase overlays the phases the websocket client cannot see
+// itself: authenticating before a dial, waiting to retry and draining on
+// Close. Zero means no overlay.
+type connectionPhase struct {
+	state atomic.Int32
+}
+
+func (p *connectionPhase) set(state ConnectionState) {
+	p.state.Store(int32(state) + 1)
+}
+
+func (p *connectionPhase) clear() {
+	p.state.Store(0)
+}
+
+func (p *connectionPhase) get() (ConnectionState, bool) {
+	state := p.state.Load()
+	return ConnectionState(state - 1), state != 0
+}
+
+// State returns the phase of the connection: Disconnected, Connecting,
+// Authenticating, Connected, Draining or Reconnecting.
+func (instance *Instance) State() ConnectionState {
+	if state, ok := instance.phase.get(); ok {
+		return state
+	}
+	return instance.Wsc.State()
+}
+
+// setPhase sets the overlay when wsc is the Instance's own client; the
+// clients of other pools report their state directly.
+func (instance *Instance) setPhase(wsc *wsclient.WSSClient, state ConnectionState) {
+	if wsc == instance.Wsc {
+		instance.phase.set(state)
+	}
+}
+
+func (instance *Instance) clearPhase(wsc *wsclient.WSSClient) {
+	if wsc == instance.Wsc {
+		instance.phase.clear()
+	}
+}
+
+// LastActivity returns when a message was last sent or received or the
+// connection last opened, or the zero time if none has happened.
+func (instance *Instance) LastActivity() time.Time {
+	return instance.Wsc.LastActivity()
+}
+
+// IdleDeadline returns when the connection will be closed for inactivity
+// unless a query is sent before, or the zero time when it is not connected,
+// e.g. to report "connection will close in 30s".
+func (instance *Instance) IdleDeadline() time.Time {
+	return instance.Wsc.IdleDeadline()
+}
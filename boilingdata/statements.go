@@ -0,0 +1,37 @@
+package boilingdata
+
+import "sync"
+
+// describeCache remembers the DescribeQuery results of recent statements.
+type describeCache struct {
+	mu      sync.Mutex
+	size    int
+	columns map[string][]ColumnMeta
+	order   []string
+}
+
+func newDescribeCache(size int) *describeCache {
+	return &describeCache{size: size, columns: make(map[string][]ColumnMeta)}
+}
+
+func (c *describeCache) get(sql string) ([]ColumnMeta, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	columns, ok := c.columns[normalizeSQL(sql)]
+	return columns, ok
+}
+
+// set stores columns for sql, evicting the oldest entry when full.
+func (c *describeCache) set(sql string, columns []ColumnMeta) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := normalizeSQL(sql)
+	if _, ok := c.columns[key]; !ok {
+		if len(c.order) >= c.size {
+			delete(c.columns, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, key)
+	}
+	c.columns[key] = columns
+}
@@ -0,0 +1,21 @@
+package boilingdata
+
+import "testing"
+
+func TestDescribeCache(t *testing.T) {
+	cache := newDescribeCache(2)
+	cache.set("SELECT a FROM t WHERE x = 'a  b'", []ColumnMeta{{Name: "a", Type: "INTEGER"}})
+	if _, ok := cache.get("SELECT a FROM t WHERE x = 'a b'"); ok {
+		t.Error("statements with different literals share columns")
+	}
+	columns, ok := cache.get("SELECT a\nFROM t WHERE x = 'a  b';")
+	if !ok || len(columns) != 1 || columns[0].Name != "a" {
+		t.Errorf("get() = %v, %v for the same statement respelled", columns, ok)
+	}
+
+	cache.set("SELECT 2", nil)
+	cache.set("SELECT 3", nil)
+	if _, ok := cache.get("SELECT a FROM t WHERE x = 'a  b'"); ok {
+		t.Error("oldest statement was kept beyond the size")
+	}
+}
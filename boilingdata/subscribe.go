@@ -0,0 +1,169 @@
+package boilingdata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+	message "github.com/boilingdata/go-boilingdata/messages"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// ErrSubscriptionOverflow ends a subscription whose consumer fell more than
+// constants.SubscriptionBuffer batches behind.
+var ErrSubscriptionOverflow = errors.New("subscription batches not consumed in time")
+
+// Subscription is a standing query started with Instance.Subscribe. Its
+// incremental DATA batches arrive on Batches until it ends.
+type Subscription struct {
+	wsc       *wsclient.WSSClient
+	requestID string
+	batches   chan *message.Response
+	done      chan struct{}
+	err       error
+	cancel    context.CancelFunc
+	closed    atomic.Bool
+	// mu guards sending on batches against closing it.
+	mu    sync.RWMutex
+	ended bool
+}
+
+// Subscribe starts sql as a standing query on servers that announce the
+// constants.SubscribeCapability capability, and returns the stream of its
+// incremental batches:
+//
+//	sub, err := instance.Subscribe(ctx, sql)
+//	if err != nil {
+//		return err
+//	}
+//	defer sub.Close()
+//	for batch := range sub.Batches() {
+//		...
+//	}
+//	return sub.Err()
+//
+// The subscription lasts until Close, until ctx is done, until the server
+// fails it or until the connection drops; it is not resumed after a
+// reconnect, so callers resubscribe on an error. Batches are delivered in
+// arrival order. Up to constants.SubscriptionBuffer batches are buffered;
+// a consumer falling further behind ends the subscription with
+// ErrSubscriptionOverflow rather than stalling the connection. On other
+// servers it fails with ErrSubscribeUnsupported. Use
+// WithKeepOpenWhilePending so the idle timeout does not end a quiet
+// subscription. It does not count as a query in flight for Close.
+func (instance *Instance) Subscribe(ctx context.Context, sql string) (*Subscription, error) {
+	end, err := instance.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	end()
+	payload := message.GetPayLoad()
+	payload.MessageType = message.SubscribeMessage
+	payload.SQL = sql
+	payload.RequestID = instance.requestID(ctx)
+	payloadMessage, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling Payload : %w", err)
+	}
+	wsc := instance.client(ctx)
+	if wsc.IsWebSocketClosed() {
+		if err := instance.connect(ctx); err != nil {
+			return nil, err
+		}
+	}
+	sub := &Subscription{
+		wsc:       wsc,
+		requestID: payload.RequestID,
+		batches:   make(chan *message.Response, constants.SubscriptionBuffer),
+		done:      make(chan struct{}),
+	}
+	ctx, sub.cancel = context.WithCancel(ctx)
+	err = wsc.SubscribeContext(ctx, payloadMessage, payload, func(header *message.Response, rows []json.RawMessage) error {
+		batch := *header
+		batch.Data = make([]map[string]interface{}, 0, len(rows))
+		for _, raw := range rows {
+			var row map[string]interface{}
+			if err := newDecoder(raw, instance.preciseNumbers).Decode(&row); err != nil {
+				return fmt.Errorf("Error parsing JSON: %w", err)
+			}
+			batch.Data = append(batch.Data, row)
+		}
+		return sub.send(&batch)
+	})
+	if err != nil {
+		sub.cancel()
+		return nil, err
+	}
+	go func() {
+		err := wsc.WaitSubscription(ctx, sub.requestID)
+		if errors.Is(err, ErrSubscriptionOverflow) {
+			wsc.CancelRequest(sub.requestID, err)
+		}
+		if !sub.closed.Load() {
+			sub.err = err
+		}
+		sub.cancel()
+		sub.mu.Lock()
+		sub.ended = true
+		close(sub.batches)
+		sub.mu.Unlock()
+		close(sub.done)
+	}()
+	return sub, nil
+}
+
+// send buffers batch for the consumer, unless the subscription has ended.
+// It never blocks the receive loop.
+func (sub *Subscription) send(batch *message.Response) error {
+	sub.mu.RLock()
+	defer sub.mu.RUnlock()
+	if sub.ended {
+		return wsclient.ErrSubscriptionClosed
+	}
+	select {
+	case sub.batches <- batch:
+		return nil
+	default:
+		return ErrSubscriptionOverflow
+	}
+}
+
+// RequestID returns the request ID of the subscription.
+func (sub *Subscription) RequestID() string {
+	return sub.requestID
+}
+
+// Batches returns the incremental batches of the subscription. The channel
+// is closed when the subscription ends; Err then tells why.
+func (sub *Subscription) Batches() <-chan *message.Response {
+	return sub.batches
+}
+
+// Done is closed when the subscription has ended.
+func (sub *Subscription) Done() <-chan struct{} {
+	return sub.done
+}
+
+// Err returns the error that ended the subscription, or nil while it runs
+// and after Close.
+func (sub *Subscription) Err() error {
+	select {
+	case <-sub.done:
+		return sub.err
+	default:
+		return nil
+	}
+}
+
+// Close stops the subscription on the server and waits until it has ended.
+// Batches buffered before are still received from Batches. It is safe to call more than once.
+func (sub *Subscription) Close() error {
+	sub.closed.Store(true)
+	sub.cancel()
+	<-sub.done
+	return nil
+}
@@ -0,0 +1,80 @@
+package boilingdata
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// TimeoutError is returned when the budget of a phase set with WithTimeouts
+// runs out; its Phase tells which.
+type TimeoutError = wsclient.TimeoutError
+
+// TimeoutPhase is the part of a query whose time budget ran out.
+type TimeoutPhase = wsclient.TimeoutPhase
+
+const (
+	PhaseAuth      = wsclient.PhaseAuth
+	PhaseDial      = wsclient.PhaseDial
+	PhaseWrite     = wsclient.PhaseWrite
+	PhaseFirstByte = wsclient.PhaseFirstByte
+	PhaseTotal     = wsclient.PhaseTotal
+)
+
+// Errors matched by the TimeoutError of each phase.
+var (
+	ErrAuthTimeout      = wsclient.ErrAuthTimeout
+	ErrDialTimeout      = wsclient.ErrDialTimeout
+	ErrWriteTimeout     = wsclient.ErrWriteTimeout
+	ErrFirstByteTimeout = wsclient.ErrFirstByteTimeout
+	ErrQueryTimeout     = wsclient.ErrQueryTimeout
+)
+
+// WithTimeouts sets the budgets of timeouts that are not zero, each phase
+// failing with its own TimeoutError instead of sharing the single response
+// timeout.
+func WithTimeouts(timeouts Timeouts) Option {
+	return func(instance *Instance) {
+		if timeouts.Idle > 0 {
+			WithIdleTimeout(timeouts.Idle)(instance)
+		}
+		if timeouts.Auth > 0 {
+			instance.authTimeout = timeouts.Auth
+		}
+		instance.wsOptions = append(instance.wsOptions,
+			wsclient.WithDialTimeout(timeouts.Dial),
+			wsclient.WithDeadlines(timeouts.Read, timeouts.Write),
+			wsclient.WithResponseTimeout(timeouts.Response),
+		)
+		if timeouts.FirstByte > 0 {
+			instance.wsOptions = append(instance.wsOptions, wsclient.WithFirstByteTimeout(timeouts.FirstByte))
+		}
+	}
+}
+
+// signHeaderWithin is signHeader bounded by the authentication budget. On
+// timeout the attempt continues in the background and a later call shares
+// it.
+func (instance *Instance) signHeaderWithin() (http.Header, error) {
+	if instance.authTimeout <= 0 {
+		return instance.signHeader()
+	}
+	type signed struct {
+		header http.Header
+		err    error
+	}
+	result := make(chan signed, 1)
+	go func() {
+		header, err := instance.signHeader()
+		result <- signed{header, err}
+	}()
+	timer := time.NewTimer(instance.authTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-result:
+		return r.header, r.err
+	case <-timer.C:
+		return nil, &TimeoutError{Phase: PhaseAuth, Budget: instance.authTimeout}
+	}
+}
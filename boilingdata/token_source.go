@@ -0,0 +1,77 @@
+package boilingdata
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenSource returns an oauth2.TokenSource of the BoilingData ID token, so
+// the session can authorize other HTTP clients. Each Token call refreshes
+// the session as Authenticate does.
+func (auth *Auth) TokenSource() oauth2.TokenSource {
+	return authTokenSource{auth: auth}
+}
+
+type authTokenSource struct {
+	auth *Auth
+}
+
+func (s authTokenSource) Token() (*oauth2.Token, error) {
+	idToken, err := s.auth.Authenticate()
+	if err != nil {
+		return nil, err
+	}
+	token := &oauth2.Token{AccessToken: idToken, TokenType: "Bearer"}
+	muLock.Lock()
+	if result := s.auth.authResult; result != nil && result.ExpiresIn != nil {
+		token.Expiry = s.auth.timeWhenLastJwtTokenWasRecieved.Add(time.Duration(*result.ExpiresIn) * time.Second)
+	}
+	muLock.Unlock()
+	return token, nil
+}
+
+// GetInstanceWithTokenSource returns a new Instance for userName that takes
+// its ID tokens from source, so existing token management can drive the
+// client. The ID token is the token's id_token extra value, or else its
+// access token. Like NewInstance, the Instance is not registered for
+// userName.
+func GetInstanceWithTokenSource(userName string, source oauth2.TokenSource, opts ...Option) *Instance {
+	instance := NewInstance(userName, nil, opts...)
+	instance.Auth.tokenSource = source
+	return instance
+}
+
+// sourceToken returns the ID token of the configured token source. A token
+// without expiry is asked for again on every call, leaving the caching to
+// the source. Callers hold muLock.
+func (auth *Auth) sourceToken() (string, error) {
+	token, err := auth.tokenSource.Token()
+	if err != nil {
+		auth.authResult = nil
+		return "", fmt.Errorf("Error getting token from token source: %w", err)
+	}
+	idToken, _ := token.Extra("id_token").(string)
+	if idToken == "" {
+		idToken = token.AccessToken
+	}
+	if idToken == "" {
+		return "", errors.New("UnAuthorized: token source returned no token")
+	}
+	if auth.IsUserLoggedIn() && *auth.authResult.IdToken == idToken && token.Expiry.IsZero() {
+		return idToken, nil
+	}
+	var expiresIn int64
+	if !token.Expiry.IsZero() {
+		expiresIn = int64(time.Until(token.Expiry) / time.Second)
+	}
+	auth.setTokens(&tokenResponse{
+		IDToken:     idToken,
+		AccessToken: token.AccessToken,
+		ExpiresIn:   expiresIn,
+		TokenType:   token.TokenType,
+	})
+	return idToken, nil
+}
@@ -0,0 +1,150 @@
+package boilingdata
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// DecodeValue converts value, as decoded from the JSON response, into the
+// native Go type for columnType (as reported by DESCRIBE):
+//
+//	TIMESTAMP, DATE, TIME     time.Time
+//	DECIMAL, NUMERIC          *big.Rat
+//	BOOLEAN                   bool
+//	BLOB, BYTEA               []byte
+//	signed integer types      int64
+//	unsigned integer types    uint64
+//	HUGEINT, UHUGEINT         *big.Int
+//	DOUBLE, FLOAT, REAL       float64
+//
+// Values of other types, and NULLs, are returned unchanged; json.Number
+// values (see WithPreciseNumbers) stay json.Number.
+func DecodeValue(value interface{}, columnType string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	base := strings.ToUpper(strings.TrimSpace(columnType))
+	if i := strings.IndexByte(base, '('); i >= 0 {
+		base = base[:i]
+	}
+	switch {
+	case strings.HasPrefix(base, "TIMESTAMP"), base == "DATE", base == "TIME", base == "DATETIME":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("converting %T to %s is unsupported", value, columnType)
+		}
+		return parseTime(s)
+	case base == "DECIMAL", base == "NUMERIC":
+		r, ok := new(big.Rat).SetString(numberString(value))
+		if !ok {
+			return nil, fmt.Errorf("cannot parse %v as %s", value, columnType)
+		}
+		return r, nil
+	case base == "BOOLEAN", base == "BOOL":
+		var b bool
+		err := convertAssign(&b, value)
+		return b, err
+	case isBinaryType(base):
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("converting %T to %s is unsupported", value, columnType)
+		}
+		return decodeBinary(s), nil
+	case base == "TINYINT", base == "SMALLINT", base == "INTEGER", base == "INT", base == "BIGINT":
+		var i int64
+		err := convertAssign(&i, value)
+		return i, err
+	case base == "UTINYINT", base == "USMALLINT", base == "UINTEGER", base == "UBIGINT":
+		var u uint64
+		err := convertAssign(&u, value)
+		return u, err
+	case base == "HUGEINT", base == "UHUGEINT":
+		i, ok := new(big.Int).SetString(numberString(value), 10)
+		if !ok {
+			return nil, fmt.Errorf("cannot parse %v as %s", value, columnType)
+		}
+		return i, nil
+	case base == "DOUBLE", base == "FLOAT", base == "REAL":
+		var f float64
+		err := convertAssign(&f, value)
+		return f, err
+	}
+	return value, nil
+}
+
+func isBinaryType(base string) bool {
+	return base == "BLOB" || base == "BYTEA" || base == "VARBINARY"
+}
+
+// decodeBinary decodes a binary value, which the server sends base64
+// encoded. Values that are not valid base64 are taken as raw bytes.
+func decodeBinary(s string) []byte {
+	if data, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return data
+	}
+	return []byte(s)
+}
+
+// BinaryColumns returns the names of the BLOB, BYTEA and VARBINARY columns
+// of columns, as reported by DescribeQuery, e.g. for DecodeBinaryColumns.
+func BinaryColumns(columns []ColumnMeta) []string {
+	var names []string
+	for _, column := range columns {
+		base := strings.ToUpper(strings.TrimSpace(column.Type))
+		if i := strings.IndexByte(base, '('); i >= 0 {
+			base = base[:i]
+		}
+		if isBinaryType(base) {
+			names = append(names, column.Name)
+		}
+	}
+	return names
+}
+
+// DecodeBinaryColumns returns a RowMapper decoding the base64 strings of the
+// named columns into []byte, so blob data does not surface as large opaque
+// strings:
+//
+//	columns, err := instance.DescribeQuery(sql)
+//	ctx = ContextWithRowMapper(ctx, DecodeBinaryColumns(BinaryColumns(columns)...))
+func DecodeBinaryColumns(columns ...string) RowMapper {
+	return func(row message.Row) (message.Row, error) {
+		for _, column := range columns {
+			if s, ok := row[column].(string); ok {
+				row[column] = decodeBinary(s)
+			}
+		}
+		return row, nil
+	}
+}
+
+// DecodeTyped returns the rows of response with each value converted by
+// DecodeValue according to columns. Columns without metadata are copied as is.
+func DecodeTyped(response *message.Response, columns []ColumnMeta) ([]map[string]interface{}, error) {
+	types := make(map[string]string, len(columns))
+	for _, column := range columns {
+		types[column.Name] = column.Type
+	}
+	rows := make([]map[string]interface{}, 0, response.RowCount())
+	for _, row := range response.Data {
+		typed := make(map[string]interface{}, len(row))
+		for key, value := range row {
+			columnType, ok := types[key]
+			if !ok {
+				typed[key] = value
+				continue
+			}
+			decoded, err := DecodeValue(value, columnType)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", key, err)
+			}
+			typed[key] = decoded
+		}
+		rows = append(rows, typed)
+	}
+	return rows, nil
+}
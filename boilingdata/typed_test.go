@@ -0,0 +1,55 @@
+package boilingdata
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestDecodeValueIntegers(t *testing.T) {
+	if got, err := DecodeValue(float64(42), "INTEGER"); err != nil || got != int64(42) {
+		t.Errorf("INTEGER: got %#v, %v", got, err)
+	}
+	if got, err := DecodeValue(json.Number("-9007199254740993"), "BIGINT"); err != nil || got != int64(-9007199254740993) {
+		t.Errorf("BIGINT: got %#v, %v", got, err)
+	}
+	if got, err := DecodeValue(json.Number("18446744073709551615"), "UBIGINT"); err != nil || got != uint64(18446744073709551615) {
+		t.Errorf("UBIGINT: got %#v, %v", got, err)
+	}
+	if _, err := DecodeValue(float64(-1), "UINTEGER"); err == nil {
+		t.Error("UINTEGER: decoded a negative value")
+	}
+
+	huge := "170141183460469231731687303715884105727"
+	got, err := DecodeValue(json.Number(huge), "HUGEINT")
+	if i, ok := got.(*big.Int); err != nil || !ok || i.String() != huge {
+		t.Errorf("HUGEINT: got %#v, %v", got, err)
+	}
+	if _, err := DecodeValue("x", "HUGEINT"); err == nil {
+		t.Error("HUGEINT: decoded a non-number")
+	}
+}
+
+func TestDecodeValueOtherTypes(t *testing.T) {
+	got, err := DecodeValue(json.Number("1.25"), "DECIMAL(10,2)")
+	if r, ok := got.(*big.Rat); err != nil || !ok || r.Cmp(big.NewRat(5, 4)) != 0 {
+		t.Errorf("DECIMAL: got %#v, %v", got, err)
+	}
+	got, err = DecodeValue("2024-03-01", "DATE")
+	if d, ok := got.(time.Time); err != nil || !ok || !d.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("DATE: got %#v, %v", got, err)
+	}
+	if got, err := DecodeValue("aGk=", "BLOB"); err != nil || string(got.([]byte)) != "hi" {
+		t.Errorf("BLOB: got %#v, %v", got, err)
+	}
+	if got, err := DecodeValue(true, "boolean"); err != nil || got != true {
+		t.Errorf("BOOLEAN: got %#v, %v", got, err)
+	}
+	if got, err := DecodeValue(nil, "INTEGER"); err != nil || got != nil {
+		t.Errorf("NULL: got %#v, %v", got, err)
+	}
+	if got, err := DecodeValue(json.Number("1"), "VARCHAR"); err != nil || got != json.Number("1") {
+		t.Errorf("json.Number of an untyped column: got %#v, %v", got, err)
+	}
+}
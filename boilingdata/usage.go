@@ -0,0 +1,51 @@
+package boilingdata
+
+import (
+	"sync"
+	"time"
+
+	message "github.com/boilingdata/go-boilingdata/messages"
+)
+
+// Usage totals the statistics the server reported for the queries of an
+// Instance, to attribute and budget BoilingData usage. Queries counts the
+// responses that carried statistics.
+type Usage struct {
+	Queries      uint64
+	RowsScanned  int64
+	BytesScanned int64
+	Cost         float64
+	ExecTime     time.Duration
+}
+
+// usageCounters aggregates Usage for an Instance.
+type usageCounters struct {
+	mu    sync.Mutex
+	usage Usage
+}
+
+func (c *usageCounters) record(stats *message.Stats) {
+	if stats == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.usage.Queries++
+	c.usage.RowsScanned += stats.RowsScanned
+	c.usage.BytesScanned += stats.BytesScanned
+	c.usage.Cost += stats.Cost
+	c.usage.ExecTime += stats.ExecTime()
+}
+
+func (c *usageCounters) snapshot() Usage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usage
+}
+
+// Usage returns the scanned bytes, rows and cost the server reported for the
+// queries sent since the Instance was created. Responses served from the
+// client side cache are not counted again.
+func (instance *Instance) Usage() Usage {
+	return instance.usage.snapshot()
+}
@@ -0,0 +1,162 @@
+package boilingdata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned for a query over its user's limits set with
+// SetUserLimits, unless the limits queue.
+var ErrRateLimited = errors.New("user rate limit exceeded")
+
+// UserLimits bound the queries of one user across all of its Instances, so
+// one tenant of a multi-tenant service cannot starve the others. Zero
+// fields are unlimited.
+type UserLimits struct {
+	// QPS is the sustained number of queries started per second, and Burst
+	// the number that may start at once; Burst defaults to 1.
+	QPS   float64
+	Burst int
+	// MaxConcurrent is the number of queries that may be in flight at once.
+	MaxConcurrent int
+	// Wait queues queries over the limits until they may run or their
+	// context is done, instead of failing them with ErrRateLimited.
+	Wait bool
+}
+
+// userLimiter enforces the UserLimits of one user.
+type userLimiter struct {
+	limits UserLimits
+	slots  chan struct{}
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+var (
+	userLimitersMu sync.Mutex
+	// userLimiters holds the limiters set with SetUserLimits, and
+	// defaultLimiters those created from defaultLimits for other users.
+	userLimiters    = map[string]*userLimiter{}
+	defaultLimiters = map[string]*userLimiter{}
+	defaultLimits   *UserLimits
+)
+
+// SetUserLimits sets the limits of userName's queries, replacing earlier
+// ones. Queries already running keep the limits they started under.
+func SetUserLimits(userName string, limits UserLimits) {
+	userLimitersMu.Lock()
+	defer userLimitersMu.Unlock()
+	userLimiters[userName] = newUserLimiter(limits)
+}
+
+// RemoveUserLimits removes the limits set for userName; the default limits
+// apply again.
+func RemoveUserLimits(userName string) {
+	userLimitersMu.Lock()
+	defer userLimitersMu.Unlock()
+	delete(userLimiters, userName)
+}
+
+// SetDefaultUserLimits sets the limits of the users without limits of their
+// own, each user getting a separate allowance. A nil limits removes them.
+func SetDefaultUserLimits(limits *UserLimits) {
+	userLimitersMu.Lock()
+	defer userLimitersMu.Unlock()
+	defaultLimits = limits
+	defaultLimiters = map[string]*userLimiter{}
+}
+
+func newUserLimiter(limits UserLimits) *userLimiter {
+	if limits.Burst <= 0 {
+		limits.Burst = 1
+	}
+	limiter := &userLimiter{limits: limits, tokens: float64(limits.Burst)}
+	if limits.MaxConcurrent > 0 {
+		limiter.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	return limiter
+}
+
+// limiterFor returns the limiter of userName, or nil when it is unlimited.
+func limiterFor(userName string) *userLimiter {
+	userLimitersMu.Lock()
+	defer userLimitersMu.Unlock()
+	if limiter, ok := userLimiters[userName]; ok {
+		return limiter
+	}
+	if defaultLimits == nil {
+		return nil
+	}
+	limiter, ok := defaultLimiters[userName]
+	if !ok {
+		limiter = newUserLimiter(*defaultLimits)
+		defaultLimiters[userName] = limiter
+	}
+	return limiter
+}
+
+// acquire waits for, or fails without, a token and a concurrency slot.
+func (limiter *userLimiter) acquire(ctx context.Context) error {
+	if err := limiter.take(ctx); err != nil {
+		return err
+	}
+	if limiter.slots == nil {
+		return nil
+	}
+	if !limiter.limits.Wait {
+		select {
+		case limiter.slots <- struct{}{}:
+			return nil
+		default:
+			return ErrRateLimited
+		}
+	}
+	select {
+	case limiter.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (limiter *userLimiter) release() {
+	if limiter.slots != nil {
+		<-limiter.slots
+	}
+}
+
+// take removes a token from the bucket, which refills at QPS.
+func (limiter *userLimiter) take(ctx context.Context) error {
+	if limiter.limits.QPS <= 0 {
+		return nil
+	}
+	for {
+		limiter.mu.Lock()
+		now := time.Now()
+		if !limiter.last.IsZero() {
+			limiter.tokens += now.Sub(limiter.last).Seconds() * limiter.limits.QPS
+			limiter.tokens = min(limiter.tokens, float64(limiter.limits.Burst))
+		}
+		limiter.last = now
+		if limiter.tokens >= 1 {
+			limiter.tokens--
+			limiter.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - limiter.tokens) / limiter.limits.QPS * float64(time.Second))
+		limiter.mu.Unlock()
+		if !limiter.limits.Wait {
+			return ErrRateLimited
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package boilingdata
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValidationError describes why the server rejected a query. Kind is the
+// error class reported by the server, e.g. "Parser", "Binder" or "Catalog".
+// Line and Column are 1-based and, with Offset, locate the error in the
+// validated SQL when the server reported a position; they are 0 otherwise.
+type ValidationError struct {
+	Kind    string
+	Message string
+	Line    int
+	Column  int
+	Offset  int
+	cause   error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s Error at line %d, column %d: %s", e.Kind, e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%s Error: %s", e.Kind, e.Message)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.cause
+}
+
+const explainPrefix = "EXPLAIN "
+
+// ErrNotSingleStatement is returned when SQL that must be a single
+// statement, e.g. for Validate, is empty or holds several statements.
+var ErrNotSingleStatement = errors.New("SQL must be exactly one statement")
+
+// singleStatement returns the only statement of sql, trimmed and without
+// its trailing semicolon, so it can be wrapped in EXPLAIN without running
+// any statement that follows it.
+func singleStatement(sql string) (string, error) {
+	statements := SplitStatements(sql)
+	if len(statements) != 1 {
+		return "", fmt.Errorf("%w, got %d", ErrNotSingleStatement, len(statements))
+	}
+	return statements[0], nil
+}
+
+var (
+	errorKindPattern = regexp.MustCompile(`^(\w+) Error: `)
+	errorLinePattern = regexp.MustCompile(`^LINE (\d+): `)
+)
+
+// Validate asks the server to plan sql without running it. It returns nil
+// when sql is valid and a *ValidationError when the server rejected it, so
+// editors can point at the error before the query is run. Other errors mean
+// the query could not be validated, e.g. ErrNotSingleStatement.
+func (instance *Instance) Validate(sql string) error {
+	statement, err := singleStatement(sql)
+	if err != nil {
+		return err
+	}
+	_, err = instance.querySQL(explainPrefix + statement + ";")
+	var serverErr *ServerError
+	if err == nil || !errors.As(err, &serverErr) {
+		return err
+	}
+	validationErr := parseValidationError(serverErr.Message)
+	validationErr.cause = err
+	if validationErr.Line > 0 {
+		// The server positions the error in the EXPLAIN statement; map it
+		// back onto sql.
+		leading := len(sql) - len(strings.TrimLeft(sql, " \t\r\n"))
+		offset := lineOffset(explainPrefix+statement, validationErr.Line, validationErr.Column)
+		offset += leading - len(explainPrefix)
+		if offset >= 0 && offset <= len(sql) {
+			validationErr.Offset = offset
+			validationErr.Line, validationErr.Column = position(sql, offset)
+		} else {
+			validationErr.Line, validationErr.Column = 0, 0
+		}
+	}
+	return validationErr
+}
+
+// parseValidationError splits a server error message such as
+//
+//	Parser Error: syntax error at or near "FORM"
+//	LINE 1: SELECT 1 FORM t
+//	                 ^
+//
+// into its kind, message and position.
+func parseValidationError(text string) *ValidationError {
+	validationErr := &ValidationError{Kind: "Server"}
+	lines := strings.Split(text, "\n")
+	if match := errorKindPattern.FindStringSubmatch(lines[0]); match != nil {
+		validationErr.Kind = match[1]
+		lines[0] = lines[0][len(match[0]):]
+	}
+	var message []string
+	for i := 0; i < len(lines); i++ {
+		match := errorLinePattern.FindStringSubmatch(lines[i])
+		if match == nil || i+1 >= len(lines) {
+			message = append(message, lines[i])
+			continue
+		}
+		caret := strings.Index(lines[i+1], "^")
+		if caret < len(match[0]) {
+			message = append(message, lines[i])
+			continue
+		}
+		validationErr.Line, _ = strconv.Atoi(match[1])
+		validationErr.Column = caret - len(match[0]) + 1
+		i++
+	}
+	validationErr.Message = strings.TrimSpace(strings.Join(message, "\n"))
+	return validationErr
+}
+
+// lineOffset returns the byte offset of the 1-based line and column in text.
+func lineOffset(text string, line, column int) int {
+	offset := 0
+	for ; line > 1; line-- {
+		next := strings.IndexByte(text[offset:], '\n')
+		if next < 0 {
+			return -1
+		}
+		offset += next + 1
+	}
+	return offset + column - 1
+}
+
+// position returns the 1-based line and column of the byte offset in text.
+func position(text string, offset int) (line, column int) {
+	before := text[:offset]
+	line = strings.Count(before, "\n") + 1
+	column = offset - (strings.LastIndexByte(before, '\n') + 1) + 1
+	return line, column
+}
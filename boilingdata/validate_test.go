@@ -0,0 +1,49 @@
+package boilingdata
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseValidationError(t *testing.T) {
+	got := parseValidationError("Parser Error: syntax error at or near \"FORM\"\nLINE 1: SELECT 1 FORM t\n                 ^")
+	want := ValidationError{Kind: "Parser", Message: `syntax error at or near "FORM"`, Line: 1, Column: 10}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+
+	got = parseValidationError("Catalog Error: Table with name t does not exist!")
+	want = ValidationError{Kind: "Catalog", Message: "Table with name t does not exist!"}
+	if *got != want {
+		t.Errorf("got %+v, want %+v", *got, want)
+	}
+
+	got = parseValidationError("connection reset")
+	if got.Kind != "Server" || got.Message != "connection reset" || got.Line != 0 {
+		t.Errorf("message without a kind: got %+v", *got)
+	}
+}
+
+func TestSingleStatement(t *testing.T) {
+	if got, err := singleStatement("  SELECT 1; "); err != nil || got != "SELECT 1" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	for _, sql := range []string{"", ";", "SELECT 1; DROP TABLE t"} {
+		if _, err := singleStatement(sql); !errors.Is(err, ErrNotSingleStatement) {
+			t.Errorf("singleStatement(%q) error = %v, want ErrNotSingleStatement", sql, err)
+		}
+	}
+}
+
+func TestPositionRoundTrip(t *testing.T) {
+	text := "SELECT 1\nFROM t\nWHERE x"
+	for _, want := range [][2]int{{1, 1}, {1, 8}, {2, 1}, {3, 7}} {
+		line, column := position(text, lineOffset(text, want[0], want[1]))
+		if line != want[0] || column != want[1] {
+			t.Errorf("line %d, column %d came back as %d, %d", want[0], want[1], line, column)
+		}
+	}
+	if offset := lineOffset(text, 4, 1); offset != -1 {
+		t.Errorf("lineOffset past the last line = %d, want -1", offset)
+	}
+}
@@ -0,0 +1,216 @@
+package boilingdata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+)
+
+// ErrWriterClosed is returned for rows written after Writer.Close.
+var ErrWriterClosed = errors.New("boilingdata writer is closed")
+
+// WriterConfig configures a Writer. Zero fields take the defaults from the
+// constants package.
+type WriterConfig struct {
+	// TapURL is the URL of the data tap the rows are sent to.
+	TapURL string
+	// BatchRows and BatchBytes bound a batch; it is sent when either is
+	// reached.
+	BatchRows  int
+	BatchBytes int
+	// MaxInFlight is the number of batches sent but not yet acknowledged
+	// before Write blocks.
+	MaxInFlight int
+}
+
+// Writer feeds rows into a data tap. Rows are batched as NDJSON and each
+// batch is POSTed to the tap; the tap acknowledges a batch by answering it
+// with a 2xx status. Write blocks while MaxInFlight batches are awaiting
+// their acknowledgement. A rejected batch fails the Writer: the error is
+// returned by the next Write, Flush or Close. A batch is posted with the
+// context of the Write or Flush that sent it, so cancelling that context
+// aborts the batch, which fails the Writer. Writer is safe for concurrent
+// use.
+type Writer struct {
+	instance *Instance
+	config   WriterConfig
+	mu       sync.Mutex
+	batch    bytes.Buffer
+	rows     int
+	closed   bool
+	// errMu guards err apart from mu, which a writer waiting for a free
+	// slot holds.
+	errMu    sync.Mutex
+	err      error
+	slots    chan struct{}
+	inFlight sync.WaitGroup
+	acked    atomic.Int64
+}
+
+// NewWriter returns a Writer sending rows to config.TapURL, authenticated as
+// the Instance's user.
+func (instance *Instance) NewWriter(config WriterConfig) (*Writer, error) {
+	if config.TapURL == "" {
+		return nil, errors.New("tap URL is required")
+	}
+	if config.BatchRows <= 0 {
+		config.BatchRows = constants.WriterBatchRows
+	}
+	if config.BatchBytes <= 0 {
+		config.BatchBytes = constants.WriterBatchBytes
+	}
+	if config.MaxInFlight <= 0 {
+		config.MaxInFlight = constants.WriterMaxInFlight
+	}
+	return &Writer{
+		instance: instance,
+		config:   config,
+		slots:    make(chan struct{}, config.MaxInFlight),
+	}, nil
+}
+
+// Write adds row, which is encoded as a JSON object, to the current batch,
+// sending the batch once it is full.
+func (w *Writer) Write(ctx context.Context, row interface{}) error {
+	line, err := json.Marshal(row)
+	if err != nil {
+		return fmt.Errorf("Error encoding row: %w", err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.failed(); err != nil {
+		return err
+	}
+	w.batch.Write(line)
+	w.batch.WriteByte('\n')
+	w.rows++
+	if w.rows >= w.config.BatchRows || w.batch.Len() >= w.config.BatchBytes {
+		return w.send(ctx)
+	}
+	return nil
+}
+
+// Flush sends the current batch and waits until every batch sent so far has
+// been acknowledged.
+func (w *Writer) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	if err := w.failed(); err != nil {
+		w.mu.Unlock()
+		return err
+	}
+	err := w.send(ctx)
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	go func() {
+		w.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return w.sendErr()
+}
+
+// Close flushes the Writer. Later writes fail with ErrWriterClosed.
+func (w *Writer) Close() error {
+	err := w.Flush(context.Background())
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	if errors.Is(err, ErrWriterClosed) {
+		return nil
+	}
+	return err
+}
+
+// Acked returns the number of rows the tap has acknowledged.
+func (w *Writer) Acked() int64 {
+	return w.acked.Load()
+}
+
+// failed returns the error that stops the Writer; w.mu is held.
+func (w *Writer) failed() error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	return w.sendErr()
+}
+
+func (w *Writer) sendErr() error {
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	return w.err
+}
+
+// send posts the current batch once a slot is free; w.mu is held, so
+// writers wait behind it while the in-flight limit is reached.
+func (w *Writer) send(ctx context.Context) error {
+	if w.rows == 0 {
+		return nil
+	}
+	select {
+	case w.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	body := bytes.Clone(w.batch.Bytes())
+	rows := w.rows
+	w.batch.Reset()
+	w.rows = 0
+	w.inFlight.Add(1)
+	go func() {
+		defer func() {
+			<-w.slots
+			w.inFlight.Done()
+		}()
+		if err := w.post(ctx, body); err != nil {
+			w.errMu.Lock()
+			if w.err == nil {
+				w.err = err
+			}
+			w.errMu.Unlock()
+			return
+		}
+		w.acked.Add(int64(rows))
+	}()
+	return nil
+}
+
+func (w *Writer) post(ctx context.Context, body []byte) error {
+	idToken, err := w.instance.Auth.Authenticate()
+	if err != nil {
+		return fmt.Errorf("Error : %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.TapURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set(constants.TapAuthHeader, idToken)
+	resp, err := w.instance.Auth.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("Error sending batch to tap: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		err := fmt.Errorf("Error sending batch to tap: unexpected status %s", resp.Status)
+		if message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)); len(bytes.TrimSpace(message)) > 0 {
+			err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(message))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// Package fakeclock provides a wsclient.Clock whose time only moves when a
+// test advances it, so idle, response and rotation timeouts can be
+// exercised without waiting for them.
+package fakeclock
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+// Clock is a wsclient.Clock driven by Advance. It is safe for concurrent
+// use.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*timer
+}
+
+// New returns a Clock reading now.
+func New(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Clock) NewTimer(d time.Duration) wsclient.Timer {
+	return c.add(&timer{clock: c, c: make(chan time.Time, 1)}, d)
+}
+
+// AfterFunc calls f from Advance, synchronously, once d has passed.
+func (c *Clock) AfterFunc(d time.Duration, f func()) wsclient.Timer {
+	return c.add(&timer{clock: c, f: f}, d)
+}
+
+func (c *Clock) NewTicker(d time.Duration) wsclient.Ticker {
+	if d <= 0 {
+		panic("fakeclock: non-positive interval for NewTicker")
+	}
+	return ticker{c.add(&timer{clock: c, c: make(chan time.Time, 1), period: d}, d)}
+}
+
+func (c *Clock) add(t *timer, d time.Duration) *timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t.when = c.now.Add(d)
+	t.active = true
+	t.listed = true
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers and tickers due
+// on the way in order. AfterFunc callbacks run before Advance returns.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	for {
+		t := c.next(target)
+		if t == nil {
+			break
+		}
+		c.now = t.when
+		if t.period > 0 {
+			t.when = t.when.Add(t.period)
+		} else {
+			t.active = false
+		}
+		if t.f != nil {
+			c.mu.Unlock()
+			t.f()
+			c.mu.Lock()
+			continue
+		}
+		select {
+		case t.c <- c.now:
+		default:
+		}
+	}
+	c.now = target
+	c.mu.Unlock()
+}
+
+// Pending returns the number of active timers and tickers.
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, t := range c.timers {
+		if t.active {
+			n++
+		}
+	}
+	return n
+}
+
+// next returns the earliest active timer due by target; c.mu must be held.
+func (c *Clock) next(target time.Time) *timer {
+	active := c.timers[:0]
+	for _, t := range c.timers {
+		if t.active {
+			active = append(active, t)
+		} else {
+			t.listed = false
+		}
+	}
+	clear(c.timers[len(active):])
+	c.timers = active
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].when.Before(active[j].when)
+	})
+	if len(active) == 0 || active[0].when.After(target) {
+		return nil
+	}
+	return active[0]
+}
+
+// timer is a Timer or Ticker of a Clock.
+type timer struct {
+	clock  *Clock
+	c      chan time.Time
+	f      func()
+	when   time.Time
+	period time.Duration
+	active bool
+	// listed is set while the timer is in clock.timers.
+	listed bool
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.active
+	t.active = false
+	return wasActive
+}
+
+func (t *timer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.active
+	t.when = t.clock.now.Add(d)
+	t.active = true
+	if !t.listed {
+		t.listed = true
+		t.clock.timers = append(t.clock.timers, t)
+	}
+	return wasActive
+}
+
+// ticker is a timer whose Stop reports nothing, as wsclient.Ticker wants.
+type ticker struct {
+	*timer
+}
+
+func (t ticker) Stop() {
+	t.timer.Stop()
+}
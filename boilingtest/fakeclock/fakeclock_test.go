@@ -0,0 +1,83 @@
+package fakeclock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdvance(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		advance []time.Duration
+		fired   int
+	}{
+		{"before deadline", []time.Duration{time.Second}, 0},
+		{"at deadline", []time.Duration{2 * time.Second}, 1},
+		{"in steps", []time.Duration{time.Second, time.Second}, 1},
+		{"long after", []time.Duration{time.Hour}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := New(start)
+			fired := 0
+			clock.AfterFunc(2*time.Second, func() { fired++ })
+			for _, d := range tt.advance {
+				clock.Advance(d)
+			}
+			if fired != tt.fired {
+				t.Errorf("fired %d times, want %d", fired, tt.fired)
+			}
+		})
+	}
+}
+
+func TestTimerStopReset(t *testing.T) {
+	clock := New(time.Time{})
+	timer := clock.NewTimer(time.Second)
+	if !timer.Stop() {
+		t.Fatal("Stop of an active timer reported false")
+	}
+	clock.Advance(2 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("stopped timer fired")
+	default:
+	}
+	timer.Reset(time.Second)
+	timer.Reset(time.Second)
+	clock.Advance(time.Second)
+	select {
+	case now := <-timer.C():
+		if want := (time.Time{}).Add(3 * time.Second); !now.Equal(want) {
+			t.Errorf("fired at %v, want %v", now, want)
+		}
+	default:
+		t.Fatal("reset timer did not fire")
+	}
+	if n := clock.Pending(); n != 0 {
+		t.Errorf("Pending() = %d, want 0", n)
+	}
+}
+
+func TestTicker(t *testing.T) {
+	clock := New(time.Time{})
+	ticker := clock.NewTicker(time.Second)
+	ticks := 0
+	for range 3 {
+		clock.Advance(time.Second)
+		select {
+		case <-ticker.C():
+			ticks++
+		default:
+		}
+	}
+	ticker.Stop()
+	clock.Advance(time.Second)
+	if ticks != 3 {
+		t.Errorf("got %d ticks, want 3", ticks)
+	}
+	if n := clock.Pending(); n != 0 {
+		t.Errorf("Pending() = %d after Stop, want 0", n)
+	}
+}
@@ -0,0 +1,148 @@
+// Package loadgen drives concurrent synthetic queries through a
+// boilingdata.Instance, against the real service or a MockTransport, and
+// reports throughput and latency, to validate connection pool and rate
+// limit settings before production.
+package loadgen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// Querier runs a query payload, e.g. a *boilingdata.Instance.
+type Querier interface {
+	QueryContext(ctx context.Context, payloadMessage []byte) (*messages.Response, error)
+}
+
+// Config describes a load run. It runs Queries queries in total, or for
+// Duration when Queries is zero.
+type Config struct {
+	// Concurrency is the number of queries kept in flight, at least 1.
+	Concurrency int
+	Queries     int
+	Duration    time.Duration
+	// SQL lists the statements sent in turn; it defaults to "SELECT 1;".
+	SQL []string
+}
+
+// Report summarizes a load run. Latencies include failed queries.
+type Report struct {
+	Queries    int
+	Errors     int
+	Elapsed    time.Duration
+	Throughput float64 // queries per second
+	Mean       time.Duration
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+	// FirstError is the first query error, if any.
+	FirstError error
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("%d queries (%d errors) in %v, %.1f q/s, latency mean %v p50 %v p90 %v p99 %v max %v",
+		r.Queries, r.Errors, r.Elapsed.Round(time.Millisecond), r.Throughput,
+		r.Mean, r.P50, r.P90, r.P99, r.Max)
+}
+
+// Run sends the queries of config through querier until they are all done,
+// the duration elapses or ctx is done, and reports the result.
+func Run(ctx context.Context, querier Querier, config Config) (Report, error) {
+	if config.Queries <= 0 && config.Duration <= 0 {
+		return Report{}, errors.New("loadgen: either Queries or Duration must be set")
+	}
+	statements := config.SQL
+	if len(statements) == 0 {
+		statements = []string{"SELECT 1;"}
+	}
+	if config.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Duration)
+		defer cancel()
+	}
+
+	var (
+		mu        sync.Mutex
+		next      int
+		latencies []time.Duration
+		report    Report
+	)
+	// claim returns the index of the next query, or false when done.
+	claim := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Err() != nil || (config.Queries > 0 && next >= config.Queries) {
+			return 0, false
+		}
+		next++
+		return next - 1, true
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range max(config.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i, ok := claim()
+				if !ok {
+					return
+				}
+				payload := messages.GetPayLoad()
+				payload.SQL = statements[i%len(statements)]
+				payload.RequestID = fmt.Sprintf("loadgen-%d-%d", start.UnixNano(), i)
+				payloadMessage, _ := json.Marshal(payload)
+				began := time.Now()
+				_, err := querier.QueryContext(ctx, payloadMessage)
+				latency := time.Since(began)
+				if err != nil && config.Queries == 0 && ctx.Err() != nil {
+					// Cut off by the end of the run.
+					return
+				}
+				mu.Lock()
+				latencies = append(latencies, latency)
+				if err != nil {
+					report.Errors++
+					if report.FirstError == nil {
+						report.FirstError = err
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report.Elapsed = time.Since(start)
+	report.Queries = len(latencies)
+	if report.Queries == 0 {
+		return report, nil
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var sum time.Duration
+	for _, latency := range latencies {
+		sum += latency
+	}
+	report.Throughput = float64(report.Queries) / report.Elapsed.Seconds()
+	report.Mean = sum / time.Duration(report.Queries)
+	report.P50 = percentile(latencies, 0.50)
+	report.P90 = percentile(latencies, 0.90)
+	report.P99 = percentile(latencies, 0.99)
+	report.Max = latencies[len(latencies)-1]
+	return report, nil
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(p*float64(len(sorted))+0.999999) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
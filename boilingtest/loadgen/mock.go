@@ -0,0 +1,132 @@
+package loadgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// errClosed is returned by a closed MockTransport.
+var errClosed = errors.New("loadgen: mock transport closed")
+
+// MockTransport is a wsclient.Transport answering every query with Rows
+// synthetic rows after Latency, so a load run can exercise the client
+// without the service:
+//
+//	mock := loadgen.NewMockTransport(20*time.Millisecond, 100)
+//	instance := boilingdata.NewInstance("load@example.com", nil, boilingdata.WithOfflineTransport(mock))
+//	report, err := loadgen.Run(ctx, instance, loadgen.Config{Concurrency: 50, Queries: 10000})
+type MockTransport struct {
+	Latency time.Duration
+	Rows    int
+	// Capabilities, if any, are announced in an INFO frame on connect,
+	// e.g. "subscribe" to accept subscriptions.
+	Capabilities []string
+
+	mu       sync.Mutex
+	incoming chan []byte
+	closed   chan struct{}
+}
+
+// NewMockTransport returns a MockTransport answering after latency with
+// rows rows.
+func NewMockTransport(latency time.Duration, rows int) *MockTransport {
+	return &MockTransport{Latency: latency, Rows: rows}
+}
+
+func (t *MockTransport) Dial(url string, header http.Header) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.incoming = make(chan []byte, 64)
+	t.closed = make(chan struct{})
+	if len(t.Capabilities) > 0 {
+		info, err := json.Marshal(map[string]interface{}{
+			"messageType":  messages.INFO.String(),
+			"capabilities": t.Capabilities,
+		})
+		if err != nil {
+			return err
+		}
+		t.incoming <- info
+	}
+	return nil
+}
+
+// Send schedules the response to a query or subscription; other messages,
+// such as cancels, are ignored.
+func (t *MockTransport) Send(message []byte) error {
+	t.mu.Lock()
+	incoming, closed := t.incoming, t.closed
+	t.mu.Unlock()
+	if closed == nil {
+		return errClosed
+	}
+	var payload messages.Payload
+	if err := json.Unmarshal(message, &payload); err != nil {
+		return err
+	}
+	if payload.MessageType != messages.SQLQueryMessage && payload.MessageType != messages.SubscribeMessage {
+		return nil
+	}
+	response, err := json.Marshal(t.response(payload.RequestID))
+	if err != nil {
+		return err
+	}
+	time.AfterFunc(t.Latency, func() {
+		select {
+		case incoming <- response:
+		case <-closed:
+		}
+	})
+	return nil
+}
+
+// response is the single DATA frame answering requestID.
+func (t *MockTransport) response(requestID string) *messages.Response {
+	data := make([]map[string]interface{}, t.Rows)
+	for i := range data {
+		data[i] = map[string]interface{}{"n": i}
+	}
+	return &messages.Response{
+		MessageType:       messages.DATA.String(),
+		RequestID:         requestID,
+		BatchSerial:       1,
+		TotalBatches:      1,
+		SplitSerial:       1,
+		TotalSplitSerials: 1,
+		SubBatchSerial:    1,
+		TotalSubBatches:   1,
+		Data:              data,
+	}
+}
+
+func (t *MockTransport) Receive() (io.Reader, error) {
+	t.mu.Lock()
+	incoming, closed := t.incoming, t.closed
+	t.mu.Unlock()
+	if closed == nil {
+		return nil, errClosed
+	}
+	select {
+	case message := <-incoming:
+		return bytes.NewReader(message), nil
+	case <-closed:
+		return nil, errClosed
+	}
+}
+
+func (t *MockTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed != nil {
+		close(t.closed)
+		t.closed = nil
+	}
+	return nil
+}
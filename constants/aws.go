@@ -12,8 +12,34 @@ const (
 	ClientID                string        = "6timr8knllr4frovfvq8r2o6oo"
 	WssUrl                  string        = "wss://4rpyi2ae3f.execute-api.eu-west-1.amazonaws.com/prodbd"
 	Service                 string        = "execute-api"
-	IdleTimeoutMinutes      time.Duration = 10 * time.Minute
+	IdleTimeout             time.Duration = 10 * time.Minute
 	TimeOutWaintForResponse time.Duration = 60 * time.Second
+	PingInterval            time.Duration = 30 * time.Second
+	PongWait                time.Duration = 75 * time.Second
+	WriteTimeout            time.Duration = 10 * time.Second
+	MaxConnectAttempts      int           = 3
+	KeepWarmSQL             string        = "SELECT 1;"
+	SendQueueSize           int           = 64
+	ProtocolVersion         string        = "1"
+	ProtocolVersionHeader   string        = "X-BoilingData-Protocol-Version"
+	ClientName              string        = "go-boilingdata"
+	ClientHeader            string        = "x-bd-client"
+	SendTimeout             time.Duration = 30 * time.Second
+	MaxPayloadSize          int           = 128 << 10
+	ConnectionTTL           time.Duration = 2 * time.Hour
+	RotationMargin          time.Duration = 5 * time.Minute
+	ResultCacheEntries      int           = 1000
+	ExchangedTokenLifetime  time.Duration = time.Hour
+	ThrottleRetries         int           = 3
+	SubscribeCapability     string        = "subscribe"
+	SharesCapability        string        = "shares"
+	CancelCapability        string        = "cancel"
+	StagingCapability       string        = "staging"
+	SubscriptionBuffer      int           = 64
+	WriterBatchRows         int           = 1000
+	WriterBatchBytes        int           = 1 << 20
+	WriterMaxInFlight       int           = 2
+	TapAuthHeader           string        = "x-bd-authorization"
 	SignWrlFormat                         = "X-Amz-Algorithm=AWS4-HMAC-SHA256&" +
 		"X-Amz-Credential=%s" +
 		"X-Amz-Date=%s" +
@@ -22,6 +48,10 @@ const (
 		"X-Amz-Signature=%s"
 )
 
+// Deprecated: IdleTimeoutMinutes is a duration despite its name; use
+// IdleTimeout.
+const IdleTimeoutMinutes = IdleTimeout
+
 var CognitoIdp string
 
 func init() {
@@ -6,7 +6,10 @@ require (
 	github.com/aws/aws-sdk-go v1.51.24
 	github.com/aws/aws-sdk-go-v2/config v1.27.11
 	github.com/aws/aws-sdk-go-v2/service/cognitoidentity v1.23.7
+	github.com/go-gota/gota v0.12.0
 	github.com/gorilla/websocket v1.5.1
+	golang.org/x/oauth2 v0.25.0
+	golang.org/x/term v0.13.0
 )
 
 require (
@@ -26,4 +29,6 @@ require (
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/orcaman/concurrent-map v1.0.0
 	golang.org/x/net v0.17.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	gonum.org/v1/gonum v0.9.1 // indirect
 )
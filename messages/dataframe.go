@@ -0,0 +1,15 @@
+//go:build gota
+
+package messages
+
+import "github.com/go-gota/gota/dataframe"
+
+// ToDataFrame converts the response rows into a gota DataFrame. Columns
+// follow Keys when available. Build with -tags gota to enable it.
+func (r *Response) ToDataFrame() dataframe.DataFrame {
+	df := dataframe.LoadMaps(r.Data)
+	if len(r.Keys) > 0 && df.Err == nil {
+		df = df.Select(r.Keys)
+	}
+	return df
+}
@@ -0,0 +1,196 @@
+package messages
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	knownFieldsOnce sync.Once
+	knownFields     map[string]bool
+)
+
+// responseFields returns the JSON names of the fields Response decodes,
+// including those of the embedded Stats.
+func responseFields() map[string]bool {
+	knownFieldsOnce.Do(func() {
+		knownFields = make(map[string]bool)
+		addJSONFields(knownFields, reflect.TypeOf(Response{}))
+	})
+	return knownFields
+}
+
+func addJSONFields(fields map[string]bool, typ reflect.Type) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			addJSONFields(fields, embedded)
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = true
+	}
+}
+
+// ExtraFields returns the top-level fields of a response frame that Response
+// does not model, undecoded, or nil when there are none. It only scans the
+// frame: values, such as the rows, are skipped without being decoded.
+func ExtraFields(frame []byte) (map[string]json.RawMessage, error) {
+	known := responseFields()
+	var fields map[string]json.RawMessage
+	err := scanObject(frame, func(key string, value []byte) {
+		if known[strings.ToLower(key)] {
+			return
+		}
+		if fields == nil {
+			fields = make(map[string]json.RawMessage)
+		}
+		fields[key] = bytes.Clone(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+var errNotObject = errors.New("frame is not a JSON object")
+
+// scanObject calls fn with each top-level key of the JSON object in data and
+// its undecoded value.
+func scanObject(data []byte, fn func(key string, value []byte)) error {
+	i := skipSpace(data, 0)
+	if i >= len(data) || data[i] != '{' {
+		return errNotObject
+	}
+	i = skipSpace(data, i+1)
+	if i < len(data) && data[i] == '}' {
+		return trailing(data, i+1)
+	}
+	for {
+		if i >= len(data) || data[i] != '"' {
+			return errNotObject
+		}
+		end, err := skipString(data, i)
+		if err != nil {
+			return err
+		}
+		key, err := unquote(data[i:end])
+		if err != nil {
+			return err
+		}
+		i = skipSpace(data, end)
+		if i >= len(data) || data[i] != ':' {
+			return errNotObject
+		}
+		start := skipSpace(data, i+1)
+		end, err = skipValue(data, start)
+		if err != nil {
+			return err
+		}
+		fn(key, data[start:end])
+		i = skipSpace(data, end)
+		if i >= len(data) {
+			return errNotObject
+		}
+		switch data[i] {
+		case ',':
+			i = skipSpace(data, i+1)
+		case '}':
+			return trailing(data, i+1)
+		default:
+			return errNotObject
+		}
+	}
+}
+
+// trailing rejects anything but whitespace after the object ending at i.
+func trailing(data []byte, i int) error {
+	if skipSpace(data, i) < len(data) {
+		return errNotObject
+	}
+	return nil
+}
+
+func skipSpace(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\t' || data[i] == '\n' || data[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// skipString returns the index after the string starting at data[i].
+func skipString(data []byte, i int) (int, error) {
+	for j := i + 1; j < len(data); j++ {
+		switch data[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+	return 0, errNotObject
+}
+
+// skipValue returns the index after the value starting at data[i].
+func skipValue(data []byte, i int) (int, error) {
+	if i >= len(data) {
+		return 0, errNotObject
+	}
+	switch data[i] {
+	case '"':
+		return skipString(data, i)
+	case '{', '[':
+		depth := 0
+		for j := i; j < len(data); j++ {
+			switch data[j] {
+			case '"':
+				end, err := skipString(data, j)
+				if err != nil {
+					return 0, err
+				}
+				j = end - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return j + 1, nil
+				}
+			}
+		}
+		return 0, errNotObject
+	}
+	j := i
+	for j < len(data) && !strings.ContainsRune(",}] \t\n\r", rune(data[j])) {
+		j++
+	}
+	if j == i {
+		return 0, errNotObject
+	}
+	return j, nil
+}
+
+// unquote returns the string of the quoted JSON string quoted.
+func unquote(quoted []byte) (string, error) {
+	if bytes.IndexByte(quoted, '\\') < 0 {
+		return string(quoted[1 : len(quoted)-1]), nil
+	}
+	var s string
+	err := json.Unmarshal(quoted, &s)
+	return s, err
+}
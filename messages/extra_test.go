@@ -0,0 +1,53 @@
+package messages
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExtraFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		frame   string
+		want    map[string]json.RawMessage
+		wantErr bool
+	}{
+		{name: "none", frame: `{"requestId":"r1","data":[{"a":1}]}`, want: nil},
+		{name: "empty object", frame: ` { } `, want: nil},
+		{
+			name:  "extra fields",
+			frame: `{"requestId":"r1","region":"eu-west-1","limits":{"rows":[1,2]},"flag":true,"none":null,"n":-1.5e3}`,
+			want: map[string]json.RawMessage{
+				"region": json.RawMessage(`"eu-west-1"`),
+				"limits": json.RawMessage(`{"rows":[1,2]}`),
+				"flag":   json.RawMessage(`true`),
+				"none":   json.RawMessage(`null`),
+				"n":      json.RawMessage(`-1.5e3`),
+			},
+		},
+		{
+			name:  "escapes and nesting",
+			frame: `{"data":[{"s":"a\"}]"}],"xy":"\\","z":[{"}":"]"}]}`,
+			want: map[string]json.RawMessage{
+				"xy": json.RawMessage(`"\\"`),
+				"z":  json.RawMessage(`[{"}":"]"}]`),
+			},
+		},
+		{name: "not an object", frame: `[1,2]`, wantErr: true},
+		{name: "truncated", frame: `{"region":"eu`, wantErr: true},
+		{name: "missing colon", frame: `{"region" "eu"}`, wantErr: true},
+		{name: "trailing data", frame: `{} x`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtraFields([]byte(tt.frame))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtraFields(%s) error = %v, want error %v", tt.frame, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtraFields(%s) = %s, want %s", tt.frame, got, tt.want)
+			}
+		})
+	}
+}
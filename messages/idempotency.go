@@ -0,0 +1,27 @@
+package messages
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// NewIdempotencyKey returns a random key for Payload.IdempotencyKey.
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("idem-%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithIdempotencyKey returns a copy of p carrying key, or a new key when key
+// is empty. Keep the returned payload to retry the query under the same key.
+func (p Payload) WithIdempotencyKey(key string) Payload {
+	if key == "" {
+		key = NewIdempotencyKey()
+	}
+	p.IdempotencyKey = key
+	return p
+}
@@ -0,0 +1,23 @@
+//go:build go1.23
+
+package messages
+
+import "iter"
+
+// All returns an iterator over the rows of r, for use with range:
+//
+//	for row, err := range response.All() {
+//		...
+//	}
+//
+// The error is always nil for a complete response; it is part of the
+// signature so the streaming iterators can share it.
+func (r *Response) All() iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		for _, row := range r.Data {
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
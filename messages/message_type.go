@@ -1,9 +1,24 @@
 package messages
 
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
 type Payload struct {
 	MessageType string `json:"messageType"`
 	SQL         string `json:"sql"`
 	RequestID   string `json:"requestId"`
+	// ReadOnly marks whether the query only reads, overriding the client's
+	// analysis of the statement when deciding if it may be retried. It is
+	// only used by the client and removed before the payload is sent.
+	ReadOnly *bool `json:"readOnly,omitempty"`
+	// IdempotencyKey identifies a query across retries, so the client runs
+	// it at most once; see boilingdata.WithIdempotencyKeys. It is only used
+	// by the client and removed before the payload is sent.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
 }
 
 type Response struct {
@@ -18,6 +33,95 @@ type Response struct {
 	TotalSubBatches   int                      `json:"totalSubBatches"`
 	Data              []map[string]interface{} `json:"data"`
 	Keys              []string                 `json:"-"`
+	// ResultURL is set instead of Data when the server wrote a large result
+	// to S3, with ResultSize its size in bytes when known.
+	ResultURL  string `json:"resultUrl,omitempty"`
+	ResultSize int64  `json:"resultSize,omitempty"`
+	// BatchRows, Checksum and TotalRows are set when the server sends
+	// integrity information: the rows in the frame, the CRC-32 (IEEE, hex)
+	// of the frame's raw data array, and the rows of the whole result.
+	BatchRows *int   `json:"batchRows,omitempty"`
+	Checksum  string `json:"checksum,omitempty"`
+	TotalRows *int   `json:"totalRows,omitempty"`
+	// Truncated is set on the rows received before the response timed
+	// out, with WithPartialOnTimeout.
+	Truncated bool `json:"truncated,omitempty"`
+	// Stats is set when the server reports execution statistics. It is
+	// nil otherwise, so check it before reading promoted fields such as
+	// ExecTimeMs; the promoted methods are safe to call either way.
+	*Stats
+	// Extra holds the top-level fields of the final frame that Response
+	// does not model yet, undecoded, so new server fields can be used
+	// before the client supports them.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// CacheStatus is the parsed form of Response.CacheInfo.
+type CacheStatus string
+
+const (
+	CacheUnknown CacheStatus = ""
+	CacheHit     CacheStatus = "HIT"
+	CacheMiss    CacheStatus = "MISS"
+	CachePartial CacheStatus = "PARTIAL"
+)
+
+// CacheStatus parses CacheInfo, which the server sends as free text such as
+// "HIT" or "partial cache hit".
+func (r *Response) CacheStatus() CacheStatus {
+	info := strings.ToUpper(r.CacheInfo)
+	switch {
+	case strings.Contains(info, "PARTIAL"):
+		return CachePartial
+	case strings.Contains(info, "MISS"):
+		return CacheMiss
+	case strings.Contains(info, "HIT"):
+		return CacheHit
+	}
+	return CacheUnknown
+}
+
+// Stats are the execution statistics the server may send with a response.
+// Its fields are decoded from the top level of the response frame.
+type Stats struct {
+	ExecTimeMs   float64 `json:"execTimeMs,omitempty"`
+	ScanTimeMs   float64 `json:"scanTimeMs,omitempty"`
+	RowsScanned  int64   `json:"rowsScanned,omitempty"`
+	BytesScanned int64   `json:"bytesScanned,omitempty"`
+	CacheHits    int64   `json:"cacheHits,omitempty"`
+	CacheMisses  int64   `json:"cacheMisses,omitempty"`
+	// Cost is the execution cost the server charged for the query, in its
+	// billing units.
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// ExecTime returns ExecTimeMs as a duration, or 0 when s is nil, e.g. on a
+// Response the server sent without statistics.
+func (s *Stats) ExecTime() time.Duration {
+	if s == nil {
+		return 0
+	}
+	return time.Duration(s.ExecTimeMs * float64(time.Millisecond))
+}
+
+// ScanTime returns ScanTimeMs as a duration, or 0 when s is nil.
+func (s *Stats) ScanTime() time.Duration {
+	if s == nil {
+		return 0
+	}
+	return time.Duration(s.ScanTimeMs * float64(time.Millisecond))
+}
+
+// IsRemote reports whether the rows of r have to be downloaded from
+// ResultURL.
+func (r *Response) IsRemote() bool {
+	return r.ResultURL != "" && len(r.Data) == 0
+}
+
+// CancelPayload asks the server to stop producing results for RequestID.
+type CancelPayload struct {
+	MessageType string `json:"messageType"`
+	RequestID   string `json:"requestId"`
 }
 
 // Define structs to represent the JSON payload
@@ -26,9 +130,22 @@ type Tag struct {
 	Value string `json:"value"`
 }
 
+// Request message types understood by the server. The share, staging,
+// cancel and subscribe messages are only sent to servers announcing the
+// matching capability.
+const (
+	SQLQueryMessage    = "SQL_QUERY"
+	CreateShareMessage = "CREATE_SHARE"
+	ListSharesMessage  = "LIST_SHARES"
+	AcceptShareMessage = "ACCEPT_SHARE"
+	StageFileMessage   = "GET_STAGING_UPLOAD_URL"
+	CancelQueryMessage = "CANCEL_QUERY"
+	SubscribeMessage   = "SQL_SUBSCRIBE"
+)
+
 func GetPayLoad() Payload {
 	return Payload{
-		MessageType: "SQL_QUERY",
+		MessageType: SQLQueryMessage,
 		SQL:         "",
 		RequestID:   "",
 	}
@@ -64,3 +181,72 @@ type LogMessage struct {
 	RequestID   string `json:"requestId"`
 	LogMessage  string `json:"logMessage"`
 }
+
+// IsNull reports whether column is present in row i with an explicit NULL.
+func (r *Response) IsNull(i int, column string) bool {
+	if i < 0 || i >= len(r.Data) {
+		return false
+	}
+	v, ok := r.Data[i][column]
+	return ok && v == nil
+}
+
+// IsMissing reports whether column is absent from row i, as opposed to NULL.
+func (r *Response) IsMissing(i int, column string) bool {
+	if i < 0 || i >= len(r.Data) {
+		return true
+	}
+	_, ok := r.Data[i][column]
+	return !ok
+}
+
+// Rows returns the data as a row matrix with columns in server order, so
+// renderers do not need to derive an ordering from the row maps.
+func (r *Response) Rows() (cols []string, rows [][]interface{}) {
+	cols = r.Columns()
+	rows = make([][]interface{}, len(r.Data))
+	for i, row := range r.Data {
+		values := make([]interface{}, len(cols))
+		for j, col := range cols {
+			values[j] = row[col]
+		}
+		rows[i] = values
+	}
+	return cols, rows
+}
+
+// RowCount returns the number of rows in the response.
+func (r *Response) RowCount() int {
+	return len(r.Data)
+}
+
+// ByteSize returns the size of the rows encoded as JSON. It encodes the rows
+// to measure them, so callers should keep the result rather than call it
+// repeatedly.
+func (r *Response) ByteSize() int {
+	if len(r.Data) == 0 {
+		return 0
+	}
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// Columns returns the column names in server order, falling back to the
+// sorted keys of the first row when the order is unknown.
+func (r *Response) Columns() []string {
+	if len(r.Keys) > 0 {
+		return r.Keys
+	}
+	if len(r.Data) == 0 {
+		return nil
+	}
+	cols := make([]string, 0, len(r.Data[0]))
+	for key := range r.Data[0] {
+		cols = append(cols, key)
+	}
+	sort.Strings(cols)
+	return cols
+}
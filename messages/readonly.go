@@ -0,0 +1,8 @@
+package messages
+
+// WithReadOnly returns a copy of p marked as reading only, or as changing
+// state, whatever its statement looks like.
+func (p Payload) WithReadOnly(readOnly bool) Payload {
+	p.ReadOnly = &readOnly
+	return p
+}
@@ -0,0 +1,4 @@
+package messages
+
+// Row is one row of a result, keyed by column name.
+type Row map[string]interface{}
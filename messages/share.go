@@ -0,0 +1,17 @@
+package messages
+
+// SharePayload is the request body of the data share management messages.
+type SharePayload struct {
+	MessageType    string `json:"messageType"`
+	RequestID      string `json:"requestId"`
+	ShareName      string `json:"shareName,omitempty"`
+	SQL            string `json:"sql,omitempty"`
+	TargetUserName string `json:"targetUserName,omitempty"`
+	Description    string `json:"description,omitempty"`
+}
+
+func GetSharePayload(messageType string) SharePayload {
+	return SharePayload{
+		MessageType: messageType,
+	}
+}
@@ -0,0 +1,15 @@
+package messages
+
+// StagePayload requests a presigned upload URL for the user's staging area.
+type StagePayload struct {
+	MessageType string `json:"messageType"`
+	RequestID   string `json:"requestId"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+}
+
+func GetStagePayload() StagePayload {
+	return StagePayload{
+		MessageType: StageFileMessage,
+	}
+}
@@ -0,0 +1,76 @@
+package wsclient
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// cancelSendTimeout bounds how long a cancel message waits for the sender.
+const cancelSendTimeout = time.Second
+
+// SendMessageContext is like SendMessage but gives up when ctx is done
+// before the message is queued for sending. A full queue fails with
+// ErrSendQueueFull as the send policy dictates. With priority scheduling
+// the message is queued by PriorityFromContext(ctx). The rows of the
+// response are projected to the columns of ctx and mapped by its row
+// mappers.
+func (wsc *WSSClient) SendMessageContext(ctx context.Context, message []byte, payload messages.Payload) error {
+	pending := wsc.registerStatement(payload.RequestID, payload.SQL)
+	pending.mappers = rowMappers(ctx)
+	if pending.columns = projection(ctx); pending.columns != nil {
+		wsc.projections.Add(1)
+	}
+	if err := wsc.submit(ctx, message); err != nil {
+		if pending.columns != nil {
+			wsc.projections.Add(-1)
+		}
+		wsc.release(payload.RequestID, pending)
+		return err
+	}
+	return nil
+}
+
+// submit queues message through the priority scheduler when enabled, and
+// straight onto the send queue otherwise.
+func (wsc *WSSClient) submit(ctx context.Context, message []byte) error {
+	if wsc.scheduler != nil {
+		return wsc.enqueue(ctx, message)
+	}
+	return wsc.queueMessage(ctx, message)
+}
+
+// CancelRequest fails the pending request with cause and drops any frames
+// received for it. Servers that announced constants.CancelCapability, and
+// servers running the request as a subscription, are also asked to stop
+// it; others finish the query and its remaining frames are discarded.
+func (wsc *WSSClient) CancelRequest(requestID string, cause error) {
+	if cause == nil {
+		cause = context.Canceled
+	}
+	pending, ok := wsc.pending(requestID)
+	subscription := ok && pending.subscription
+	wsc.failRequest(requestID, cause)
+	wsc.requests.remove(requestID)
+	if wsc.IsWebSocketClosed() || !subscription && !wsc.ServerInfo().Supports(constants.CancelCapability) {
+		return
+	}
+	message, err := json.Marshal(messages.CancelPayload{MessageType: messages.CancelQueryMessage, RequestID: requestID})
+	if err != nil {
+		log.Println("Error marshalling cancel message:", err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	timer := wsc.clock.AfterFunc(cancelSendTimeout, cancel)
+	defer timer.Stop()
+	if err := wsc.submit(ctx, message); err != nil {
+		log.Println("Could not send cancel for request", requestID)
+		return
+	}
+	log.Println("Cancelled request", requestID)
+}
@@ -0,0 +1,270 @@
+package wsclient
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCassetteMismatch is returned by a ReplayTransport when the client sends
+// a message the recording does not contain at that point.
+var ErrCassetteMismatch = errors.New("message does not match the recording")
+
+// Directions of the frames of a cassette.
+const (
+	CassetteDial = "dial"
+	CassetteSend = "send"
+	CassetteRecv = "recv"
+)
+
+// CassetteFrame is one line of a cassette: a dial, or a message sent or
+// received, At after the recording started.
+type CassetteFrame struct {
+	Dir     string          `json:"dir"`
+	At      time.Duration   `json:"at"`
+	Message json.RawMessage `json:"message,omitempty"`
+}
+
+// recordingTransport writes every frame passing through transport to a
+// cassette.
+type recordingTransport struct {
+	transport Transport
+	cassette  *cassetteWriter
+}
+
+// cassetteWriter appends frames to a cassette. It is shared by the
+// transports of a client, so frames of rotated connections end up in one
+// cassette.
+type cassetteWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+}
+
+func (c *cassetteWriter) write(dir string, message []byte) {
+	frame := CassetteFrame{Dir: dir, At: time.Since(c.start)}
+	if len(message) > 0 {
+		if json.Valid(message) {
+			frame.Message = message
+		} else {
+			frame.Message, _ = json.Marshal(string(message))
+		}
+	}
+	line, err := json.Marshal(frame)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.w.Write(append(line, '\n'))
+}
+
+func (t *recordingTransport) Dial(url string, header http.Header) error {
+	if err := t.transport.Dial(url, header); err != nil {
+		return err
+	}
+	t.cassette.write(CassetteDial, nil)
+	return nil
+}
+
+func (t *recordingTransport) Send(message []byte) error {
+	t.cassette.write(CassetteSend, message)
+	return t.transport.Send(message)
+}
+
+func (t *recordingTransport) Receive() (io.Reader, error) {
+	reader, err := t.transport.Receive()
+	if err != nil {
+		return nil, err
+	}
+	message, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	t.cassette.write(CassetteRecv, message)
+	return &byteReader{data: message}, nil
+}
+
+func (t *recordingTransport) Close() error {
+	return t.transport.Close()
+}
+
+type byteReader struct {
+	data []byte
+}
+
+func (r *byteReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// WithRecorder writes every message the client sends and receives to w as a
+// cassette, one JSON CassetteFrame per line, for NewReplayTransport to serve
+// back offline in tests and bug reports. Writes to w are serialized.
+func WithRecorder(w io.Writer) Option {
+	return func(wsc *WSSClient) {
+		wsc.recorder = &cassetteWriter{w: w, start: time.Now()}
+	}
+}
+
+// record wraps the client's transports in recording transports.
+func (wsc *WSSClient) record() {
+	cassette := wsc.recorder
+	wsc.transport = &recordingTransport{transport: wsc.transport, cassette: cassette}
+	if newTransport := wsc.newTransport; newTransport != nil {
+		wsc.newTransport = func() Transport {
+			return &recordingTransport{transport: newTransport(), cassette: cassette}
+		}
+	}
+}
+
+// ReplayTransport serves the messages of a cassette back to a client,
+// without a network connection. Each message the client sends must match
+// the next recorded one by message type and SQL; the messages received
+// after it in the recording are then delivered. Request IDs are rewritten
+// from the recorded ones to those the client sends, so replays work with
+// generated request IDs.
+type ReplayTransport struct {
+	// Redactor, if set, masks the SQL quoted in ErrCassetteMismatch errors.
+	// It defaults to the redactors of the client the transport is set on.
+	Redactor Redactor
+
+	mu       sync.Mutex
+	frames   []CassetteFrame
+	next     int
+	ids      map[string]string
+	incoming chan []byte
+	closed   chan struct{}
+}
+
+// NewReplayTransport reads a cassette written by WithRecorder.
+func NewReplayTransport(r io.Reader) (*ReplayTransport, error) {
+	t := &ReplayTransport{ids: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1<<30)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var frame CassetteFrame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
+			return nil, fmt.Errorf("Error parsing cassette: %w", err)
+		}
+		t.frames = append(t.frames, frame)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Error reading cassette: %w", err)
+	}
+	return t, nil
+}
+
+// Dial delivers the messages recorded before the first send.
+func (t *ReplayTransport) Dial(url string, header http.Header) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.incoming = make(chan []byte, len(t.frames))
+	t.closed = make(chan struct{})
+	t.deliver()
+	return nil
+}
+
+// replayKey is the part of a sent message that must match the recording.
+type replayKey struct {
+	MessageType string `json:"messageType"`
+	SQL         string `json:"sql"`
+	RequestID   string `json:"requestId"`
+}
+
+func (t *ReplayTransport) Send(message []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var sent replayKey
+	if err := json.Unmarshal(message, &sent); err != nil {
+		return fmt.Errorf("%w: %v", ErrCassetteMismatch, err)
+	}
+	for t.next < len(t.frames) && t.frames[t.next].Dir == CassetteDial {
+		t.next++
+	}
+	if t.next >= len(t.frames) {
+		return fmt.Errorf("%w: recording has no more messages, got %q", ErrCassetteMismatch, t.Redactor.Redact(sent.SQL))
+	}
+	var recorded replayKey
+	json.Unmarshal(t.frames[t.next].Message, &recorded)
+	if recorded.MessageType != sent.MessageType || recorded.SQL != sent.SQL {
+		return fmt.Errorf("%w: recorded %q, got %q", ErrCassetteMismatch, t.Redactor.Redact(recorded.SQL), t.Redactor.Redact(sent.SQL))
+	}
+	if recorded.RequestID != "" {
+		t.ids[recorded.RequestID] = sent.RequestID
+	}
+	t.next++
+	t.deliver()
+	return nil
+}
+
+// deliver queues the received messages up to the next send; t.mu is held.
+func (t *ReplayTransport) deliver() {
+	for ; t.next < len(t.frames) && t.frames[t.next].Dir != CassetteSend; t.next++ {
+		if t.frames[t.next].Dir == CassetteRecv {
+			t.incoming <- t.rewrite(t.frames[t.next].Message)
+		}
+	}
+}
+
+// rewrite replaces the recorded request ID of message with the live one.
+func (t *ReplayTransport) rewrite(message []byte) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(message, &fields); err != nil {
+		return message
+	}
+	var id string
+	if err := json.Unmarshal(fields["requestId"], &id); err != nil {
+		return message
+	}
+	live, ok := t.ids[id]
+	if !ok {
+		return message
+	}
+	fields["requestId"], _ = json.Marshal(live)
+	rewritten, err := json.Marshal(fields)
+	if err != nil {
+		return message
+	}
+	return rewritten
+}
+
+// Receive returns the next delivered message, blocking until one is
+// delivered or the transport is closed.
+func (t *ReplayTransport) Receive() (io.Reader, error) {
+	t.mu.Lock()
+	incoming, closed := t.incoming, t.closed
+	t.mu.Unlock()
+	select {
+	case message := <-incoming:
+		return &byteReader{data: message}, nil
+	case <-closed:
+		return nil, ErrConnectionClosed
+	}
+}
+
+func (t *ReplayTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed == nil {
+		return nil
+	}
+	select {
+	case <-t.closed:
+	default:
+		close(t.closed)
+	}
+	return nil
+}
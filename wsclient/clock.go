@@ -0,0 +1,93 @@
+package wsclient
+
+import "time"
+
+// Clock is the time source of a WSSClient. All of its timeouts, including
+// the idle timer, response, send and keep-warm timeouts and connection
+// rotation, are measured with it, so tests can advance time
+// deterministically with a fake implementation such as the one in package
+// boilingtest/fakeclock. Network deadlines of the default transport always
+// use the system clock.
+type Clock interface {
+	Now() time.Time
+	// NewTimer returns a Timer that sends the current time on its channel
+	// after d.
+	NewTimer(d time.Duration) Timer
+	// AfterFunc calls f in its own goroutine after d.
+	AfterFunc(d time.Duration, f func()) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the timer returned by a Clock. C is nil for AfterFunc timers.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the ticker returned by a Clock.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the Clock backed by the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{time.AfterFunc(d, f)}
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+func (t systemTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
+
+type systemTicker struct {
+	ticker *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t systemTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// since returns the time elapsed since t on the client's clock.
+func (wsc *WSSClient) since(t time.Time) time.Duration {
+	return wsc.clock.Now().Sub(t)
+}
+
+// after returns a channel that receives after d on the client's clock, and
+// a func to release the timer.
+func (wsc *WSSClient) after(d time.Duration) (<-chan time.Time, func() bool) {
+	timer := wsc.clock.NewTimer(d)
+	return timer.C(), timer.Stop
+}
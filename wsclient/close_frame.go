@@ -0,0 +1,61 @@
+package wsclient
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrClosedByServer matches the CloseError of a connection the server
+// closed with a close frame.
+var ErrClosedByServer = errors.New("connection closed by server")
+
+// CloseError reports the close code and reason the server sent when it
+// closed the connection, e.g. 1001 (going away) or an application code such
+// as 4401. Queries pending at that time fail with a ConnectionLostError
+// whose Cause is the CloseError, and the connection listener receives it.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("%v (code %d)", ErrClosedByServer, e.Code)
+	}
+	return fmt.Sprintf("%v (code %d): %s", ErrClosedByServer, e.Code, e.Reason)
+}
+
+func (e *CloseError) Is(target error) bool {
+	return target == ErrClosedByServer
+}
+
+// closeHandler logs the close frame of the server and answers it, like the
+// default handler of gorilla/websocket.
+func closeHandler(conn *websocket.Conn) func(code int, text string) error {
+	return func(code int, text string) error {
+		log.Printf("Server closed the connection (code %d): %s", code, text)
+		message := []byte{}
+		if code != websocket.CloseNoStatusReceived {
+			message = websocket.FormatCloseMessage(code, "")
+		}
+		err := conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(time.Second))
+		if err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+			return err
+		}
+		return nil
+	}
+}
+
+// closeError converts the close error of gorilla/websocket into a
+// CloseError, leaving other errors as they are.
+func closeError(err error) error {
+	var closed *websocket.CloseError
+	if errors.As(err, &closed) {
+		return throttled(&CloseError{Code: closed.Code, Reason: closed.Text}, nil)
+	}
+	return err
+}
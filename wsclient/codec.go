@@ -0,0 +1,50 @@
+package wsclient
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Codec decodes the JSON frames received from the server. It allows
+// swapping encoding/json for a faster implementation such as jsoniter or
+// sonic, which expose the same function signatures.
+//
+// Frames are read into pooled buffers that are reused once a frame has
+// been handled. Decoders that alias their input, as many fast ones do for
+// strings and json.RawMessage, would hand out rows overwritten by the next
+// frame, so a Codec other than DefaultCodec and NumberCodec is passed a
+// copy of each frame that it may keep.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type stdCodec struct{}
+
+func (stdCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// DefaultCodec is the encoding/json based Codec used when none is configured.
+var DefaultCodec Codec = stdCodec{}
+
+// NumberCodec is an encoding/json based Codec that decodes numbers into
+// json.Number instead of float64, so BIGINT and DECIMAL values keep their
+// full precision.
+var NumberCodec Codec = numberCodec{}
+
+type numberCodec struct{}
+
+func (numberCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (numberCodec) Unmarshal(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
@@ -0,0 +1,118 @@
+package wsclient
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+)
+
+// Decompressor returns a reader of the decompressed form of r.
+type Decompressor func(r io.Reader) (io.Reader, error)
+
+// defaultDecompressors are the encodings decoded without configuration.
+var defaultDecompressors = map[string]Decompressor{
+	"gzip": func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	},
+	"deflate": func(r io.Reader) (io.Reader, error) {
+		return flate.NewReader(r), nil
+	},
+}
+
+// frameMagic identifies compressed binary frames by their leading bytes.
+var frameMagic = []struct {
+	encoding string
+	magic    []byte
+}{
+	{"gzip", []byte{0x1f, 0x8b}},
+	{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// compressedFrame is a JSON frame carrying a compressed frame in Payload,
+// base64 encoded.
+type compressedFrame struct {
+	Compression string `json:"compression"`
+	Payload     []byte `json:"payload"`
+}
+
+var compressionField = []byte(`"compression":"`)
+
+// WithDecompressor decodes frames compressed with encoding using fn, e.g.
+// "zstd" with a zstd decoder from a third-party package. gzip and deflate
+// are supported without configuration.
+func WithDecompressor(encoding string, fn Decompressor) Option {
+	return func(wsc *WSSClient) {
+		if wsc.decompressors == nil {
+			wsc.decompressors = make(map[string]Decompressor)
+		}
+		wsc.decompressors[encoding] = fn
+	}
+}
+
+func (wsc *WSSClient) decompressor(encoding string) (Decompressor, bool) {
+	if fn, ok := wsc.decompressors[encoding]; ok {
+		return fn, true
+	}
+	fn, ok := defaultDecompressors[encoding]
+	return fn, ok
+}
+
+// inflate returns frame decompressed when it is a compressed binary frame,
+// recognized by its magic bytes, or a JSON frame whose compression field
+// names the encoding of its payload. Other frames are returned as they are.
+// The frame is released when a new one is returned.
+func (wsc *WSSClient) inflate(frame *bytes.Buffer) (*bytes.Buffer, error) {
+	message := frame.Bytes()
+	for _, known := range frameMagic {
+		if bytes.HasPrefix(message, known.magic) {
+			return wsc.decompressFrame(frame, known.encoding, message)
+		}
+	}
+	if !bytes.Contains(message, compressionField) {
+		return frame, nil
+	}
+	var envelope compressedFrame
+	if err := wsc.codec.Unmarshal(message, &envelope); err != nil || envelope.Compression == "" || envelope.Payload == nil {
+		// A column named compression, not an envelope.
+		return frame, nil
+	}
+	return wsc.decompressFrame(frame, envelope.Compression, envelope.Payload)
+}
+
+func (wsc *WSSClient) decompressFrame(frame *bytes.Buffer, encoding string, compressed []byte) (*bytes.Buffer, error) {
+	fn, ok := wsc.decompressor(encoding)
+	if !ok {
+		return nil, fmt.Errorf("unsupported frame compression %q", encoding)
+	}
+	reader, err := fn(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("Error decompressing %s frame: %w", encoding, err)
+	}
+	inflated := framePool.Get().(*bytes.Buffer)
+	inflated.Reset()
+	_, err = inflated.ReadFrom(reader)
+	if closer, ok := reader.(io.Closer); ok {
+		closer.Close()
+	}
+	if err != nil {
+		releaseFrame(inflated)
+		return nil, fmt.Errorf("Error decompressing %s frame: %w", encoding, err)
+	}
+	releaseFrame(frame)
+	return inflated, nil
+}
+
+// inflateOrDrop is inflate for the receive loop, which drops frames that
+// fail to decompress since their request cannot be told.
+func (wsc *WSSClient) inflateOrDrop(frame *bytes.Buffer) (*bytes.Buffer, bool) {
+	inflated, err := wsc.inflate(frame)
+	if err != nil {
+		log.Println("Dropping frame:", err)
+		releaseFrame(frame)
+		return nil, false
+	}
+	return inflated, true
+}
@@ -0,0 +1,72 @@
+package wsclient
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotConnected is wrapped by errors raised while the websocket is closed.
+var ErrNotConnected = errors.New("Not connected to WebSocket server")
+
+// ErrMessageTooLarge is returned when a server frame exceeds the configured
+// maximum message size.
+var ErrMessageTooLarge = errors.New("message from server exceeds the maximum message size")
+
+// ErrPayloadTooLarge matches the PayloadTooLargeError of messages larger
+// than the gateway accepts.
+var ErrPayloadTooLarge = errors.New("payload exceeds the maximum message size")
+
+// PayloadTooLargeError is returned for an outgoing message larger than the
+// limit set with WithMaxPayloadSize; the message is not sent. The protocol
+// has no multi-part requests, so such queries have to be made smaller, e.g.
+// by staging a large IN-list as a file.
+type PayloadTooLargeError struct {
+	Size  int
+	Limit int
+}
+
+func (e *PayloadTooLargeError) Error() string {
+	return fmt.Sprintf("%v: %d bytes, limit %d", ErrPayloadTooLarge, e.Size, e.Limit)
+}
+
+func (e *PayloadTooLargeError) Is(target error) bool {
+	return target == ErrPayloadTooLarge
+}
+
+// ErrResponseTimeout is returned when no complete response arrives within the
+// response timeout.
+var ErrResponseTimeout = errors.New("timeout occurred while waiting for response")
+
+// ServerError is returned for a query that the server failed with a
+// LOG_MESSAGE. It carries the request ID and log level for correlation.
+type ServerError struct {
+	RequestID string
+	LogLevel  string
+	Message   string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("Log message from server (%s, request %s): %s", e.LogLevel, e.RequestID, e.Message)
+}
+
+// ErrConnectionLost matches the ConnectionLostError of requests that were
+// pending when the connection dropped.
+var ErrConnectionLost = errors.New("connection lost")
+
+// ConnectionLostError fails a request that was pending when the connection
+// dropped. BatchesReceived tells how many sub-batches had arrived; Cause is
+// the connection error. It matches both ErrConnectionLost and Cause with
+// errors.Is.
+type ConnectionLostError struct {
+	RequestID       string
+	BatchesReceived int
+	Cause           error
+}
+
+func (e *ConnectionLostError) Error() string {
+	return fmt.Sprintf("%v: request %s after %d sub-batches: %v", ErrConnectionLost, e.RequestID, e.BatchesReceived, e.Cause)
+}
+
+func (e *ConnectionLostError) Unwrap() []error {
+	return []error{ErrConnectionLost, e.Cause}
+}
@@ -0,0 +1,48 @@
+package wsclient
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+)
+
+// WithHeaders adds extra headers, such as tenant IDs, tracing headers or WAF
+// tokens, to every websocket handshake. They never replace the signed
+// headers, the protocol and client identification headers, or the headers
+// the websocket handshake itself sets.
+func WithHeaders(header http.Header) Option {
+	return func(wsc *WSSClient) {
+		if wsc.extraHeader == nil {
+			wsc.extraHeader = make(http.Header)
+		}
+		for key, values := range header {
+			for _, value := range values {
+				wsc.extraHeader.Add(key, value)
+			}
+		}
+	}
+}
+
+// mergeHeaders adds the extra headers to header, skipping any header it
+// already has and any header relevant to the signature or the handshake.
+func mergeHeaders(header http.Header, extra http.Header) {
+	for key, values := range extra {
+		key = http.CanonicalHeaderKey(key)
+		if reservedHeader(key) || len(header.Values(key)) > 0 {
+			continue
+		}
+		header[key] = append([]string(nil), values...)
+	}
+}
+
+// reservedHeader reports whether key may not be set through WithHeaders.
+func reservedHeader(key string) bool {
+	switch key {
+	case "Authorization", "Host", "Upgrade", "Connection", "User-Agent",
+		http.CanonicalHeaderKey(constants.ClientHeader),
+		http.CanonicalHeaderKey(constants.ProtocolVersionHeader):
+		return true
+	}
+	return strings.HasPrefix(key, "X-Amz-") || strings.HasPrefix(key, "Sec-Websocket-")
+}
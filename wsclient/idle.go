@@ -0,0 +1,48 @@
+package wsclient
+
+import "time"
+
+// nextIdleDelay returns the delay to arm the idle timer with and records
+// the deadline it sets.
+func (wsc *WSSClient) nextIdleDelay() time.Duration {
+	delay := wsc.idleDelay()
+	wsc.idleDeadline.Store(wsc.clock.Now().Add(delay).UnixNano())
+	return delay
+}
+
+// markActive records activity on the connection and restarts the idle
+// timer while connected.
+func (wsc *WSSClient) markActive() {
+	if wsc.State() == Connected {
+		wsc.idleTimer.Reset(wsc.nextIdleDelay())
+	}
+	wsc.touch()
+}
+
+// WithKeepOpenWhilePending keeps the idle timer from closing the connection
+// while any request is still waiting for its response, however long the
+// server takes between frames.
+func WithKeepOpenWhilePending() Option {
+	return func(wsc *WSSClient) {
+		wsc.keepOpenWhilePending = true
+	}
+}
+
+// LastActivity returns when a message was last sent or received or the
+// connection was last opened, or the zero time if none has happened.
+func (wsc *WSSClient) LastActivity() time.Time {
+	if wsc.lastActivity.Load() == 0 {
+		return time.Time{}
+	}
+	return wsc.lastActivityTime()
+}
+
+// IdleDeadline returns when the idle timer will close the connection unless
+// there is activity before, or the zero time when it is not connected. The
+// time until then is wsc.IdleDeadline().Sub(time.Now()).
+func (wsc *WSSClient) IdleDeadline() time.Time {
+	if wsc.State() != Connected {
+		return time.Time{}
+	}
+	return time.Unix(0, wsc.idleDeadline.Load())
+}
@@ -0,0 +1,47 @@
+package wsclient_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/boilingtest/fakeclock"
+	"github.com/boilingdata/go-boilingdata/boilingtest/loadgen"
+	"github.com/boilingdata/go-boilingdata/wsclient"
+)
+
+func TestIdleTimer(t *testing.T) {
+	clock := fakeclock.New(time.Now())
+	wsc := wsclient.NewWSSClient("wss://example.invalid", 0, nil,
+		wsclient.WithIdleTimeout(time.Minute),
+		wsclient.WithClock(clock),
+		wsclient.WithTransport(loadgen.NewMockTransport(0, 1)),
+		wsclient.WithKeepWarm(0),
+	)
+	defer wsc.Close()
+	disconnects := 0
+	wsc.SetConnectionListener(func(connected bool, err error) {
+		if !connected {
+			disconnects++
+		}
+	})
+
+	clock.Advance(2 * time.Minute)
+	if disconnects != 0 {
+		t.Fatalf("idle timer fired before connecting")
+	}
+	if err := wsc.ConnectE(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(30 * time.Second)
+	if got := wsc.State(); got != wsclient.Connected {
+		t.Fatalf("state %v before the idle timeout, want Connected", got)
+	}
+	clock.Advance(31 * time.Second)
+	if got := wsc.State(); got != wsclient.Disconnected {
+		t.Fatalf("state %v after the idle timeout, want Disconnected", got)
+	}
+	clock.Advance(10 * time.Minute)
+	if disconnects != 1 {
+		t.Errorf("got %d disconnects, want 1: the idle timer must stay stopped while disconnected", disconnects)
+	}
+}
@@ -0,0 +1,70 @@
+package wsclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"time"
+)
+
+// QueryInfo describes a request waiting for its response.
+type QueryInfo struct {
+	RequestID string
+	// SQLHash identifies the statement without exposing it: a hex SHA-256
+	// prefix of the whitespace-normalized SQL, empty when unknown.
+	SQLHash string
+	Started time.Time
+	// Batches is the number of sub-batches received so far.
+	Batches int
+}
+
+// hashSQL returns the QueryInfo.SQLHash of sql.
+func hashSQL(sql string) string {
+	if sql == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(statementKey(sql)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// InFlight returns the requests still waiting for their response, oldest
+// first. A straggler can be stopped with CancelRequest.
+func (wsc *WSSClient) InFlight() []QueryInfo {
+	var infos []QueryInfo
+	for requestID, pending := range wsc.requests.snapshot() {
+		pending.mu.Lock()
+		if !pending.closed {
+			infos = append(infos, QueryInfo{
+				RequestID: requestID,
+				SQLHash:   pending.sqlHash,
+				Started:   pending.started,
+				Batches:   len(pending.batches),
+			})
+		}
+		pending.mu.Unlock()
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Started.Before(infos[j].Started)
+	})
+	return infos
+}
+
+// HasRequest reports whether requestID is waiting for its response on this
+// client.
+func (wsc *WSSClient) HasRequest(requestID string) bool {
+	_, ok := wsc.pending(requestID)
+	return ok
+}
+
+// QueueDepth returns the number of messages queued for sending: those in
+// the send channel, the SendExpand overflow and the priority scheduler.
+func (wsc *WSSClient) QueueDepth() int {
+	depth := len(wsc.messageChannel)
+	wsc.overflowMu.Lock()
+	depth += len(wsc.overflow)
+	wsc.overflowMu.Unlock()
+	if wsc.scheduler != nil {
+		depth += wsc.scheduler.len()
+	}
+	return depth
+}
@@ -0,0 +1,67 @@
+package wsclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// ErrCorruptBatch is returned when a sub-batch or the assembled result does
+// not match the row count or checksum the server reported for it.
+var ErrCorruptBatch = errors.New("corrupt result batch")
+
+// VerifyFrame checks a decoded DATA frame against the row count and
+// checksum the server sent with it, for callers assembling frames received
+// outside the websocket, such as over REST.
+func VerifyFrame(response *messages.Response, frame []byte) error {
+	return verifyBatch(response, len(response.Data), frame)
+}
+
+// verifyBatch checks a DATA frame of rows rows against the row count and
+// checksum the server sent with it, if any.
+func verifyBatch(response *messages.Response, rows int, message []byte) error {
+	if response.BatchRows != nil && *response.BatchRows != rows {
+		return fmt.Errorf("%w: sub-batch %d of request %s has %d rows, server reported %d",
+			ErrCorruptBatch, response.SubBatchSerial, response.RequestID, rows, *response.BatchRows)
+	}
+	if response.Checksum == "" {
+		return nil
+	}
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return fmt.Errorf("%w: sub-batch %d of request %s: %v", ErrCorruptBatch, response.SubBatchSerial, response.RequestID, err)
+	}
+	sum := fmt.Sprintf("%08x", crc32.ChecksumIEEE(envelope.Data))
+	if !strings.EqualFold(sum, response.Checksum) {
+		return fmt.Errorf("%w: sub-batch %d of request %s has checksum %s, server reported %s",
+			ErrCorruptBatch, response.SubBatchSerial, response.RequestID, sum, response.Checksum)
+	}
+	return nil
+}
+
+// verifyTotal checks the rows received against the total the server
+// reported for the result, if any; p.mu must be held. Raw requests and
+// results written to S3 are not checked.
+func (p *pendingRequest) verifyTotal(requestID string) error {
+	if p.totalRows == nil || p.raw != nil {
+		return nil
+	}
+	received := 0
+	for serial, batch := range p.batches {
+		if batch.ResultURL != "" {
+			return nil
+		}
+		received += p.rows[serial]
+	}
+	if received != *p.totalRows {
+		return fmt.Errorf("%w: request %s assembled %d rows, server reported %d",
+			ErrCorruptBatch, requestID, received, *p.totalRows)
+	}
+	return nil
+}
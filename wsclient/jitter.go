@@ -0,0 +1,33 @@
+package wsclient
+
+import (
+	"math/rand"
+	"time"
+)
+
+// WithJitter randomizes the idle timeout by up to fraction of its length in
+// either direction (0.2 = ±20%), and brings connection rotation forward by
+// up to fraction of the connection lifetime, so that many clients with the
+// same settings do not disconnect and reconnect in lockstep. Zero disables
+// it; fractions above 1 are capped at 1.
+func WithJitter(fraction float64) Option {
+	return func(wsc *WSSClient) {
+		wsc.jitter = min(max(fraction, 0), 1)
+	}
+}
+
+// idleDelay returns the idle timeout with jitter applied.
+func (wsc *WSSClient) idleDelay() time.Duration {
+	if wsc.jitter == 0 {
+		return wsc.idleTimeout
+	}
+	return time.Duration(float64(wsc.idleTimeout) * (1 + wsc.jitter*(2*rand.Float64()-1)))
+}
+
+// earlier shortens delay by a random part of up to the jitter fraction.
+func (wsc *WSSClient) earlier(delay time.Duration) time.Duration {
+	if wsc.jitter == 0 {
+		return delay
+	}
+	return time.Duration(float64(delay) * (1 - wsc.jitter*rand.Float64()))
+}
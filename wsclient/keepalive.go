@@ -0,0 +1,56 @@
+package wsclient
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// startKeepAlive pings the server periodically and arms a read deadline that
+// is refreshed by every pong and message. A half-open connection then fails
+// ReadMessage instead of hanging forever, which tears the connection down.
+func (wsc *WSSClient) startKeepAlive(conn *websocket.Conn, stop <-chan struct{}) {
+	if wait := wsc.readWait(); wait > 0 {
+		conn.SetReadDeadline(time.Now().Add(wait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wait))
+		})
+	}
+	if wsc.pingInterval <= 0 {
+		return
+	}
+	wsc.workers.spawn("keepalive", func() {
+		defer wsc.closeOnPanic("keepalive")
+		ticker := time.NewTicker(wsc.pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsc.pingInterval)); err != nil {
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	})
+}
+
+// extendReadDeadline pushes the read deadline forward after a message.
+func (wsc *WSSClient) extendReadDeadline(conn *websocket.Conn) {
+	if wait := wsc.readWait(); wait > 0 {
+		conn.SetReadDeadline(time.Now().Add(wait))
+	}
+}
+
+// readWait is how long a connection may stay silent before reads fail: the
+// configured read timeout, else the pong wait when pings are enabled.
+func (wsc *WSSClient) readWait() time.Duration {
+	if wsc.readTimeout != 0 {
+		return max(wsc.readTimeout, 0)
+	}
+	if wsc.pingInterval > 0 {
+		return wsc.pongWait
+	}
+	return 0
+}
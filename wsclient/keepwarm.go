@@ -0,0 +1,82 @@
+package wsclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// startKeepWarm sends a cheap warm-up query whenever the connection has been
+// idle for the keep-warm interval, so the server side stays warm and the idle
+// timer does not disconnect.
+func (wsc *WSSClient) startKeepWarm(stop chan []byte) {
+	if wsc.keepWarmInterval <= 0 {
+		return
+	}
+	wsc.workers.spawn("keepwarm", func() {
+		defer wsc.closeOnPanic("keepwarm")
+		ticker := wsc.clock.NewTicker(wsc.keepWarmInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C():
+				if wsc.since(wsc.lastActivityTime()) >= wsc.keepWarmInterval {
+					wsc.sendWarmUp()
+				}
+			case <-stop:
+				return
+			}
+		}
+	})
+}
+
+func (wsc *WSSClient) sendWarmUp() {
+	payload := messages.GetPayLoad()
+	payload.SQL = constants.KeepWarmSQL
+	payload.RequestID = fmt.Sprintf("keepwarm-%d", wsc.clock.Now().UnixNano())
+	message, err := json.Marshal(payload)
+	if err != nil {
+		log.Println("Error marshalling warm-up message:", err)
+		return
+	}
+	pending := wsc.register(payload.RequestID, payload.SQL)
+	timeout, stop := wsc.after(wsc.keepWarmInterval)
+	defer stop()
+	select {
+	case wsc.messageChannel <- message:
+	case <-timeout:
+		wsc.release(payload.RequestID, pending)
+		return
+	}
+	var release func()
+	release = func() {
+		defer wsc.restartOnPanic("keepwarm-release", release)
+		wsc.releaseWarmUp(payload.RequestID, pending)
+	}
+	wsc.workers.spawn("keepwarm-release", release)
+}
+
+// releaseWarmUp forgets a warm-up query once it completed, timed out or the
+// client closed; its result is not needed.
+func (wsc *WSSClient) releaseWarmUp(requestID string, pending *pendingRequest) {
+	timeout, stop := wsc.after(wsc.responseTimeout)
+	defer stop()
+	select {
+	case <-pending.done:
+	case <-timeout:
+	case <-wsc.done:
+	}
+	wsc.release(requestID, pending)
+}
+
+func (wsc *WSSClient) touch() {
+	wsc.lastActivity.Store(wsc.clock.Now().UnixNano())
+}
+
+func (wsc *WSSClient) lastActivityTime() time.Time {
+	return time.Unix(0, wsc.lastActivity.Load())
+}
@@ -0,0 +1,101 @@
+package wsclient
+
+import (
+	"context"
+	"sort"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// RowMapper transforms a row of a response as its sub-batch arrives. It may
+// modify and return row, return a new row, or return nil to drop the row.
+// An error fails the request.
+type RowMapper func(row messages.Row) (messages.Row, error)
+
+type rowMapperKey struct{}
+
+// ContextWithRowMapper returns a copy of ctx whose requests have their rows
+// passed through mappers, in order, after any mappers already in ctx.
+// Mappers run on the receive goroutine and should be fast. They apply to
+// collected responses, not to rows delivered by SendStreamContext.
+func ContextWithRowMapper(ctx context.Context, mappers ...RowMapper) context.Context {
+	chain := append(append([]RowMapper(nil), rowMappers(ctx)...), mappers...)
+	return context.WithValue(ctx, rowMapperKey{}, chain)
+}
+
+// HasRowMapper reports whether requests run with ctx have their rows mapped.
+func HasRowMapper(ctx context.Context) bool {
+	return len(rowMappers(ctx)) > 0
+}
+
+func rowMappers(ctx context.Context) []RowMapper {
+	mappers, _ := ctx.Value(rowMapperKey{}).([]RowMapper)
+	return mappers
+}
+
+// MapRows projects rows to the columns of ctx and passes them through its
+// mappers, for rows obtained outside the websocket such as downloaded
+// results.
+func MapRows(ctx context.Context, rows []map[string]interface{}) ([]map[string]interface{}, error) {
+	projectRows(projection(ctx), rows)
+	return mapRows(rowMappers(ctx), rows)
+}
+
+func mapRows(mappers []RowMapper, rows []map[string]interface{}) ([]map[string]interface{}, error) {
+	if len(mappers) == 0 {
+		return rows, nil
+	}
+	mapped := rows[:0]
+	for _, data := range rows {
+		row := messages.Row(data)
+		var err error
+		for _, mapper := range mappers {
+			if row, err = mapper(row); err != nil {
+				return nil, err
+			}
+			if row == nil {
+				break
+			}
+		}
+		if row != nil {
+			mapped = append(mapped, row)
+		}
+	}
+	return mapped, nil
+}
+
+// mapResponse maps the rows of response and adjusts its column keys: the
+// original columns still present keep their order, followed by the added
+// ones sorted by name.
+func mapResponse(mappers []RowMapper, response *messages.Response) error {
+	data, err := mapRows(mappers, response.Data)
+	if err != nil {
+		return err
+	}
+	response.Data = data
+	if response.Keys == nil {
+		return nil
+	}
+	if len(data) == 0 {
+		response.Keys = nil
+		return nil
+	}
+	first := data[0]
+	keys := make([]string, 0, len(first))
+	seen := make(map[string]bool, len(first))
+	for _, key := range response.Keys {
+		if _, ok := first[key]; ok {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	var added []string
+	for key := range first {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	response.Keys = append(keys, added...)
+	return nil
+}
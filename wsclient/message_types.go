@@ -0,0 +1,124 @@
+package wsclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// MessageHandler handles a server frame of a message type registered with
+// WithMessageType. message is the undecoded frame and is only valid during
+// the call; requestID is its requestId, empty for frames not tied to a
+// request. A non-nil error fails that request.
+type MessageHandler func(requestID string, message []byte) error
+
+// DecodeMessage returns a MessageHandler that decodes each frame into a
+// new T before passing it to fn, e.g. a struct modelling a message type
+// the client does not know.
+func DecodeMessage[T any](fn func(requestID string, message T) error) MessageHandler {
+	return func(requestID string, message []byte) error {
+		var decoded T
+		if err := json.Unmarshal(message, &decoded); err != nil {
+			return fmt.Errorf("Error parsing JSON: %w", err)
+		}
+		return fn(requestID, decoded)
+	}
+}
+
+// messageTypes holds the handlers registered for server message types.
+type messageTypes struct {
+	mu       sync.RWMutex
+	handlers map[string]MessageHandler
+}
+
+func (types *messageTypes) set(messageType string, handler MessageHandler) {
+	types.mu.Lock()
+	defer types.mu.Unlock()
+	if handler == nil {
+		delete(types.handlers, messageType)
+		return
+	}
+	if types.handlers == nil {
+		types.handlers = make(map[string]MessageHandler)
+	}
+	types.handlers[messageType] = handler
+}
+
+func (types *messageTypes) get(messageType string) (MessageHandler, bool) {
+	types.mu.RLock()
+	defer types.mu.RUnlock()
+	handler, ok := types.handlers[messageType]
+	return handler, ok
+}
+
+func (types *messageTypes) empty() bool {
+	types.mu.RLock()
+	defer types.mu.RUnlock()
+	return len(types.handlers) == 0
+}
+
+// isBuiltinMessageType reports whether the client handles messageType
+// itself.
+func isBuiltinMessageType(messageType string) bool {
+	switch messageType {
+	case messages.DATA.String(), messages.INFO.String(), messages.LOG_MESSAGE.String():
+		return true
+	}
+	return false
+}
+
+// WithMessageType registers handler for server frames whose messageType is
+// messageType, so applications can consume protocol messages the client
+// does not model yet. DATA, INFO and LOG_MESSAGE are always handled by the
+// client; handlers for them are ignored.
+func WithMessageType(messageType string, handler MessageHandler) Option {
+	return func(wsc *WSSClient) {
+		wsc.HandleMessageType(messageType, handler)
+	}
+}
+
+// HandleMessageType registers handler for messageType on a running client
+// like WithMessageType, replacing any previous handler. A nil handler
+// removes it.
+func (wsc *WSSClient) HandleMessageType(messageType string, handler MessageHandler) {
+	if isBuiltinMessageType(messageType) {
+		log.Println("Ignoring handler for built-in message type", messageType)
+		return
+	}
+	wsc.messageTypes.set(messageType, handler)
+}
+
+// dispatchMessageType passes a frame of a registered message type, as read
+// by the main decode of handleMessage, to its handler. It reports false when
+// no handler is registered for messageType.
+func (wsc *WSSClient) dispatchMessageType(messageType string, requestID string, message []byte) bool {
+	if isBuiltinMessageType(messageType) {
+		return false
+	}
+	handler, ok := wsc.messageTypes.get(messageType)
+	if !ok {
+		return false
+	}
+	if err := handler(requestID, message); err != nil {
+		log.Printf("Error handling %s message: %v", messageType, err)
+		wsc.failRequest(requestID, err)
+	}
+	return true
+}
+
+// handleRegisteredFrame dispatches a frame that does not decode as a
+// Response, reading only its message type and request ID. It reports false
+// when no handler is registered for the frame.
+func (wsc *WSSClient) handleRegisteredFrame(message []byte) bool {
+	var header struct {
+		MessageType string `json:"messageType"`
+		RequestID   string `json:"requestId"`
+	}
+	if err := wsc.codec.Unmarshal(message, &header); err != nil {
+		return false
+	}
+	return wsc.dispatchMessageType(header.MessageType, header.RequestID, message)
+}
@@ -0,0 +1,212 @@
+package wsclient
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// Option configures a WSSClient created by NewWSSClient.
+type Option func(*WSSClient)
+
+// WithCodec sets the Codec used to decode frames in the receive path.
+func WithCodec(codec Codec) Option {
+	return func(wsc *WSSClient) {
+		if codec != nil {
+			wsc.codec = codec
+		}
+	}
+}
+
+// WithSubprotocols sets the Sec-WebSocket-Protocol values offered when
+// dialing, in order of preference, e.g. "boilingdata.v2.json".
+func WithSubprotocols(protocols ...string) Option {
+	return func(wsc *WSSClient) {
+		wsc.DialOpts.Subprotocols = protocols
+	}
+}
+
+// WithNetDialer opens the connection's TCP connections with dialer, e.g. one
+// with a custom Resolver for DNS caching or split-horizon DNS.
+func WithNetDialer(dialer *net.Dialer) Option {
+	return WithDialContext(dialer.DialContext)
+}
+
+// WithDialContext opens the connection's TCP connections with dial, e.g. to
+// force IPv4 by dialing "tcp4" whatever network is asked for.
+func WithDialContext(dial func(ctx context.Context, network string, addr string) (net.Conn, error)) Option {
+	return func(wsc *WSSClient) {
+		wsc.DialOpts.NetDialContext = dial
+	}
+}
+
+// WithKeepAlive sets how often the server is pinged and how long the
+// connection may stay silent before it is considered dead. A zero interval
+// disables pings and read deadlines.
+func WithKeepAlive(pingInterval time.Duration, pongWait time.Duration) Option {
+	return func(wsc *WSSClient) {
+		wsc.pingInterval = pingInterval
+		wsc.pongWait = pongWait
+	}
+}
+
+// WithMaxMessageSize limits the size of a single frame read from the server.
+// Larger frames close the connection and fail pending queries with
+// ErrMessageTooLarge. Zero means no limit.
+func WithMaxMessageSize(bytes int64) Option {
+	return func(wsc *WSSClient) {
+		wsc.maxMessageSize = bytes
+	}
+}
+
+// WithMaxPayloadSize sets the size of the largest message the client sends;
+// larger queries fail with a PayloadTooLargeError before being sent. The
+// default is constants.MaxPayloadSize, the gateway limit. Zero or less
+// disables the check.
+func WithMaxPayloadSize(bytes int) Option {
+	return func(wsc *WSSClient) {
+		wsc.maxPayloadSize = bytes
+	}
+}
+
+// WithCompression offers permessage-deflate when dialing and, if the server
+// accepts it, compresses outgoing messages of at least threshold bytes,
+// such as generated SQL with thousands of literals. Servers that do not
+// advertise the extension receive messages uncompressed.
+func WithCompression(threshold int) Option {
+	return func(wsc *WSSClient) {
+		wsc.DialOpts.EnableCompression = threshold > 0
+		wsc.compressThreshold = threshold
+	}
+}
+
+// WithDeadlines sets the read and write deadlines of the connection. A write
+// that cannot complete within write fails instead of blocking the sender; a
+// connection silent for longer than read is torn down. Zero keeps the
+// defaults: the keep-alive pong wait for reads and constants.WriteTimeout for
+// writes. Negative values disable the deadline.
+func WithDeadlines(read time.Duration, write time.Duration) Option {
+	return func(wsc *WSSClient) {
+		if read != 0 {
+			wsc.readTimeout = read
+		}
+		if write != 0 {
+			wsc.writeTimeout = write
+		}
+	}
+}
+
+// WithKeepWarm sends a warm-up query after every interval without traffic,
+// keeping the connection open and the server warm instead of letting the
+// idle timer disconnect. Zero disables it.
+func WithKeepWarm(interval time.Duration) Option {
+	return func(wsc *WSSClient) {
+		wsc.keepWarmInterval = interval
+	}
+}
+
+// WithResponseTimeout sets how long GetResponseSync waits for a response.
+func WithResponseTimeout(timeout time.Duration) Option {
+	return func(wsc *WSSClient) {
+		if timeout > 0 {
+			wsc.responseTimeout = timeout
+		}
+	}
+}
+
+// WithPartialOnTimeout makes GetResponseSync and GetResponseContext return
+// the rows received so far, with Truncated set, when the response timeout
+// expires or the context is done, instead of failing. A request that has
+// received no rows still fails.
+func WithPartialOnTimeout() Option {
+	return func(wsc *WSSClient) {
+		wsc.partialOnTimeout = true
+	}
+}
+
+// WithIdleTimeout closes the connection after timeout without activity,
+// overriding the minutes given to NewWSSClient. Zero or less keeps the
+// default, constants.IdleTimeout.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(wsc *WSSClient) {
+		if timeout > 0 {
+			wsc.idleTimeout = timeout
+		}
+	}
+}
+
+// WithPriorityScheduling queues outgoing queries by the Priority of their
+// context, so interactive queries are sent ahead of queued batch queries.
+// The scheduler replaces the send queue: WithSendQueue has no effect.
+func WithPriorityScheduling() Option {
+	return func(wsc *WSSClient) {
+		wsc.scheduler = newScheduler()
+	}
+}
+
+// WithTransport replaces the gorilla/websocket transport. The keep-alive,
+// deadline, message size and subprotocol options only apply to the default
+// transport.
+func WithTransport(transport Transport) Option {
+	return func(wsc *WSSClient) {
+		wsc.transport = transport
+	}
+}
+
+// WithStatementCache remembers the result column order of the last size
+// distinct statements, so responses to repeated statements, such as
+// dashboard queries, skip the second parse of their final frame. Cached
+// columns are checked against each response before use.
+func WithStatementCache(size int) Option {
+	return func(wsc *WSSClient) {
+		if size > 0 {
+			wsc.statements = newStatementCache(size)
+		}
+	}
+}
+
+// WithClock sets the clock the client measures its timeouts with. It is
+// meant for tests; SystemClock is used by default.
+func WithClock(clock Clock) Option {
+	return func(wsc *WSSClient) {
+		wsc.clock = clock
+	}
+}
+
+// WithConnectionTTL sets the maximum lifetime the server allows a
+// connection, constants.ConnectionTTL by default. Shortly before it is
+// reached the connection is replaced by a new one without interrupting the
+// requests in flight, which requires a HeaderSigner and the default
+// transport. Zero disables rotation.
+func WithConnectionTTL(ttl time.Duration) Option {
+	return func(wsc *WSSClient) {
+		wsc.connectionTTL = ttl
+	}
+}
+
+// WithSendQueue sets the size of the outgoing message queue and the policy
+// applied when it is full. timeout bounds the wait of SendBlock; zero waits
+// until the message is queued or the caller's context is done.
+func WithSendQueue(size int, policy SendPolicy, timeout time.Duration) Option {
+	return func(wsc *WSSClient) {
+		wsc.sendQueueSize = max(size, 0)
+		wsc.sendPolicy = policy
+		wsc.sendTimeout = timeout
+	}
+}
+
+// WithDuplicatePolicy sets how sub-batches the server delivers more than
+// once are handled. The default is DuplicateIgnore.
+func WithDuplicatePolicy(policy DuplicatePolicy) Option {
+	return func(wsc *WSSClient) {
+		wsc.duplicatePolicy = policy
+	}
+}
+
+// WithUserAgent overrides the User-Agent and x-bd-client headers sent when
+// dialing, which default to UserAgent().
+func WithUserAgent(userAgent string) Option {
+	return func(wsc *WSSClient) {
+		wsc.userAgent = userAgent
+	}
+}
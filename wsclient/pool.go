@@ -0,0 +1,60 @@
+package wsclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledFrameSize keeps unusually large frames from pinning memory in the pool.
+const maxPooledFrameSize = 4 << 20
+
+var framePool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// readFrame reads the next message into a pooled buffer. The buffer must be
+// handed back with releaseFrame once the frame has been decoded.
+func readFrame(transport Transport) (*bytes.Buffer, error) {
+	reader, err := transport.Receive()
+	if err != nil {
+		return nil, err
+	}
+	frame := framePool.Get().(*bytes.Buffer)
+	frame.Reset()
+	if _, err := frame.ReadFrom(reader); err != nil {
+		releaseFrame(frame)
+		return nil, err
+	}
+	return frame, nil
+}
+
+// frameBytes returns the bytes of frame to decode with the client's codec.
+// The built-in codecs copy everything they keep and decode the pooled buffer
+// directly; any other codec gets a copy that outlives it.
+func (wsc *WSSClient) frameBytes(frame *bytes.Buffer) []byte {
+	switch wsc.codec.(type) {
+	case stdCodec, numberCodec:
+		return frame.Bytes()
+	}
+	return bytes.Clone(frame.Bytes())
+}
+
+func releaseFrame(frame *bytes.Buffer) {
+	if frame.Cap() > maxPooledFrameSize {
+		return
+	}
+	framePool.Put(frame)
+}
+
+type keysEnvelope struct {
+	Data []json.RawMessage `json:"data"`
+}
+
+var keysEnvelopePool = sync.Pool{
+	New: func() interface{} {
+		return new(keysEnvelope)
+	},
+}
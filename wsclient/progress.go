@@ -0,0 +1,82 @@
+package wsclient
+
+import (
+	"sync"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// Progress describes how much of a query response has been received so far.
+type Progress struct {
+	RequestID       string
+	FramesReceived  int
+	BatchSerial     int
+	TotalBatches    int
+	SubBatchSerial  int
+	TotalSubBatches int
+	BytesReceived   int64
+	Elapsed         time.Duration
+}
+
+// Percent estimates the completion of the query from its batch counters,
+// from 0 to 100. Serials are counted from 1; frames without totals count as
+// a single complete batch.
+func (p Progress) Percent() float64 {
+	if p.FramesReceived == 0 {
+		return 0
+	}
+	sub := 1.0
+	if p.TotalSubBatches > 0 {
+		sub = min(float64(p.SubBatchSerial), float64(p.TotalSubBatches)) / float64(p.TotalSubBatches)
+	}
+	if p.TotalBatches <= 1 {
+		return 100 * sub
+	}
+	done := float64(min(max(p.BatchSerial-1, 0), p.TotalBatches-1))
+	return 100 * (done + sub) / float64(p.TotalBatches)
+}
+
+// ProgressFunc is called from the receive loop for every DATA frame of a
+// request. It must not block.
+type ProgressFunc func(Progress)
+
+type progressTracker struct {
+	mu       sync.Mutex
+	started  time.Time
+	progress Progress
+	fn       ProgressFunc
+}
+
+// SetProgressListener registers fn to be notified about DATA frames received
+// for requestID. It should be called before the message is sent.
+func (wsc *WSSClient) SetProgressListener(requestID string, fn ProgressFunc) {
+	wsc.progressListeners.set(requestID, &progressTracker{
+		started:  wsc.clock.Now(),
+		progress: Progress{RequestID: requestID},
+		fn:       fn,
+	})
+}
+
+// RemoveProgressListener stops progress notifications for requestID.
+func (wsc *WSSClient) RemoveProgressListener(requestID string) {
+	wsc.progressListeners.remove(requestID)
+}
+
+func (wsc *WSSClient) notifyProgress(response *messages.Response, frameSize int) {
+	tracker, ok := wsc.progressListeners.get(response.RequestID)
+	if !ok {
+		return
+	}
+	tracker.mu.Lock()
+	tracker.progress.FramesReceived++
+	tracker.progress.BatchSerial = response.BatchSerial
+	tracker.progress.TotalBatches = response.TotalBatches
+	tracker.progress.SubBatchSerial = response.SubBatchSerial
+	tracker.progress.TotalSubBatches = response.TotalSubBatches
+	tracker.progress.BytesReceived += int64(frameSize)
+	tracker.progress.Elapsed = wsc.since(tracker.started)
+	progress := tracker.progress
+	tracker.mu.Unlock()
+	tracker.fn(progress)
+}
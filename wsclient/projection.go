@@ -0,0 +1,165 @@
+package wsclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+type projectionKey struct{}
+
+// ContextWithColumns returns a copy of ctx whose requests keep only the
+// named columns of each row. Other columns are skipped while the frame is
+// decoded rather than dropped afterwards, which saves memory on SELECT *
+// against wide tables when only a few fields are used. Names must match
+// the result columns exactly.
+func ContextWithColumns(ctx context.Context, columns ...string) context.Context {
+	set := make(map[string]struct{}, len(columns))
+	for _, column := range columns {
+		set[column] = struct{}{}
+	}
+	return context.WithValue(ctx, projectionKey{}, set)
+}
+
+// HasProjection reports whether requests run with ctx keep only some
+// columns.
+func HasProjection(ctx context.Context) bool {
+	return projection(ctx) != nil
+}
+
+func projection(ctx context.Context) map[string]struct{} {
+	columns, _ := ctx.Value(projectionKey{}).(map[string]struct{})
+	return columns
+}
+
+// projectRows drops the columns outside of columns from rows in place.
+func projectRows(columns map[string]struct{}, rows []map[string]interface{}) {
+	if columns == nil {
+		return
+	}
+	for _, row := range rows {
+		for key := range row {
+			if _, ok := columns[key]; !ok {
+				delete(row, key)
+			}
+		}
+	}
+}
+
+// projectedFrame decodes a DATA frame, its rows through projectedRows.
+type projectedFrame struct {
+	*messages.Response
+	Data *projectedRows `json:"data"`
+}
+
+// projectedRows decodes a JSON array of rows keeping only columns. keys
+// are the kept columns of the first row, in frame order.
+type projectedRows struct {
+	columns map[string]struct{}
+	codec   Codec
+	rows    []map[string]interface{}
+	keys    []string
+}
+
+// skipValue discards a JSON value without decoding it.
+type skipValue struct{}
+
+func (*skipValue) UnmarshalJSON([]byte) error {
+	return nil
+}
+
+func (p *projectedRows) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := expectDelim(decoder, '['); err != nil {
+		return err
+	}
+	var skip skipValue
+	for decoder.More() {
+		if err := expectDelim(decoder, '{'); err != nil {
+			return err
+		}
+		first := len(p.rows) == 0
+		row := make(map[string]interface{}, len(p.columns))
+		for decoder.More() {
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key, _ := token.(string)
+			if _, ok := p.columns[key]; !ok {
+				if err := decoder.Decode(&skip); err != nil {
+					return err
+				}
+				continue
+			}
+			var raw json.RawMessage
+			if err := decoder.Decode(&raw); err != nil {
+				return err
+			}
+			var value interface{}
+			if err := p.codec.Unmarshal(raw, &value); err != nil {
+				return err
+			}
+			row[key] = value
+			if first {
+				p.keys = append(p.keys, key)
+			}
+		}
+		if err := expectDelim(decoder, '}'); err != nil {
+			return err
+		}
+		p.rows = append(p.rows, row)
+	}
+	return expectDelim(decoder, ']')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v in rows, got %v", delim, token)
+	}
+	return nil
+}
+
+// handleProjectedFrame decodes a DATA frame of a request with a column
+// projection. It reports false when the frame belongs to no such request.
+func (wsc *WSSClient) handleProjectedFrame(message []byte) bool {
+	var header frameHeader
+	if err := wsc.codec.Unmarshal(message, &header); err != nil || header.MessageType != messages.DATA.String() {
+		return false
+	}
+	pending, ok := wsc.pending(header.RequestID)
+	if !ok || pending.columns == nil {
+		return false
+	}
+	frame := projectedFrame{
+		Response: new(messages.Response),
+		Data:     &projectedRows{columns: pending.columns, codec: wsc.codec},
+	}
+	if err := wsc.codec.Unmarshal(message, &frame); err != nil {
+		pending.fail(fmt.Errorf("Error parsing JSON: %w", err))
+		return true
+	}
+	response := frame.Response
+	response.Data = frame.Data.rows
+	if response.TotalSubBatches == 0 || response.TotalSubBatches == response.SubBatchSerial {
+		response.Keys = frame.Data.keys
+		response.Extra, _ = messages.ExtraFields(message)
+	}
+	if err := verifyBatch(response, len(response.Data), message); err != nil {
+		pending.fail(err)
+		return true
+	}
+	wsc.notifyProgress(response, len(message))
+	pending.add(response)
+	return true
+}
@@ -0,0 +1,152 @@
+package wsclient
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// infoMessage is the part of an INFO frame announcing the server protocol,
+// version and capabilities, or reporting the statistics of a request.
+type infoMessage struct {
+	MessageType     string          `json:"messageType"`
+	ProtocolVersion string          `json:"protocolVersion"`
+	ServerVersion   string          `json:"serverVersion"`
+	EngineVersion   string          `json:"engineVersion"`
+	Capabilities    json.RawMessage `json:"capabilities"`
+	RequestID       string          `json:"requestId"`
+	*messages.Stats
+}
+
+// ServerInfo is what the server announced about itself in INFO frames.
+// Fields it has not announced are empty.
+type ServerInfo struct {
+	ProtocolVersion string
+	ServerVersion   string
+	EngineVersion   string
+	// Capabilities lists the features the backend supports, e.g. "arrow".
+	Capabilities []string
+}
+
+// Supports reports whether the server announced capability.
+func (info ServerInfo) Supports(capability string) bool {
+	for _, c := range info.Capabilities {
+		if strings.EqualFold(c, capability) {
+			return true
+		}
+	}
+	return false
+}
+
+// merge overwrites the fields of info announced in message.
+func (info *ServerInfo) merge(message infoMessage) {
+	if message.ProtocolVersion != "" {
+		info.ProtocolVersion = message.ProtocolVersion
+	}
+	if message.ServerVersion != "" {
+		info.ServerVersion = message.ServerVersion
+	}
+	if message.EngineVersion != "" {
+		info.EngineVersion = message.EngineVersion
+	}
+	if capabilities, ok := parseCapabilities(message.Capabilities); ok {
+		info.Capabilities = capabilities
+	}
+}
+
+// parseCapabilities reads capabilities sent either as a list of names or as
+// an object of boolean flags.
+func parseCapabilities(raw json.RawMessage) ([]string, bool) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, false
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list, true
+	}
+	var flags map[string]bool
+	if err := json.Unmarshal(raw, &flags); err != nil {
+		return nil, false
+	}
+	list = make([]string, 0, len(flags))
+	for name, enabled := range flags {
+		if enabled {
+			list = append(list, name)
+		}
+	}
+	sort.Strings(list)
+	return list, true
+}
+
+// dialHeader returns the signed header with the protocol version this
+// client speaks, the client identification headers and the extra headers
+// set with WithHeaders.
+func (wsc *WSSClient) dialHeader() http.Header {
+	header := wsc.SignedHeader.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set(constants.ProtocolVersionHeader, constants.ProtocolVersion)
+	SetClientHeaders(header, wsc.userAgent)
+	mergeHeaders(header, wsc.extraHeader)
+	return header
+}
+
+// handleInfo records the protocol version, server version and capabilities
+// announced by the server and warns when its major protocol version differs
+// from the one this client speaks. Statistics sent for a request are
+// attached to its response.
+func (wsc *WSSClient) handleInfo(message []byte) {
+	var info infoMessage
+	if err := wsc.codec.Unmarshal(message, &info); err != nil {
+		return
+	}
+	if info.RequestID != "" && info.Stats != nil {
+		if pending, ok := wsc.pending(info.RequestID); ok {
+			pending.setStats(info.Stats)
+		}
+	}
+	wsc.mu.Lock()
+	wsc.serverInfo.merge(info)
+	wsc.mu.Unlock()
+	if info.ProtocolVersion != "" && wsc.protocolMismatch() {
+		log.Printf("Server speaks protocol version %s, this client speaks %s; responses may not parse", info.ProtocolVersion, constants.ProtocolVersion)
+	}
+}
+
+// ServerProtocolVersion returns the protocol version the server announced,
+// or an empty string when it has not announced one.
+func (wsc *WSSClient) ServerProtocolVersion() string {
+	return wsc.ServerInfo().ProtocolVersion
+}
+
+// ServerInfo returns the versions and capabilities the server announced.
+func (wsc *WSSClient) ServerInfo() ServerInfo {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	info := wsc.serverInfo
+	info.Capabilities = append([]string(nil), info.Capabilities...)
+	return info
+}
+
+// protocolMismatch reports whether the server announced a different major
+// protocol version.
+func (wsc *WSSClient) protocolMismatch() bool {
+	server := wsc.ServerProtocolVersion()
+	return server != "" && majorVersion(server) != majorVersion(constants.ProtocolVersion)
+}
+
+func majorVersion(version string) string {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	return major
+}
+
+// isInfo reports whether response is an INFO frame.
+func isInfo(response *messages.Response) bool {
+	return response.MessageType == messages.INFO.String()
+}
@@ -0,0 +1,67 @@
+package wsclient
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// SendRawContext sends message like SendMessageContext, but the DATA frames
+// of the response are kept as received instead of being decoded into rows.
+// Collect them with GetRawResponse.
+func (wsc *WSSClient) SendRawContext(ctx context.Context, message []byte, payload messages.Payload) error {
+	pending := wsc.register(payload.RequestID, payload.SQL)
+	pending.raw = make(map[int][]byte)
+	wsc.streams.Add(1)
+	if err := wsc.submit(ctx, message); err != nil {
+		wsc.streams.Add(-1)
+		wsc.release(payload.RequestID, pending)
+		return err
+	}
+	return nil
+}
+
+// GetRawResponse waits for a request sent with SendRawContext and returns
+// its DATA frames, undecoded, in sub-batch order.
+func (wsc *WSSClient) GetRawResponse(ctx context.Context, requestID string) ([][]byte, error) {
+	pending, ok := wsc.pending(requestID)
+	if !ok || pending.raw == nil {
+		return nil, fmt.Errorf("unknown raw request ID %q", requestID)
+	}
+	defer wsc.streams.Add(-1)
+	if _, err := wsc.GetResponseContext(ctx, requestID); err != nil {
+		return nil, err
+	}
+	return pending.rawFrames(), nil
+}
+
+// handleRawFrame keeps a copy of a DATA frame of a raw request.
+func (wsc *WSSClient) handleRawFrame(pending *pendingRequest, header *messages.Response, message []byte) {
+	wsc.notifyProgress(header, len(message))
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+	if _, ok := pending.batches[header.SubBatchSerial]; ok {
+		pending.duplicate(header, string(pending.raw[header.SubBatchSerial]) != string(message))
+		return
+	}
+	pending.raw[header.SubBatchSerial] = append([]byte(nil), message...)
+	pending.record(header, 0, false)
+}
+
+// rawFrames returns the kept frames in sub-batch order.
+func (p *pendingRequest) rawFrames() [][]byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	serials := make([]int, 0, len(p.raw))
+	for serial := range p.raw {
+		serials = append(serials, serial)
+	}
+	sort.Ints(serials)
+	frames := make([][]byte, len(serials))
+	for i, serial := range serials {
+		frames[i] = p.raw[serial]
+	}
+	return frames
+}
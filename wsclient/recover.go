@@ -0,0 +1,68 @@
+package wsclient
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// ErrPanic matches the PanicError of requests failed by a recovered panic.
+var ErrPanic = errors.New("panic in websocket client")
+
+// PanicError reports a panic recovered in one of the client's goroutines.
+// Every request in flight at the time fails with it.
+type PanicError struct {
+	Goroutine string
+	Value     interface{}
+	Stack     []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v (%s): %v", ErrPanic, e.Goroutine, e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	return ErrPanic
+}
+
+// recoverPanic turns a panic in a client goroutine into a PanicError for all
+// pending requests instead of stranding them. It must be deferred directly.
+func (wsc *WSSClient) recoverPanic(goroutine string) {
+	if value := recover(); value != nil {
+		wsc.panicked(goroutine, value)
+	}
+}
+
+// restartOnPanic is recoverPanic for goroutines that live as long as the
+// client: fn is started again unless the client is closed, so sending does
+// not silently stop. It must be deferred directly.
+func (wsc *WSSClient) restartOnPanic(goroutine string, fn func()) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	wsc.panicked(goroutine, value)
+	if !wsc.isClosed() {
+		wsc.workers.spawn(goroutine, fn)
+	}
+}
+
+// closeOnPanic is recoverPanic for goroutines of one connection: the
+// connection is closed, so the next query reconnects with all of its
+// goroutines running. It must be deferred directly.
+func (wsc *WSSClient) closeOnPanic(goroutine string) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	wsc.panicked(goroutine, value)
+	wsc.shutdown()
+}
+
+func (wsc *WSSClient) panicked(goroutine string, value interface{}) {
+	err := &PanicError{Goroutine: goroutine, Value: value, Stack: debug.Stack()}
+	log.Printf("%v\n%s", err, err.Stack)
+	wsc.setError(err)
+	wsc.failAll(err)
+}
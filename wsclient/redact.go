@@ -0,0 +1,58 @@
+package wsclient
+
+import "regexp"
+
+// Redactor masks the sensitive parts of text, such as SQL literals or
+// credentials embedded in SQL, before it is logged or put in an error.
+type Redactor func(text string) string
+
+// Redacted replaces whatever a Redactor masks.
+const Redacted = "***"
+
+var sqlLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// MaskLiterals is a Redactor replacing every SQL string literal with '***'.
+func MaskLiterals(text string) string {
+	return sqlLiteral.ReplaceAllLiteralString(text, "'"+Redacted+"'")
+}
+
+// RedactPatterns returns a Redactor replacing every match of patterns with
+// ***.
+func RedactPatterns(patterns ...*regexp.Regexp) Redactor {
+	return func(text string) string {
+		for _, pattern := range patterns {
+			text = pattern.ReplaceAllLiteralString(text, Redacted)
+		}
+		return text
+	}
+}
+
+// Chain returns a Redactor applying r and then next. Either may be nil.
+func (r Redactor) Chain(next Redactor) Redactor {
+	if r == nil {
+		return next
+	}
+	if next == nil {
+		return r
+	}
+	return func(text string) string {
+		return next(r(text))
+	}
+}
+
+// Redact applies r to text; a nil Redactor returns text unchanged.
+func (r Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	return r(text)
+}
+
+// WithRedactor applies redactor to server log messages before they are
+// logged or returned in a ServerError. Redactors set more than once are
+// applied in order.
+func WithRedactor(redactor Redactor) Option {
+	return func(wsc *WSSClient) {
+		wsc.redactor = wsc.redactor.Chain(redactor)
+	}
+}
@@ -0,0 +1,72 @@
+package wsclient
+
+import "sync"
+
+// registry maps request IDs to their per-request state of type V. Unlike an
+// untyped concurrent map it cannot hold values of any other type, so lookups
+// need no type assertions.
+type registry[V any] struct {
+	mu     sync.RWMutex
+	values map[string]V
+}
+
+func newRegistry[V any]() *registry[V] {
+	return &registry[V]{values: make(map[string]V)}
+}
+
+func (r *registry[V]) set(id string, value V) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[id] = value
+}
+
+func (r *registry[V]) get(id string) (V, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	value, ok := r.values[id]
+	return value, ok
+}
+
+func (r *registry[V]) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.values, id)
+}
+
+// removeIf removes id only while it still maps to a value matching match,
+// so a request that reused the ID is left alone.
+func (r *registry[V]) removeIf(id string, match func(V) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if value, ok := r.values[id]; ok && match(value) {
+		delete(r.values, id)
+	}
+}
+
+func (r *registry[V]) count() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.values)
+}
+
+func (r *registry[V]) keys() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]string, 0, len(r.values))
+	for id := range r.values {
+		keys = append(keys, id)
+	}
+	return keys
+}
+
+// snapshot returns a copy of the entries, to iterate without holding the
+// lock.
+func (r *registry[V]) snapshot() map[string]V {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	values := make(map[string]V, len(r.values))
+	for id, value := range r.values {
+		values[id] = value
+	}
+	return values
+}
@@ -0,0 +1,53 @@
+package wsclient
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestRegistrySetGetRemove(t *testing.T) {
+	r := newRegistry[int]()
+	r.set("a", 1)
+	r.set("b", 2)
+	r.set("a", 3)
+	if value, ok := r.get("a"); !ok || value != 3 {
+		t.Errorf("get(a) = %d, %v after overwriting", value, ok)
+	}
+	r.remove("a")
+	r.remove("missing")
+	if _, ok := r.get("a"); ok {
+		t.Error("removed request is still registered")
+	}
+	keys := r.keys()
+	sort.Strings(keys)
+	if r.count() != 1 || len(keys) != 1 || keys[0] != "b" {
+		t.Errorf("count() = %d, keys() = %v", r.count(), keys)
+	}
+}
+
+func TestRegistryRemoveIfKeepsReusedID(t *testing.T) {
+	r := newRegistry[int]()
+	r.set("a", 2)
+	r.removeIf("a", func(value int) bool { return value == 1 })
+	if _, ok := r.get("a"); !ok {
+		t.Error("request reusing the ID was removed")
+	}
+	r.removeIf("a", func(value int) bool { return value == 2 })
+	if _, ok := r.get("a"); ok {
+		t.Error("matching request was kept")
+	}
+}
+
+func TestRegistrySnapshotIsACopy(t *testing.T) {
+	r := newRegistry[int]()
+	r.set("a", 1)
+	snapshot := r.snapshot()
+	snapshot["b"] = 2
+	r.set("c", 3)
+	if _, ok := r.get("b"); ok {
+		t.Error("writing to the snapshot changed the registry")
+	}
+	if _, ok := snapshot["c"]; ok {
+		t.Error("the snapshot saw a later set")
+	}
+}
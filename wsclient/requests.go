@@ -0,0 +1,306 @@
+package wsclient
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// ErrConnectionClosed fails requests still pending when the connection closes.
+var ErrConnectionClosed = errors.New("websocket connection closed")
+
+// pendingRequest collects the frames of a single request. Each request has
+// its own state and completion channel, so concurrent queries on one client
+// never observe each other's frames or failures.
+type pendingRequest struct {
+	mu      sync.Mutex
+	batches map[int]*messages.Response
+	err     error
+	done    chan struct{}
+	closed  bool
+	// stream is set for requests sent with SendStreamContext, whose rows
+	// are handed to it instead of being kept in batches.
+	stream   FrameFunc
+	activity chan struct{}
+	// subscription is set for standing queries sent with
+	// SubscribeContext.
+	subscription bool
+	policy       DuplicatePolicy
+	rows         map[int]int
+	mappers      []RowMapper
+	// columns is the column projection, when set.
+	columns map[string]struct{}
+	// statement keys the statement cache, when enabled.
+	statement string
+	// stats are the statistics reported in an INFO frame.
+	stats *messages.Stats
+	// raw holds the undecoded DATA frames of requests sent with
+	// SendRawContext, by sub-batch serial.
+	raw map[int][]byte
+	// totalRows is the row count of the whole result, when the server
+	// reports it.
+	totalRows *int
+	// first is closed once the first frame arrives or the request
+	// completes.
+	first chan struct{}
+	// started and sqlHash describe the request for InFlight.
+	started time.Time
+	sqlHash string
+}
+
+func newPendingRequest(policy DuplicatePolicy) *pendingRequest {
+	return &pendingRequest{
+		batches: make(map[int]*messages.Response),
+		done:    make(chan struct{}),
+		first:   make(chan struct{}),
+		policy:  policy,
+		rows:    make(map[int]int),
+	}
+}
+
+// add records a DATA frame and completes the request once every sub-batch
+// has arrived. Its rows are passed through the request's row mappers first.
+func (p *pendingRequest) add(response *messages.Response) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	empty := len(response.Data) == 0 && response.ResultURL == ""
+	rows := len(response.Data)
+	if err := mapResponse(p.mappers, response); err != nil {
+		p.finish(err)
+		return
+	}
+	if previous, ok := p.batches[response.SubBatchSerial]; ok {
+		p.duplicate(response, !reflect.DeepEqual(previous.Data, response.Data))
+		return
+	}
+	p.record(response, rows, empty)
+}
+
+// addStreamed records a streamed frame of rows rows, whose Data is not kept.
+func (p *pendingRequest) addStreamed(header *messages.Response, rows int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.batches[header.SubBatchSerial]; ok {
+		p.duplicate(header, p.rows[header.SubBatchSerial] != rows)
+		return
+	}
+	p.record(header, rows, rows == 0 && header.ResultURL == "")
+}
+
+// accepts reports whether a frame for serial is new, so its rows may be
+// passed on to a stream.
+func (p *pendingRequest) accepts(serial int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, seen := p.batches[serial]
+	return !seen && !p.closed
+}
+
+// duplicate applies the duplicate policy to a redelivered sub-batch; p.mu
+// must be held. The first delivery is always kept.
+func (p *pendingRequest) duplicate(response *messages.Response, mismatch bool) {
+	log.Printf("Duplicate sub-batch %d for request %s", response.SubBatchSerial, response.RequestID)
+	if mismatch && p.policy == DuplicateError && !p.closed {
+		p.finish(fmt.Errorf("%w: sub-batch %d of request %s", ErrDuplicateMismatch, response.SubBatchSerial, response.RequestID))
+	}
+}
+
+// record stores a new sub-batch of rows rows, as received before any row
+// mappers, and completes the request when all have arrived; p.mu must be
+// held.
+func (p *pendingRequest) record(response *messages.Response, rows int, empty bool) {
+	if p.closed {
+		return
+	}
+	if len(p.batches) == 0 {
+		close(p.first)
+	}
+	if len(p.batches) == 0 && response.TotalSubBatches > 1 {
+		// Size the maps for every sub-batch up front instead of growing
+		// them frame by frame.
+		p.batches = make(map[int]*messages.Response, response.TotalSubBatches)
+		p.rows = make(map[int]int, response.TotalSubBatches)
+	}
+	p.batches[response.SubBatchSerial] = response
+	p.rows[response.SubBatchSerial] = rows
+	if response.TotalRows != nil {
+		p.totalRows = response.TotalRows
+	}
+	if empty && len(p.batches) == 1 {
+		p.finish(fmt.Errorf("No response from server. Check SQL syntax"))
+		return
+	}
+	if response.TotalSubBatches == 0 || len(p.batches) >= response.TotalSubBatches {
+		p.finish(p.verifyTotal(response.RequestID))
+	}
+}
+
+// fail completes the request with err unless it already completed.
+func (p *pendingRequest) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.finish(err)
+	}
+}
+
+func (p *pendingRequest) finish(err error) {
+	if len(p.batches) == 0 {
+		close(p.first)
+	}
+	p.err = err
+	p.closed = true
+	close(p.done)
+}
+
+// result assembles the sub-batches in serial order into a single response.
+// The last sub-batch carries the column keys and becomes the result.
+func (p *pendingRequest) result() (*messages.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return &messages.Response{}, p.err
+	}
+	return p.assemble(), nil
+}
+
+// partial assembles the sub-batches received so far.
+func (p *pendingRequest) partial() *messages.Response {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.batches) == 0 {
+		return &messages.Response{}
+	}
+	return p.assemble()
+}
+
+// assemble merges the batches; p.mu must be held and batches non-empty.
+func (p *pendingRequest) assemble() *messages.Response {
+	serials := make([]int, 0, len(p.batches))
+	for serial := range p.batches {
+		serials = append(serials, serial)
+	}
+	sort.Ints(serials)
+	total := 0
+	for _, serial := range serials {
+		total += len(p.batches[serial].Data)
+	}
+	var data []map[string]interface{}
+	if total > 0 {
+		data = make([]map[string]interface{}, 0, total)
+	}
+	for _, serial := range serials {
+		data = append(data, p.batches[serial].Data...)
+	}
+	finalResponse := *p.batches[serials[len(serials)-1]]
+	finalResponse.Data = data
+	if finalResponse.ResultURL == "" {
+		finalResponse.ResultURL = p.batches[serials[0]].ResultURL
+	}
+	if finalResponse.Stats == nil {
+		finalResponse.Stats = p.batches[serials[0]].Stats
+	}
+	if finalResponse.Stats == nil {
+		finalResponse.Stats = p.stats
+	}
+	return &finalResponse
+}
+
+// setStats records the statistics the server sent for the request in an
+// INFO frame.
+func (p *pendingRequest) setStats(stats *messages.Stats) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats = stats
+}
+
+// PartialResponse returns the rows received so far for a request that is
+// still pending, merged in sub-batch order. ok is false for unknown requests.
+func (wsc *WSSClient) PartialResponse(requestID string) (response *messages.Response, ok bool) {
+	pending, ok := wsc.pending(requestID)
+	if !ok {
+		return nil, false
+	}
+	return pending.partial(), true
+}
+
+// DuplicatePolicy decides how a redelivered sub-batch is handled. The first
+// delivery of a sub-batch is always the one kept.
+type DuplicatePolicy int
+
+const (
+	// DuplicateIgnore drops redelivered sub-batches.
+	DuplicateIgnore DuplicatePolicy = iota
+	// DuplicateError fails the request with ErrDuplicateMismatch when a
+	// redelivered sub-batch differs from the first delivery.
+	DuplicateError
+)
+
+// ErrDuplicateMismatch is returned under DuplicateError when the server
+// redelivers a sub-batch with different rows.
+var ErrDuplicateMismatch = errors.New("redelivered sub-batch does not match the original")
+
+// register creates the pending state for requestID, running sql, before its
+// message is sent.
+func (wsc *WSSClient) register(requestID string, sql string) *pendingRequest {
+	pending := newPendingRequest(wsc.duplicatePolicy)
+	pending.started = wsc.clock.Now()
+	pending.sqlHash = hashSQL(sql)
+	wsc.requests.set(requestID, pending)
+	return pending
+}
+
+// registerStatement registers a request running sql, whose result columns
+// may then be served from the statement cache.
+func (wsc *WSSClient) registerStatement(requestID string, sql string) *pendingRequest {
+	pending := wsc.register(requestID, sql)
+	if wsc.statements != nil && sql != "" {
+		pending.statement = statementKey(sql)
+	}
+	return pending
+}
+
+func (wsc *WSSClient) pending(requestID string) (*pendingRequest, bool) {
+	return wsc.requests.get(requestID)
+}
+
+// release forgets requestID once its response was collected, unless the ID
+// has since been registered again by another request.
+func (wsc *WSSClient) release(requestID string, pending *pendingRequest) {
+	wsc.requests.removeIf(requestID, func(current *pendingRequest) bool {
+		return current == pending
+	})
+}
+
+// failRequest fails a single request with err.
+func (wsc *WSSClient) failRequest(requestID string, err error) {
+	if pending, ok := wsc.pending(requestID); ok {
+		pending.fail(err)
+	}
+}
+
+// failAll fails every pending request with a ConnectionLostError when the
+// connection is lost, dropping the messages still queued for sending first.
+func (wsc *WSSClient) failAll(err error) {
+	wsc.dropQueued()
+	for requestID, pending := range wsc.requests.snapshot() {
+		pending.fail(&ConnectionLostError{
+			RequestID:       requestID,
+			BatchesReceived: pending.received(),
+			Cause:           err,
+		})
+	}
+}
+
+// received returns the number of sub-batches received so far.
+func (p *pendingRequest) received() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.batches)
+}
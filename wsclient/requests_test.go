@@ -0,0 +1,73 @@
+package wsclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// subBatch returns sub-batch serial of total holding a row per value.
+func subBatch(serial, total int, values ...int) *messages.Response {
+	response := &messages.Response{RequestID: "r1", SubBatchSerial: serial, TotalSubBatches: total}
+	for _, value := range values {
+		response.Data = append(response.Data, map[string]interface{}{"v": value})
+	}
+	return response
+}
+
+func values(t *testing.T, pending *pendingRequest) []int {
+	t.Helper()
+	response, err := pending.result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, row := range response.Data {
+		got = append(got, row["v"].(int))
+	}
+	return got
+}
+
+func TestDuplicateSubBatchIgnored(t *testing.T) {
+	for _, policy := range []DuplicatePolicy{DuplicateIgnore, DuplicateError} {
+		pending := newPendingRequest(policy)
+		pending.add(subBatch(2, 2, 3, 4))
+		pending.add(subBatch(2, 2, 3, 4))
+		pending.add(subBatch(1, 2, 1, 2))
+		if got := values(t, pending); len(got) != 4 || got[0] != 1 || got[3] != 4 {
+			t.Errorf("policy %v: got rows %v, want [1 2 3 4]", policy, got)
+		}
+	}
+}
+
+func TestDuplicateSubBatchMismatch(t *testing.T) {
+	pending := newPendingRequest(DuplicateIgnore)
+	pending.add(subBatch(1, 2, 1))
+	pending.add(subBatch(1, 2, 9))
+	pending.add(subBatch(2, 2, 2))
+	if got := values(t, pending); len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Errorf("DuplicateIgnore: got rows %v, want the first delivery [1 2]", got)
+	}
+
+	pending = newPendingRequest(DuplicateError)
+	pending.add(subBatch(1, 2, 1))
+	pending.add(subBatch(1, 2, 9))
+	select {
+	case <-pending.done:
+	default:
+		t.Fatal("DuplicateError: request still pending after a mismatching redelivery")
+	}
+	if _, err := pending.result(); !errors.Is(err, ErrDuplicateMismatch) {
+		t.Errorf("DuplicateError: got %v, want ErrDuplicateMismatch", err)
+	}
+}
+
+func TestDuplicateAfterCompletion(t *testing.T) {
+	pending := newPendingRequest(DuplicateError)
+	pending.add(subBatch(1, 1, 1))
+	pending.add(subBatch(1, 1, 9))
+	if got := values(t, pending); len(got) != 1 || got[0] != 1 {
+		t.Errorf("got rows %v, want [1]", got)
+	}
+}
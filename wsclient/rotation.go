@@ -0,0 +1,186 @@
+package wsclient
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+)
+
+// HeaderSigner returns a freshly signed header for dialing the server. It
+// is needed to rotate connections, since the header used for the first dial
+// will have expired by then.
+type HeaderSigner func() (http.Header, error)
+
+// SetHeaderSigner sets the signer used to dial replacement connections.
+// Connections are only rotated when a signer is set.
+func (wsc *WSSClient) SetHeaderSigner(signer HeaderSigner) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	wsc.headerSigner = signer
+}
+
+// SetRotationListener sets fn to be called after a connection has been
+// replaced, e.g. to restore per-connection settings on the new one.
+func (wsc *WSSClient) SetRotationListener(fn func()) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	wsc.rotationListener = fn
+}
+
+// rotationDelay returns how long after connecting the connection is
+// rotated, or 0 when rotation is disabled.
+func (wsc *WSSClient) rotationDelay() time.Duration {
+	if wsc.connectionTTL <= 0 || wsc.newTransport == nil || wsc.headerSigner == nil {
+		return 0
+	}
+	return max(wsc.earlier(wsc.connectionTTL-wsc.rotationMargin()), time.Second)
+}
+
+// rotationMargin is how long before the TTL the connection is rotated, and
+// how long the old connection is given to drain.
+func (wsc *WSSClient) rotationMargin() time.Duration {
+	return min(constants.RotationMargin, wsc.connectionTTL/10)
+}
+
+// scheduleRotation arms the rotation timer for the current connection. It
+// must be called with mu held.
+func (wsc *WSSClient) scheduleRotation() {
+	if wsc.rotationTimer != nil {
+		wsc.rotationTimer.Stop()
+		wsc.rotationTimer = nil
+	}
+	if delay := wsc.rotationDelay(); delay > 0 {
+		wsc.rotationTimer = wsc.clock.AfterFunc(delay, wsc.rotate)
+	}
+}
+
+// rotate dials a replacement connection before the server's connection TTL
+// is reached. New messages are sent on the replacement while the old
+// connection keeps receiving the responses of the requests in flight on it
+// until they complete or the margin runs out. A connection still draining
+// from the previous rotation is closed first. When the replacement cannot be
+// dialed the old connection is kept until the server drops it.
+func (wsc *WSSClient) rotate() {
+	defer wsc.closeOnPanic("rotate")
+	if wsc.isClosed() || wsc.IsWebSocketClosed() {
+		return
+	}
+	log.Println("Connection TTL approaching, rotating connection")
+	header, err := wsc.headerSigner()
+	if err != nil {
+		log.Println("Could not sign replacement connection:", err)
+		return
+	}
+	wsc.mu.Lock()
+	wsc.SignedHeader = header
+	dialHeader := wsc.dialHeader()
+	wsc.mu.Unlock()
+	next := wsc.newTransport()
+	if err := next.Dial(wsc.URL, dialHeader); err != nil {
+		log.Println("Could not dial replacement connection:", err)
+		return
+	}
+
+	wsc.mu.Lock()
+	if wsc.State() != Connected {
+		wsc.mu.Unlock()
+		next.Close()
+		return
+	}
+	old := wsc.transport
+	previous, previousIDs := wsc.draining, wsc.drainIDs
+	wsc.transport = next
+	wsc.Conn = websocketConn(next)
+	wsc.draining = old
+	wsc.drainIDs = wsc.requests.keys()
+	wsc.scheduleRotation()
+	listener, stop := wsc.rotationListener, wsc.stopChannel
+	wsc.mu.Unlock()
+	wsc.counters.connected(wsc.clock.Now())
+	if previous != nil {
+		wsc.closeRetired(previous, previousIDs, errors.New("rotated again before it drained"))
+	}
+
+	wsc.workers.spawn("receive", func() { wsc.receiveMessageAsync(next, stop) })
+	wsc.workers.spawn("drain", func() { wsc.drain(old) })
+	if listener != nil {
+		listener()
+	}
+}
+
+// drain closes the retired transport once the requests in flight when it
+// was retired have completed, or when the rotation margin has passed.
+func (wsc *WSSClient) drain(old Transport) {
+	defer wsc.recoverPanic("drain")
+	deadline := wsc.clock.Now().Add(wsc.rotationMargin())
+	ticker := wsc.clock.NewTicker(time.Second)
+	defer ticker.Stop()
+	for wsc.clock.Now().Before(deadline) && wsc.drainPending(old) {
+		select {
+		case <-ticker.C():
+		case <-wsc.done:
+		}
+	}
+	wsc.retire(old, nil)
+}
+
+// drainPending reports whether old is still draining and any request in
+// flight on it is pending.
+func (wsc *WSSClient) drainPending(old Transport) bool {
+	wsc.mu.Lock()
+	if wsc.draining != old {
+		wsc.mu.Unlock()
+		return false
+	}
+	ids := wsc.drainIDs
+	wsc.mu.Unlock()
+	for _, id := range ids {
+		if _, ok := wsc.pending(id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// retire closes the retired transport old. With err set, the requests still
+// in flight on it fail with a ConnectionLostError.
+func (wsc *WSSClient) retire(old Transport, err error) {
+	wsc.mu.Lock()
+	if wsc.draining != old {
+		wsc.mu.Unlock()
+		return
+	}
+	ids := wsc.drainIDs
+	wsc.draining, wsc.drainIDs = nil, nil
+	wsc.mu.Unlock()
+	wsc.closeRetired(old, ids, err)
+}
+
+// closeRetired closes old, which is no longer draining. With err set, the
+// requests ids still in flight on it fail with a ConnectionLostError.
+func (wsc *WSSClient) closeRetired(old Transport, ids []string, err error) {
+	old.Close()
+	if err == nil {
+		return
+	}
+	for _, id := range ids {
+		if pending, ok := wsc.pending(id); ok {
+			pending.fail(&ConnectionLostError{
+				RequestID:       id,
+				BatchesReceived: pending.received(),
+				Cause:           fmt.Errorf("retired connection: %w", err),
+			})
+		}
+	}
+}
+
+// isCurrent reports whether transport is the one new messages are sent on.
+func (wsc *WSSClient) isCurrent(transport Transport) bool {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	return wsc.transport == transport
+}
@@ -0,0 +1,146 @@
+package wsclient
+
+import (
+	"context"
+	"sync"
+)
+
+// Priority orders queued messages when priority scheduling is enabled.
+type Priority int
+
+const (
+	// PriorityInteractive is the default, for queries a user is waiting on.
+	PriorityInteractive Priority = iota
+	// PriorityBatch is for background jobs that may wait behind interactive
+	// queries.
+	PriorityBatch
+)
+
+type priorityKey struct{}
+
+// ContextWithPriority returns a copy of ctx carrying priority.
+func ContextWithPriority(ctx context.Context, priority Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the priority stored in ctx, or
+// PriorityInteractive.
+func PriorityFromContext(ctx context.Context) Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return priority
+	}
+	return PriorityInteractive
+}
+
+// scheduledMessage is a message waiting in the scheduler.
+type scheduledMessage struct {
+	ctx      context.Context
+	message  []byte
+	accepted chan struct{}
+	// dropped is closed when the message is discarded unsent.
+	dropped chan struct{}
+}
+
+// scheduler sits in front of the send channel and hands messages to the
+// sender highest priority first, FIFO within a priority.
+type scheduler struct {
+	mu     sync.Mutex
+	queues [PriorityBatch + 1][]*scheduledMessage
+	ready  chan struct{}
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{ready: make(chan struct{}, 1)}
+}
+
+func (s *scheduler) push(priority Priority, item *scheduledMessage) {
+	priority = min(max(priority, PriorityInteractive), PriorityBatch)
+	s.mu.Lock()
+	s.queues[priority] = append(s.queues[priority], item)
+	s.mu.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// len returns the number of queued messages.
+func (s *scheduler) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, queue := range s.queues {
+		n += len(queue)
+	}
+	return n
+}
+
+// pop removes the next message, skipping those whose context is done.
+func (s *scheduler) pop() *scheduledMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.queues {
+		for len(s.queues[i]) > 0 {
+			item := s.queues[i][0]
+			s.queues[i][0] = nil
+			s.queues[i] = s.queues[i][1:]
+			if item.ctx.Err() == nil {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+// drop discards every queued message.
+func (s *scheduler) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, queue := range s.queues {
+		for _, item := range queue {
+			close(item.dropped)
+		}
+		s.queues[i] = nil
+	}
+}
+
+// runScheduler forwards queued messages to the send channel for the life
+// of the client.
+func (wsc *WSSClient) runScheduler() {
+	defer wsc.restartOnPanic("scheduler", wsc.runScheduler)
+	for {
+		select {
+		case <-wsc.scheduler.ready:
+		case <-wsc.done:
+			return
+		}
+		for item := wsc.scheduler.pop(); item != nil; item = wsc.scheduler.pop() {
+			select {
+			case wsc.messageChannel <- item.message:
+				close(item.accepted)
+			case <-item.ctx.Done():
+			case <-wsc.done:
+				return
+			}
+		}
+	}
+}
+
+// enqueue waits until the scheduler has handed message to the sender.
+func (wsc *WSSClient) enqueue(ctx context.Context, message []byte) error {
+	if err := wsc.checkPayloadSize(message); err != nil {
+		return err
+	}
+	item := &scheduledMessage{ctx: ctx, message: message, accepted: make(chan struct{}), dropped: make(chan struct{})}
+	wsc.scheduler.push(PriorityFromContext(ctx), item)
+	select {
+	case <-item.accepted:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-item.dropped:
+		return ErrConnectionClosed
+	case <-wsc.done:
+		return ErrConnectionClosed
+	}
+}
@@ -0,0 +1,130 @@
+package wsclient
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SendPolicy decides what happens to a message when the send queue is full.
+type SendPolicy int
+
+const (
+	// SendBlock waits for room in the queue, up to the send timeout.
+	SendBlock SendPolicy = iota
+	// SendDrop fails the message with ErrSendQueueFull at once.
+	SendDrop
+	// SendExpand queues the message in an unbounded overflow list.
+	SendExpand
+)
+
+// ErrSendQueueFull is returned when a message cannot be queued for sending
+// under the configured SendPolicy.
+var ErrSendQueueFull = errors.New("send queue is full")
+
+// queueMessage hands message to the sender goroutine according to the send
+// policy, giving up when ctx is done.
+func (wsc *WSSClient) queueMessage(ctx context.Context, message []byte) error {
+	if err := wsc.checkPayloadSize(message); err != nil {
+		return err
+	}
+	switch wsc.sendPolicy {
+	case SendDrop:
+		select {
+		case wsc.messageChannel <- message:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			return ErrSendQueueFull
+		}
+	case SendExpand:
+		wsc.expand(message)
+		return nil
+	}
+	var timeout <-chan time.Time
+	if wsc.sendTimeout > 0 {
+		var stop func() bool
+		timeout, stop = wsc.after(wsc.sendTimeout)
+		defer stop()
+	}
+	select {
+	case wsc.messageChannel <- message:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timeout:
+		return ErrSendQueueFull
+	}
+}
+
+// expand queues message, spilling into the overflow list while the channel
+// is full. Overflowed messages are forwarded in order by drainOverflow.
+func (wsc *WSSClient) expand(message []byte) {
+	wsc.overflowMu.Lock()
+	defer wsc.overflowMu.Unlock()
+	if len(wsc.overflow) == 0 {
+		select {
+		case wsc.messageChannel <- message:
+			return
+		default:
+		}
+		wsc.workers.spawn("overflow", wsc.drainOverflow)
+	}
+	wsc.overflow = append(wsc.overflow, message)
+}
+
+func (wsc *WSSClient) drainOverflow() {
+	defer wsc.restartOnPanic("overflow", wsc.drainOverflow)
+	for {
+		wsc.overflowMu.Lock()
+		if len(wsc.overflow) == 0 {
+			wsc.overflowMu.Unlock()
+			return
+		}
+		message, epoch := wsc.overflow[0], wsc.overflowEpoch
+		wsc.overflowMu.Unlock()
+		select {
+		case wsc.messageChannel <- message:
+		case <-wsc.done:
+			return
+		}
+		wsc.overflowMu.Lock()
+		if wsc.overflowEpoch != epoch {
+			// dropQueued emptied the overflow meanwhile.
+			wsc.overflowMu.Unlock()
+			return
+		}
+		wsc.overflow[0] = nil
+		wsc.overflow = wsc.overflow[1:]
+		wsc.overflowMu.Unlock()
+	}
+}
+
+// dropQueued discards the messages waiting to be sent. It runs when the
+// connection is lost: their requests are failed, so sending them on the
+// next connection would run queries nobody waits for.
+func (wsc *WSSClient) dropQueued() {
+	wsc.overflowMu.Lock()
+	clear(wsc.overflow)
+	wsc.overflow = nil
+	wsc.overflowEpoch++
+	wsc.overflowMu.Unlock()
+	for len(wsc.messageChannel) > 0 {
+		select {
+		case <-wsc.messageChannel:
+		default:
+		}
+	}
+	if wsc.scheduler != nil {
+		wsc.scheduler.drop()
+	}
+}
+
+// checkPayloadSize rejects messages larger than the maximum payload size.
+func (wsc *WSSClient) checkPayloadSize(message []byte) error {
+	if wsc.maxPayloadSize > 0 && len(message) > wsc.maxPayloadSize {
+		return &PayloadTooLargeError{Size: len(message), Limit: wsc.maxPayloadSize}
+	}
+	return nil
+}
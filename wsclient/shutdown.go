@@ -0,0 +1,114 @@
+package wsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrShutdownIncomplete is matched by the ShutdownError returned when a
+// shutdown deadline passes before everything stopped.
+var ErrShutdownIncomplete = errors.New("shutdown incomplete")
+
+// ShutdownError lists what was still running when Shutdown gave up waiting.
+// Cause is the context error that ended the wait.
+type ShutdownError struct {
+	Running []string
+	Cause   error
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("Shutdown incomplete, still running: %s: %v", strings.Join(e.Running, ", "), e.Cause)
+}
+
+func (e *ShutdownError) Is(target error) bool {
+	return target == ErrShutdownIncomplete
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Cause
+}
+
+// workers tracks the background goroutines of a client by name, so Shutdown
+// can wait for them and report the ones that did not exit.
+type workers struct {
+	mu      sync.Mutex
+	running map[string]int
+	changed chan struct{}
+}
+
+// spawn runs fn in a goroutine tracked as name.
+func (w *workers) spawn(name string, fn func()) {
+	w.mu.Lock()
+	if w.running == nil {
+		w.running = make(map[string]int)
+	}
+	w.running[name]++
+	w.mu.Unlock()
+	go func() {
+		defer w.exit(name)
+		fn()
+	}()
+}
+
+func (w *workers) exit(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running[name]--; w.running[name] == 0 {
+		delete(w.running, name)
+	}
+	if w.changed != nil {
+		close(w.changed)
+		w.changed = nil
+	}
+}
+
+// wait blocks until no goroutine runs or ctx is done, returning the names
+// of the goroutines still running in the latter case.
+func (w *workers) wait(ctx context.Context) []string {
+	for {
+		w.mu.Lock()
+		if len(w.running) == 0 {
+			w.mu.Unlock()
+			return nil
+		}
+		if w.changed == nil {
+			w.changed = make(chan struct{})
+		}
+		changed := w.changed
+		w.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return w.names()
+		}
+	}
+}
+
+func (w *workers) names() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	names := make([]string, 0, len(w.running))
+	for name, n := range w.running {
+		if n > 1 {
+			name = fmt.Sprintf("%s (%d)", name, n)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shutdown closes the client like Close and waits until its send, receive
+// and other background goroutines have exited. When ctx is done first it
+// returns a ShutdownError naming the goroutines still running.
+func (wsc *WSSClient) Shutdown(ctx context.Context) error {
+	wsc.Close()
+	if running := wsc.workers.wait(ctx); running != nil {
+		return &ShutdownError{Running: running, Cause: ctx.Err()}
+	}
+	return nil
+}
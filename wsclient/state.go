@@ -0,0 +1,52 @@
+package wsclient
+
+// ConnectionState is the phase of a client's connection.
+type ConnectionState int32
+
+const (
+	// Disconnected means there is no connection; the next query connects.
+	Disconnected ConnectionState = iota
+	// Connecting means the connection is being dialed.
+	Connecting
+	// Authenticating means credentials are being obtained and the dial
+	// header signed before connecting.
+	Authenticating
+	// Connected means queries can be sent.
+	Connected
+	// Draining means the client is closing and waits for the queries in
+	// flight to finish; new queries are rejected.
+	Draining
+	// Reconnecting means a connection attempt failed or the connection
+	// dropped, and the client is waiting to try again.
+	Reconnecting
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case Disconnected:
+		return "Disconnected"
+	case Connecting:
+		return "Connecting"
+	case Authenticating:
+		return "Authenticating"
+	case Connected:
+		return "Connected"
+	case Draining:
+		return "Draining"
+	case Reconnecting:
+		return "Reconnecting"
+	default:
+		return "Unknown"
+	}
+}
+
+// State returns the state of the connection: Disconnected, Connecting or
+// Connected. The other states are reported by callers that authenticate,
+// retry and close around the client, such as boilingdata.Instance.
+func (wsc *WSSClient) State() ConnectionState {
+	return ConnectionState(wsc.state.Load())
+}
+
+func (wsc *WSSClient) setState(state ConnectionState) {
+	wsc.state.Store(int32(state))
+}
@@ -0,0 +1,101 @@
+package wsclient
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// statementCache remembers the column order of the results of recently
+// seen statements, so the final frame of a repeated statement does not
+// have to be parsed a second time to extract it.
+type statementCache struct {
+	mu    sync.Mutex
+	size  int
+	keys  map[string][]string
+	order []string
+}
+
+func newStatementCache(size int) *statementCache {
+	return &statementCache{size: size, keys: make(map[string][]string)}
+}
+
+// statementKey normalizes sql so that statements differing only in
+// whitespace share an entry. Whitespace inside quotes is kept, as it makes
+// a different statement.
+func statementKey(sql string) string {
+	var key strings.Builder
+	var quote byte
+	space := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case unicode.IsSpace(rune(c)):
+			space = true
+			continue
+		}
+		if space && key.Len() > 0 {
+			key.WriteByte(' ')
+		}
+		space = false
+		key.WriteByte(c)
+	}
+	return strings.TrimRight(key.String(), "; ")
+}
+
+func (c *statementCache) get(statement string) ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys, ok := c.keys[statement]
+	return keys, ok
+}
+
+// set stores keys for statement, evicting the oldest entry when full.
+func (c *statementCache) set(statement string, keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[statement]; !ok {
+		if len(c.order) >= c.size {
+			delete(c.keys, c.order[0])
+			c.order = c.order[1:]
+		}
+		c.order = append(c.order, statement)
+	}
+	c.keys[statement] = keys
+}
+
+// responseKeys returns the column order of the final frame message of a
+// request. Cached keys are used when they still match the columns of the
+// first row; otherwise they are extracted from message and cached.
+func (wsc *WSSClient) responseKeys(pending *pendingRequest, data []map[string]interface{}, message []byte) []string {
+	if wsc.statements == nil || pending.statement == "" {
+		return extractKeys(wsc.codec, message)
+	}
+	if keys, ok := wsc.statements.get(pending.statement); ok && keysMatch(keys, data) {
+		return keys
+	}
+	keys := extractKeys(wsc.codec, message)
+	if keys != nil {
+		wsc.statements.set(pending.statement, keys)
+	}
+	return keys
+}
+
+// keysMatch reports whether keys are exactly the columns of the first row.
+func keysMatch(keys []string, data []map[string]interface{}) bool {
+	if len(data) == 0 || len(data[0]) != len(keys) {
+		return false
+	}
+	for _, key := range keys {
+		if _, ok := data[0][key]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package wsclient
+
+import "testing"
+
+func TestStatementKey(t *testing.T) {
+	same := [][2]string{
+		{"SELECT a,  b\nFROM t;", "SELECT a, b FROM t"},
+		{"SELECT 'x  y' ", "SELECT 'x  y';"},
+		{`SELECT 1 AS "a  b"`, "\tSELECT 1 AS \"a  b\""},
+	}
+	for _, pair := range same {
+		if statementKey(pair[0]) != statementKey(pair[1]) {
+			t.Errorf("%q and %q have different keys", pair[0], pair[1])
+		}
+	}
+	different := [][2]string{
+		{"SELECT * FROM t WHERE x = 'a  b'", "SELECT * FROM t WHERE x = 'a b'"},
+		{`SELECT 1 AS "a b", 2 AS "a  b"`, `SELECT 1 AS "a  b", 2 AS "a b"`},
+		{"SELECT 'it''s  ok'", "SELECT 'it''s ok'"},
+	}
+	for _, pair := range different {
+		if statementKey(pair[0]) == statementKey(pair[1]) {
+			t.Errorf("%q and %q share the key %q", pair[0], pair[1], statementKey(pair[0]))
+		}
+	}
+}
+
+func TestStatementCacheEvictsOldest(t *testing.T) {
+	cache := newStatementCache(2)
+	cache.set("a", []string{"x"})
+	cache.set("b", []string{"y"})
+	cache.set("a", []string{"z"})
+	cache.set("c", []string{"w"})
+	if _, ok := cache.get("a"); ok {
+		t.Error("oldest statement was kept")
+	}
+	if keys, ok := cache.get("b"); !ok || keys[0] != "y" {
+		t.Errorf("get(b) = %v, %v", keys, ok)
+	}
+}
@@ -0,0 +1,69 @@
+package wsclient
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the traffic and connection counters of a WSSClient.
+type Stats struct {
+	BytesSent      int64
+	BytesReceived  int64
+	FramesSent     int64
+	FramesReceived int64
+	Connects       int64
+	Reconnects     int64
+	InFlight       int
+	ConnectedSince time.Time
+	Uptime         time.Duration
+}
+
+type clientCounters struct {
+	bytesSent      atomic.Int64
+	bytesReceived  atomic.Int64
+	framesSent     atomic.Int64
+	framesReceived atomic.Int64
+	connects       atomic.Int64
+	connectedAt    atomic.Int64
+}
+
+func (c *clientCounters) sent(size int) {
+	c.framesSent.Add(1)
+	c.bytesSent.Add(int64(size))
+}
+
+func (c *clientCounters) received(size int) {
+	c.framesReceived.Add(1)
+	c.bytesReceived.Add(int64(size))
+}
+
+func (c *clientCounters) connected(now time.Time) {
+	c.connects.Add(1)
+	c.connectedAt.Store(now.UnixNano())
+}
+
+func (c *clientCounters) disconnected() {
+	c.connectedAt.Store(0)
+}
+
+// Stats returns the client counters. Uptime and ConnectedSince are zero
+// while disconnected.
+func (wsc *WSSClient) Stats() Stats {
+	c := &wsc.counters
+	stats := Stats{
+		BytesSent:      c.bytesSent.Load(),
+		BytesReceived:  c.bytesReceived.Load(),
+		FramesSent:     c.framesSent.Load(),
+		FramesReceived: c.framesReceived.Load(),
+		Connects:       c.connects.Load(),
+		InFlight:       wsc.requests.count(),
+	}
+	if stats.Connects > 1 {
+		stats.Reconnects = stats.Connects - 1
+	}
+	if connectedAt := c.connectedAt.Load(); connectedAt != 0 {
+		stats.ConnectedSince = time.Unix(0, connectedAt)
+		stats.Uptime = wsc.since(stats.ConnectedSince)
+	}
+	return stats
+}
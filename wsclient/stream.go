@@ -0,0 +1,157 @@
+package wsclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// FrameFunc receives the rows of one DATA frame of a streamed request as raw
+// JSON objects, with the frame's batch counters in header. The rows share
+// the frame buffer and are only valid during the call. Returning an error
+// fails the request.
+type FrameFunc func(header *messages.Response, rows []json.RawMessage) error
+
+// frameHeader is a DATA frame without its rows.
+type frameHeader struct {
+	MessageType     string `json:"messageType"`
+	RequestID       string `json:"requestId"`
+	BatchSerial     int    `json:"batchSerial"`
+	TotalBatches    int    `json:"totalBatches"`
+	CacheInfo       string `json:"cacheInfo"`
+	SubBatchSerial  int    `json:"subBatchSerial"`
+	TotalSubBatches int    `json:"totalSubBatches"`
+	BatchRows       *int   `json:"batchRows"`
+	Checksum        string `json:"checksum"`
+	TotalRows       *int   `json:"totalRows"`
+	ResultURL       string `json:"resultUrl"`
+	ResultSize      int64  `json:"resultSize"`
+}
+
+// response returns the header as a Response without rows.
+func (header *frameHeader) response() *messages.Response {
+	return &messages.Response{
+		MessageType:     header.MessageType,
+		RequestID:       header.RequestID,
+		BatchSerial:     header.BatchSerial,
+		TotalBatches:    header.TotalBatches,
+		CacheInfo:       header.CacheInfo,
+		SubBatchSerial:  header.SubBatchSerial,
+		TotalSubBatches: header.TotalSubBatches,
+		BatchRows:       header.BatchRows,
+		Checksum:        header.Checksum,
+		TotalRows:       header.TotalRows,
+		ResultURL:       header.ResultURL,
+		ResultSize:      header.ResultSize,
+	}
+}
+
+// SendStreamContext sends message like SendMessageContext, but the rows of
+// the response are passed to fn as they arrive instead of being collected.
+// Rows are delivered in arrival order. Wait for the end of the stream with
+// WaitStream.
+func (wsc *WSSClient) SendStreamContext(ctx context.Context, message []byte, payload messages.Payload, fn FrameFunc) error {
+	pending := wsc.register(payload.RequestID, payload.SQL)
+	pending.stream = fn
+	pending.activity = make(chan struct{}, 1)
+	wsc.streams.Add(1)
+	if err := wsc.submit(ctx, message); err != nil {
+		wsc.streams.Add(-1)
+		wsc.release(payload.RequestID, pending)
+		return err
+	}
+	return nil
+}
+
+// WaitStream waits for a request sent with SendStreamContext to complete.
+// The response timeout applies to the gap between frames rather than to
+// the whole stream. The returned response has no rows; when the server
+// wrote the result to S3 instead, its ResultURL is set and the rows are
+// left to the caller to fetch.
+func (wsc *WSSClient) WaitStream(ctx context.Context, requestID string) (*messages.Response, error) {
+	pending, ok := wsc.pending(requestID)
+	if !ok || pending.stream == nil {
+		return &messages.Response{}, fmt.Errorf("unknown stream request ID %q", requestID)
+	}
+	defer func() {
+		wsc.release(requestID, pending)
+		wsc.streams.Add(-1)
+	}()
+	timeout := wsc.clock.NewTimer(wsc.responseTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-pending.done:
+			return pending.result()
+		case <-pending.activity:
+			timeout.Reset(wsc.responseTimeout)
+		case <-ctx.Done():
+			wsc.CancelRequest(requestID, ctx.Err())
+			return nil, ctx.Err()
+		case <-timeout.C():
+			wsc.CancelRequest(requestID, ErrResponseTimeout)
+			return nil, ErrResponseTimeout
+		}
+	}
+}
+
+// handleStreamFrame passes a DATA frame of a streamed request to its
+// FrameFunc. It reports false when the frame does not belong to a stream.
+func (wsc *WSSClient) handleStreamFrame(message []byte) bool {
+	var header frameHeader
+	if err := wsc.codec.Unmarshal(message, &header); err != nil || header.MessageType != messages.DATA.String() {
+		return false
+	}
+	pending, ok := wsc.pending(header.RequestID)
+	if !ok || pending.stream == nil && pending.raw == nil {
+		return false
+	}
+	if pending.raw != nil {
+		wsc.handleRawFrame(pending, header.response(), message)
+		return true
+	}
+	envelope := keysEnvelopePool.Get().(*keysEnvelope)
+	defer func() {
+		clear(envelope.Data)
+		envelope.Data = envelope.Data[:0]
+		keysEnvelopePool.Put(envelope)
+	}()
+	if err := wsc.codec.Unmarshal(message, envelope); err != nil {
+		pending.fail(fmt.Errorf("Error parsing JSON: %w", err))
+		return true
+	}
+	response := header.response()
+	if err := verifyBatch(response, len(envelope.Data), message); err != nil {
+		pending.fail(err)
+		return true
+	}
+	if len(envelope.Data) > 0 {
+		response.Keys = parse(envelope.Data[0])
+	}
+	if pending.subscription {
+		// Subscriptions never complete by batch count; every frame is
+		// passed on.
+		wsc.notifyProgress(response, len(message))
+		if err := pending.stream(response, envelope.Data); err != nil {
+			pending.fail(err)
+		}
+		return true
+	}
+	if !pending.accepts(response.SubBatchSerial) {
+		pending.addStreamed(response, len(envelope.Data))
+		return true
+	}
+	wsc.notifyProgress(response, len(message))
+	if err := pending.stream(response, envelope.Data); err != nil {
+		pending.fail(err)
+		return true
+	}
+	select {
+	case pending.activity <- struct{}{}:
+	default:
+	}
+	pending.addStreamed(response, len(envelope.Data))
+	return true
+}
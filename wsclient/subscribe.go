@@ -0,0 +1,73 @@
+package wsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+	"github.com/boilingdata/go-boilingdata/messages"
+)
+
+// ErrSubscriptionClosed ends a subscription stopped with
+// CancelSubscription.
+var ErrSubscriptionClosed = errors.New("subscription closed")
+
+// ErrSubscribeUnsupported is returned by SubscribeContext when the server
+// has not announced the constants.SubscribeCapability capability.
+var ErrSubscribeUnsupported = errors.New("server does not support subscriptions")
+
+// SubscribeContext sends a standing query. Each DATA frame the server sends
+// for it is passed to fn as it arrives, without tracking batch counts, so
+// the subscription does not complete when a batch does. It lasts until
+// CancelSubscription, until the server fails it or until the connection
+// closes. No response timeout applies while it is idle. Wait for its end
+// with WaitSubscription. It fails with ErrSubscribeUnsupported unless the
+// server announced subscription support in its INFO frame.
+func (wsc *WSSClient) SubscribeContext(ctx context.Context, message []byte, payload messages.Payload, fn FrameFunc) error {
+	if !wsc.ServerInfo().Supports(constants.SubscribeCapability) {
+		return ErrSubscribeUnsupported
+	}
+	pending := wsc.register(payload.RequestID, payload.SQL)
+	pending.stream = fn
+	pending.subscription = true
+	wsc.streams.Add(1)
+	if err := wsc.submit(ctx, message); err != nil {
+		wsc.streams.Add(-1)
+		wsc.release(payload.RequestID, pending)
+		return err
+	}
+	return nil
+}
+
+// WaitSubscription waits for a subscription sent with SubscribeContext to
+// end, returning nil when it was stopped with CancelSubscription. When ctx
+// is done first the subscription is cancelled on the server.
+func (wsc *WSSClient) WaitSubscription(ctx context.Context, requestID string) error {
+	pending, ok := wsc.pending(requestID)
+	if !ok || !pending.subscription {
+		return fmt.Errorf("unknown subscription request ID %q", requestID)
+	}
+	defer func() {
+		wsc.release(requestID, pending)
+		wsc.streams.Add(-1)
+	}()
+	select {
+	case <-pending.done:
+	case <-ctx.Done():
+		wsc.CancelRequest(requestID, ctx.Err())
+		return ctx.Err()
+	}
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+	if errors.Is(pending.err, ErrSubscriptionClosed) {
+		return nil
+	}
+	return pending.err
+}
+
+// CancelSubscription stops the subscription requestID on the server and
+// ends it with ErrSubscriptionClosed.
+func (wsc *WSSClient) CancelSubscription(requestID string) {
+	wsc.CancelRequest(requestID, ErrSubscriptionClosed)
+}
@@ -0,0 +1,117 @@
+package wsclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CloseTryAgainLater is the close code a server sends when it sheds load.
+const CloseTryAgainLater = 1013
+
+// ErrThrottled matches the ThrottledError of requests and connections the
+// server throttled.
+var ErrThrottled = errors.New("throttled by server")
+
+// ThrottledError is returned when the server signals throttling, in a
+// LOG_MESSAGE, a close frame or a 429 handshake response. RetryAfter is the
+// wait the server asked for, or zero when it gave no hint. Cause is the
+// error carrying the signal, e.g. the ServerError or CloseError.
+type ThrottledError struct {
+	RetryAfter time.Duration
+	Cause      error
+}
+
+func (e *ThrottledError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%v, retry after %s: %v", ErrThrottled, e.RetryAfter, e.Cause)
+	}
+	return fmt.Sprintf("%v: %v", ErrThrottled, e.Cause)
+}
+
+func (e *ThrottledError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+func (e *ThrottledError) Unwrap() error {
+	return e.Cause
+}
+
+// IsThrottleMessage reports whether a server message signals throttling.
+func IsThrottleMessage(message string) bool {
+	lower := strings.ToLower(message)
+	return strings.Contains(lower, "throttl") || strings.Contains(lower, "rate exceeded") || strings.Contains(lower, "too many requests")
+}
+
+// retryAfterHint matches hints such as "retry after 5s", "Retry-After: 30"
+// or "retry in 2 seconds".
+var retryAfterHint = regexp.MustCompile(`(?i)retry[\s_-]*(?:after|in)["'\s]*[:=]?\s*(\d+(?:\.\d+)?)\s*(ms|milliseconds?|s|secs?|seconds?|m|mins?|minutes?)?\b`)
+
+// ParseRetryAfter returns the wait hinted at in a server message, in
+// seconds unless a unit is given, or zero when there is none.
+func ParseRetryAfter(message string) time.Duration {
+	match := retryAfterHint.FindStringSubmatch(message)
+	if match == nil {
+		return 0
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0
+	}
+	unit := time.Second
+	switch strings.ToLower(match[2]) {
+	case "ms", "millisecond", "milliseconds":
+		unit = time.Millisecond
+	case "m", "min", "mins", "minute", "minutes":
+		unit = time.Minute
+	}
+	return time.Duration(value * float64(unit))
+}
+
+// retryAfterHeader parses a Retry-After header of delay seconds or an HTTP
+// date.
+func retryAfterHeader(value string) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+// throttled returns err as a ThrottledError when it signals throttling:
+// a close frame with code 1013 or a throttling reason, or a 429 handshake
+// response.
+func throttled(err error, response *http.Response) error {
+	var closeErr *CloseError
+	switch {
+	case response != nil && response.StatusCode == http.StatusTooManyRequests:
+		return &ThrottledError{RetryAfter: retryAfterHeader(response.Header.Get("Retry-After")), Cause: err}
+	case errors.As(err, &closeErr) && (closeErr.Code == CloseTryAgainLater || IsThrottleMessage(closeErr.Reason)):
+		return &ThrottledError{RetryAfter: ParseRetryAfter(closeErr.Reason), Cause: err}
+	}
+	return err
+}
+
+// ThrottledUntil returns until when the server asked the client not to
+// reconnect, or the zero time.
+func (wsc *WSSClient) ThrottledUntil() time.Time {
+	until := wsc.throttledUntil.Load()
+	if until == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, until)
+}
+
+// noteThrottle records the RetryAfter of a throttled connection error.
+func (wsc *WSSClient) noteThrottle(err error) {
+	var throttle *ThrottledError
+	if errors.As(err, &throttle) && throttle.RetryAfter > 0 {
+		wsc.throttledUntil.Store(wsc.clock.Now().Add(throttle.RetryAfter).UnixNano())
+	}
+}
@@ -0,0 +1,128 @@
+package wsclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// TimeoutPhase is the part of a query whose time budget ran out.
+type TimeoutPhase int
+
+const (
+	// PhaseAuth is signing in and signing the dial header.
+	PhaseAuth TimeoutPhase = iota
+	// PhaseDial is the TCP, TLS and websocket handshake.
+	PhaseDial
+	// PhaseWrite is writing a message to the connection.
+	PhaseWrite
+	// PhaseFirstByte is waiting for the first frame of a response.
+	PhaseFirstByte
+	// PhaseTotal is waiting for the complete response.
+	PhaseTotal
+)
+
+func (phase TimeoutPhase) String() string {
+	switch phase {
+	case PhaseAuth:
+		return "authentication"
+	case PhaseDial:
+		return "dial"
+	case PhaseWrite:
+		return "write"
+	case PhaseFirstByte:
+		return "first byte"
+	case PhaseTotal:
+		return "query"
+	}
+	return fmt.Sprintf("TimeoutPhase(%d)", int(phase))
+}
+
+// Errors matched by the TimeoutError of each phase. The first byte and
+// query timeouts also match ErrResponseTimeout.
+var (
+	ErrAuthTimeout      = errors.New("authentication timed out")
+	ErrDialTimeout      = errors.New("dial timed out")
+	ErrWriteTimeout     = errors.New("write timed out")
+	ErrFirstByteTimeout = errors.New("no response frame within the first byte timeout")
+	ErrQueryTimeout     = errors.New("query timed out")
+)
+
+// TimeoutError is returned when the budget of a phase runs out. Cause is the
+// underlying error, when there is one.
+type TimeoutError struct {
+	Phase  TimeoutPhase
+	Budget time.Duration
+	Cause  error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s timeout after %s: %v", e.Phase, e.Budget, e.Cause)
+	}
+	return fmt.Sprintf("%s timeout after %s", e.Phase, e.Budget)
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	switch e.Phase {
+	case PhaseAuth:
+		return target == ErrAuthTimeout
+	case PhaseDial:
+		return target == ErrDialTimeout
+	case PhaseWrite:
+		return target == ErrWriteTimeout
+	case PhaseFirstByte:
+		return target == ErrFirstByteTimeout || target == ErrResponseTimeout
+	case PhaseTotal:
+		return target == ErrQueryTimeout || target == ErrResponseTimeout
+	}
+	return false
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Cause
+}
+
+// WithDialTimeout bounds the TCP, TLS and websocket handshake of a dial,
+// which then fails with a TimeoutError of PhaseDial.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(wsc *WSSClient) {
+		if timeout > 0 {
+			wsc.DialOpts.HandshakeTimeout = timeout
+		}
+	}
+}
+
+// WithFirstByteTimeout fails a request that gets no frame of its response
+// within timeout with a TimeoutError of PhaseFirstByte, well before the
+// response timeout bounding the whole query, e.g. to give up early on a
+// server that never started the query.
+func WithFirstByteTimeout(timeout time.Duration) Option {
+	return func(wsc *WSSClient) {
+		wsc.firstByteTimeout = timeout
+	}
+}
+
+// isTimeout reports whether err is a network or context timeout.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout() || errors.Is(err, context.DeadlineExceeded)
+}
+
+// dialTimeout classifies a dial error exceeding the handshake timeout.
+func (wsc *WSSClient) dialTimeout(err error) error {
+	if budget := wsc.DialOpts.HandshakeTimeout; budget > 0 && isTimeout(err) {
+		return &TimeoutError{Phase: PhaseDial, Budget: budget, Cause: err}
+	}
+	return err
+}
+
+// writeTimeoutError classifies a send error exceeding the write deadline.
+func (wsc *WSSClient) writeTimeoutError(err error) error {
+	if wsc.writeTimeout > 0 && isTimeout(err) {
+		return &TimeoutError{Phase: PhaseWrite, Budget: wsc.writeTimeout, Cause: err}
+	}
+	return err
+}
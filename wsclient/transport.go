@@ -0,0 +1,113 @@
+package wsclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Transport carries messages between a WSSClient and the server. The client
+// calls Dial to open a connection and Close when it is done with it; Dial
+// may be called again after Close to reconnect. Send is never called
+// concurrently, nor is Receive.
+//
+// The default transport uses gorilla/websocket. Other implementations, such
+// as in-memory transports for tests, are set with WithTransport.
+type Transport interface {
+	Dial(url string, header http.Header) error
+	Send(message []byte) error
+	// Receive returns a reader for the next message, which is read to EOF
+	// before Receive is called again.
+	Receive() (io.Reader, error)
+	Close() error
+}
+
+// webSocketTransport is the gorilla/websocket Transport. It applies the
+// dialer, read limit, keep-alive and deadline settings of its client.
+type webSocketTransport struct {
+	wsc  *WSSClient
+	conn *websocket.Conn
+	stop chan struct{}
+	once sync.Once
+}
+
+func newWebSocketTransport(wsc *WSSClient) *webSocketTransport {
+	return &webSocketTransport{wsc: wsc}
+}
+
+func (t *webSocketTransport) Dial(url string, header http.Header) error {
+	conn, response, err := t.wsc.DialOpts.Dial(url, header)
+	if err != nil {
+		return throttled(err, response)
+	}
+	if t.wsc.maxMessageSize > 0 {
+		conn.SetReadLimit(t.wsc.maxMessageSize)
+	}
+	conn.SetCloseHandler(closeHandler(conn))
+	t.conn = conn
+	t.stop = make(chan struct{})
+	t.once = sync.Once{}
+	t.wsc.startKeepAlive(conn, t.stop)
+	return nil
+}
+
+func (t *webSocketTransport) Send(message []byte) error {
+	if t.wsc.writeTimeout > 0 {
+		t.conn.SetWriteDeadline(time.Now().Add(t.wsc.writeTimeout))
+	}
+	if t.wsc.compressThreshold > 0 {
+		// Only takes effect when the server accepted permessage-deflate.
+		t.conn.EnableWriteCompression(len(message) >= t.wsc.compressThreshold)
+	}
+	return t.conn.WriteMessage(websocket.TextMessage, message)
+}
+
+func (t *webSocketTransport) Receive() (io.Reader, error) {
+	_, reader, err := t.conn.NextReader()
+	if err != nil {
+		return nil, closeError(err)
+	}
+	t.wsc.extendReadDeadline(t.conn)
+	return readLimitReader{reader: reader, limit: t.wsc.maxMessageSize}, nil
+}
+
+func (t *webSocketTransport) Close() error {
+	t.once.Do(func() { close(t.stop) })
+	return t.conn.Close()
+}
+
+// websocketConn returns the gorilla connection of transport, for the
+// deprecated WSSClient.Conn field, or nil for other transports.
+func websocketConn(transport Transport) *websocket.Conn {
+	switch t := transport.(type) {
+	case *webSocketTransport:
+		return t.conn
+	case *recordingTransport:
+		return websocketConn(t.transport)
+	}
+	return nil
+}
+
+// Subprotocol returns the negotiated subprotocol.
+func (t *webSocketTransport) Subprotocol() string {
+	return t.conn.Subprotocol()
+}
+
+// readLimitReader reports websocket.ErrReadLimit as ErrMessageTooLarge.
+type readLimitReader struct {
+	reader io.Reader
+	limit  int64
+}
+
+func (r readLimitReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if errors.Is(err, websocket.ErrReadLimit) {
+		err = fmt.Errorf("%w (limit %d bytes)", ErrMessageTooLarge, r.limit)
+	}
+	return n, err
+}
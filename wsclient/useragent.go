@@ -0,0 +1,45 @@
+package wsclient
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/boilingdata/go-boilingdata/constants"
+)
+
+const modulePath = "github.com/boilingdata/go-boilingdata"
+
+// UserAgent identifies this library to the server, e.g.
+// "go-boilingdata/v0.1.0 (go1.22.1; linux/amd64)". The version is read from
+// the build information of the binary.
+var UserAgent = sync.OnceValue(func() string {
+	return constants.ClientName + "/" + moduleVersion() + " (" + runtime.Version() + "; " + runtime.GOOS + "/" + runtime.GOARCH + ")"
+})
+
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// SetClientHeaders sets the User-Agent and x-bd-client headers to
+// userAgent, or to UserAgent() when it is empty.
+func SetClientHeaders(header http.Header, userAgent string) {
+	if userAgent == "" {
+		userAgent = UserAgent()
+	}
+	header.Set("User-Agent", userAgent)
+	header.Set(constants.ClientHeader, userAgent)
+}
@@ -2,134 +2,397 @@ package wsclient
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/boilingdata/go-boilingdata/constants"
 	"github.com/boilingdata/go-boilingdata/messages"
 	"github.com/gorilla/websocket"
-	cmap "github.com/orcaman/concurrent-map"
 )
 
 // WSSClient represents the WebSocket client.
 type WSSClient struct {
-	URL                string
-	Conn               *websocket.Conn
-	DialOpts           *websocket.Dialer
-	idleTimeoutMinutes time.Duration
-	idleTimer          *time.Timer
-	Wg                 sync.WaitGroup
-	ConnInit           sync.WaitGroup
-	SignedHeader       http.Header
-	Error              string
-	mu                 sync.Mutex
-	messageChannel     chan []byte
-	stopChannel        chan []byte
-	resultsMap         cmap.ConcurrentMap
-	interrupt          chan os.Signal
+	URL      string
+	DialOpts *websocket.Dialer
+	// Conn is the current websocket connection, or nil while disconnected
+	// or with a transport other than the default one.
+	//
+	// Deprecated: the connection is owned by the client's Transport; use
+	// the WSSClient methods instead of writing to Conn directly.
+	Conn                 *websocket.Conn
+	transport            Transport
+	state                atomic.Int32
+	idleTimeout          time.Duration
+	idleTimer            Timer
+	jitter               float64
+	maxPayloadSize       int
+	compressThreshold    int
+	clock                Clock
+	Wg                   sync.WaitGroup
+	ConnInit             sync.WaitGroup
+	dialMu               sync.Mutex
+	dialing              *dialCall
+	SignedHeader         http.Header
+	Error                string
+	lastErr              error
+	errMu                sync.Mutex
+	mu                   sync.Mutex
+	messageChannel       chan []byte
+	stopChannel          chan []byte
+	requests             *registry[*pendingRequest]
+	progressListeners    *registry[*progressTracker]
+	codec                Codec
+	pingInterval         time.Duration
+	pongWait             time.Duration
+	maxMessageSize       int64
+	readTimeout          time.Duration
+	writeTimeout         time.Duration
+	keepWarmInterval     time.Duration
+	responseTimeout      time.Duration
+	lastActivity         atomic.Int64
+	idleDeadline         atomic.Int64
+	keepOpenWhilePending bool
+	partialOnTimeout     bool
+	firstByteTimeout     time.Duration
+	throttledUntil       atomic.Int64
+	decompressors        map[string]Decompressor
+	counters             clientCounters
+	scheduler            *scheduler
+	interrupt            chan os.Signal
+	done                 chan struct{}
+	closeOnce            sync.Once
+	connListener         ConnectionListener
+	sendQueueSize        int
+	sendPolicy           SendPolicy
+	sendTimeout          time.Duration
+	overflowMu           sync.Mutex
+	overflow             [][]byte
+	overflowEpoch        int
+	streams              atomic.Int32
+	projections          atomic.Int32
+	duplicatePolicy      DuplicatePolicy
+	serverInfo           ServerInfo
+	userAgent            string
+	extraHeader          http.Header
+	redactor             Redactor
+	newTransport         func() Transport
+	recorder             *cassetteWriter
+	headerSigner         HeaderSigner
+	connectionTTL        time.Duration
+	rotationTimer        Timer
+	draining             Transport
+	drainIDs             []string
+	rotationListener     func()
+	statements           *statementCache
+	workers              workers
+	messageTypes         messageTypes
+}
+
+// ConnectionListener is called after the client connects, with connected
+// true, and after the connection closes, with the error that closed it or
+// nil when it was closed deliberately, e.g. on idle timeout.
+type ConnectionListener func(connected bool, err error)
+
+// SetConnectionListener sets the listener notified of connects and
+// disconnects.
+func (wsc *WSSClient) SetConnectionListener(listener ConnectionListener) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	wsc.connListener = listener
+}
+
+func (listener ConnectionListener) notify(connected bool, err error) {
+	if listener != nil {
+		listener(connected, err)
+	}
 }
 
 // NewWSSClient creates a new instance of WSSClient.
-// Either fully signed url needs to be provided OR signedHeader
-func NewWSSClient(url string, idleTimeoutMinutes time.Duration, signedHeader http.Header) *WSSClient {
+// Either fully signed url needs to be provided OR signedHeader.
+// idleTimeoutMinutes is the idle timeout in whole minutes, kept for existing
+// callers; 0 selects constants.IdleTimeout. New code passes 0 and sets the
+// timeout as a duration with WithIdleTimeout.
+func NewWSSClient(url string, idleTimeoutMinutes time.Duration, signedHeader http.Header, opts ...Option) *WSSClient {
 	if signedHeader == nil {
 		signedHeader = make(http.Header)
 	}
+	dialer := *websocket.DefaultDialer
 	wsc := &WSSClient{
-		URL:                url,
-		DialOpts:           &websocket.Dialer{},
-		idleTimeoutMinutes: idleTimeoutMinutes,
-		SignedHeader:       signedHeader,
-		messageChannel:     make(chan []byte),
-		stopChannel:        make(chan []byte),
-		resultsMap:         cmap.New(),
-		interrupt:          make(chan os.Signal, 1),
+		URL:               url,
+		DialOpts:          &dialer,
+		idleTimeout:       constants.IdleTimeout,
+		clock:             SystemClock,
+		SignedHeader:      signedHeader,
+		stopChannel:       make(chan []byte),
+		requests:          newRegistry[*pendingRequest](),
+		progressListeners: newRegistry[*progressTracker](),
+		codec:             DefaultCodec,
+		pingInterval:      constants.PingInterval,
+		pongWait:          constants.PongWait,
+		writeTimeout:      constants.WriteTimeout,
+		responseTimeout:   constants.TimeOutWaintForResponse,
+		sendQueueSize:     constants.SendQueueSize,
+		sendTimeout:       constants.SendTimeout,
+		maxPayloadSize:    constants.MaxPayloadSize,
+		connectionTTL:     constants.ConnectionTTL,
+		interrupt:         make(chan os.Signal, 1),
+		done:              make(chan struct{}),
+	}
+	if idleTimeoutMinutes > 0 {
+		wsc.idleTimeout = idleTimeoutMinutes * time.Minute
+	}
+	for _, opt := range opts {
+		opt(wsc)
+	}
+	if wsc.transport == nil {
+		wsc.newTransport = func() Transport { return newWebSocketTransport(wsc) }
+		wsc.transport = wsc.newTransport()
+	}
+	if wsc.recorder != nil {
+		wsc.record()
+	}
+	if replay, ok := wsc.transport.(*ReplayTransport); ok && replay.Redactor == nil {
+		replay.Redactor = wsc.redactor
+	}
+	if wsc.scheduler != nil {
+		// Hand messages to the sender one at a time, so each is picked by
+		// priority only once the sender is ready for it rather than
+		// waiting in FIFO order in a buffer.
+		wsc.messageChannel = make(chan []byte)
+		wsc.workers.spawn("scheduler", wsc.runScheduler)
+	} else {
+		wsc.messageChannel = make(chan []byte, wsc.sendQueueSize)
 	}
 	wsc.resetIdleTimer()
 	wsc.osInterrupt()
 	return wsc
 }
 
+// SetSignedHeader replaces the signed header used for the next dial. An open
+// connection is kept: the signature is only checked when connecting, so
+// re-signing ahead of its expiry never interrupts in-flight queries. It
+// waits for a connect in progress to finish.
+func (wsc *WSSClient) SetSignedHeader(header http.Header) {
+	wsc.mu.Lock()
+	defer wsc.mu.Unlock()
+	wsc.SignedHeader = header
+}
+
+// Connect opens the connection unless it is already open. A failure is
+// recorded in Error and LastError; ConnectE returns it instead.
 func (wsc *WSSClient) Connect() {
+	wsc.ConnectE()
+}
+
+// ConnectE is like Connect but returns the dial failure, wrapping its cause,
+// to the caller. It returns nil when the connection is already open.
+// Concurrent callers share a single dial attempt and its outcome.
+func (wsc *WSSClient) ConnectE() error {
+	wsc.dialMu.Lock()
+	if call := wsc.dialing; call != nil {
+		wsc.dialMu.Unlock()
+		<-call.done
+		return call.err
+	}
+	if !wsc.IsWebSocketClosed() {
+		wsc.dialMu.Unlock()
+		return nil
+	}
+	call := &dialCall{done: make(chan struct{})}
+	wsc.dialing = call
+	wsc.dialMu.Unlock()
+
+	call.err = wsc.dial()
+	wsc.dialMu.Lock()
+	wsc.dialing = nil
+	wsc.dialMu.Unlock()
+	close(call.done)
+	return call.err
+}
+
+// dialCall is a dial attempt shared by the callers of ConnectE.
+type dialCall struct {
+	done chan struct{}
+	err  error
+}
+
+// dial makes a single attempt to open the connection.
+func (wsc *WSSClient) dial() error {
 	wsc.mu.Lock()
 	defer wsc.mu.Unlock()
-	if wsc.IsWebSocketClosed() {
-		log.Println("Connecting to web socket..")
-		wsc.ConnInit.Add(1)
-		go wsc.connect()
-		wsc.ConnInit.Wait()
-		if !wsc.IsWebSocketClosed() {
-			log.Println("Websocket Connected!")
-		}
+	if !wsc.IsWebSocketClosed() {
+		return nil
 	}
+	log.Println("Connecting to web socket..")
+	wsc.setState(Connecting)
+	wsc.ConnInit.Add(1)
+	if err := wsc.connect(); err != nil {
+		return err
+	}
+	log.Println("Websocket Connected!")
+	return nil
 }
 
-func (wsc *WSSClient) connect() {
+func (wsc *WSSClient) connect() error {
 	// Connect to WebSocket server
-	conn, _, err := websocket.DefaultDialer.Dial(wsc.URL, wsc.SignedHeader)
+	err := wsc.transport.Dial(wsc.URL, wsc.dialHeader())
 	if err != nil {
-		wsc.Error = err.Error()
-		log.Println("dial:", err)
+		err = fmt.Errorf("dial: %w", wsc.dialTimeout(err))
+		wsc.setError(err)
+		log.Println(err)
+		wsc.setState(Disconnected)
 		wsc.ConnInit.Done()
-		return
+		return err
 	}
-	wsc.Conn = conn // Assign the connection to the Conn field
-	wsc.stopChannel = make(chan []byte)
-	go wsc.sendMessageAsync()
-	go wsc.receiveMessageAsync()
+	wsc.Conn = websocketConn(wsc.transport)
+	wsc.setState(Connected)
+	wsc.errMu.Lock()
+	wsc.lastErr = nil
+	wsc.errMu.Unlock()
+	wsc.counters.connected(wsc.clock.Now())
+	stop := make(chan []byte)
+	wsc.stopChannel = stop
+	wsc.touch()
+	if !wsc.isClosed() {
+		wsc.idleTimer.Reset(wsc.nextIdleDelay())
+	}
+	wsc.startKeepWarm(stop)
+	wsc.scheduleRotation()
+	transport := wsc.transport
+	wsc.workers.spawn("send", func() { wsc.sendMessageAsync(stop) })
+	wsc.workers.spawn("receive", func() { wsc.receiveMessageAsync(transport, stop) })
 	wsc.ConnInit.Done()
+	wsc.connListener.notify(true, nil)
+	return nil
 }
 
-// SendMessage sends a message over the WebSocket connection.
+// SendMessage sends a message over the WebSocket connection. It is safe for
+// concurrent use; each request ID is tracked and answered independently.
+// When the message cannot be queued the request fails with the reason,
+// which GetResponseSync returns.
 func (wsc *WSSClient) SendMessage(message []byte, payload messages.Payload) {
-	wsc.resultsMap.Set("error", nil)
-	wsc.resultsMap.Set(payload.RequestID, nil)
-	wsc.messageChannel <- message
+	pending := wsc.registerStatement(payload.RequestID, payload.SQL)
+	if err := wsc.submit(context.Background(), message); err != nil {
+		pending.fail(err)
+	}
 }
 
 // Close closes the WebSocket connection. perform clean up
 func (wsc *WSSClient) shutdown() {
 	wsc.mu.Lock()
-	defer wsc.mu.Unlock()
-	wsc.resultsMap.Clear()
+	if wsc.idleTimer != nil {
+		wsc.idleTimer.Stop()
+	}
+	wsc.failAll(ErrConnectionClosed)
 	if wsc.stopChannel != nil {
 		close(wsc.stopChannel)
 		wsc.stopChannel = nil
 	}
-	if wsc.Conn != nil {
-		wsc.Conn.Close()
-		wsc.Conn = nil
-		log.Println("Websocket connnection closed")
+	if wsc.rotationTimer != nil {
+		wsc.rotationTimer.Stop()
+		wsc.rotationTimer = nil
+	}
+	if wsc.draining != nil {
+		wsc.draining.Close()
+		wsc.draining, wsc.drainIDs = nil, nil
+	}
+	if wsc.State() != Connected {
+		wsc.mu.Unlock()
+		return
 	}
+	wsc.transport.Close()
+	wsc.Conn = nil
+	wsc.setState(Disconnected)
+	wsc.counters.disconnected()
+	log.Println("Websocket connnection closed")
+	listener, reason := wsc.connListener, wsc.LastError()
+	wsc.mu.Unlock()
+	listener.notify(false, reason)
 }
 
-func (wsc *WSSClient) IsWebSocketClosed() bool {
-	return wsc.Conn == nil
+// Close closes the connection and stops the idle timer and interrupt
+// handler. The client must not be used afterwards.
+func (wsc *WSSClient) Close() {
+	wsc.closeOnce.Do(func() {
+		signal.Stop(wsc.interrupt)
+		close(wsc.done)
+		wsc.mu.Lock()
+		if wsc.idleTimer != nil {
+			wsc.idleTimer.Stop()
+		}
+		wsc.mu.Unlock()
+		wsc.shutdown()
+	})
 }
 
-// resetIdleTimer resets the idle timer.
-func (wsc *WSSClient) resetIdleTimer() {
-	if wsc.idleTimeoutMinutes <= 0 {
-		wsc.idleTimeoutMinutes = constants.IdleTimeoutMinutes
-	} else {
-		wsc.idleTimeoutMinutes = wsc.idleTimeoutMinutes * time.Minute
+func (wsc *WSSClient) isClosed() bool {
+	select {
+	case <-wsc.done:
+		return true
+	default:
+		return false
 	}
-	// Stop the existing timer if it exists
-	if wsc.idleTimer != nil {
-		wsc.idleTimer.Stop()
+}
+
+// LastError returns the most recent connection error. Error holds its message.
+func (wsc *WSSClient) LastError() error {
+	wsc.errMu.Lock()
+	defer wsc.errMu.Unlock()
+	return wsc.lastErr
+}
+
+func (wsc *WSSClient) setError(err error) {
+	wsc.noteThrottle(err)
+	wsc.errMu.Lock()
+	defer wsc.errMu.Unlock()
+	wsc.lastErr = err
+	wsc.Error = err.Error()
+}
+
+// IsWebSocketClosed reports whether the client is not Connected. State
+// tells the phases apart.
+func (wsc *WSSClient) IsWebSocketClosed() bool {
+	return wsc.State() != Connected
+}
+
+// Subprotocol returns the subprotocol negotiated with the server, or an empty
+// string when not connected or none was agreed.
+func (wsc *WSSClient) Subprotocol() string {
+	transport, ok := wsc.transport.(interface{ Subprotocol() string })
+	if !ok || wsc.IsWebSocketClosed() {
+		return ""
 	}
-	wsc.idleTimer = time.AfterFunc(wsc.idleTimeoutMinutes, func() {
+	return transport.Subprotocol()
+}
+
+// resetIdleTimer creates the idle timer, which closes the connection once
+// no message has been sent or received for the idle timeout, with jitter if
+// configured. With WithKeepOpenWhilePending it waits for pending requests.
+// The timer is stopped until the client connects and again when the
+// connection closes.
+func (wsc *WSSClient) resetIdleTimer() {
+	wsc.idleTimer = wsc.clock.AfterFunc(wsc.idleTimeout, func() {
+		if wsc.isClosed() || wsc.State() != Connected {
+			return
+		}
+		if wsc.keepOpenWhilePending && wsc.requests.count() > 0 {
+			wsc.idleTimer.Reset(wsc.nextIdleDelay())
+			return
+		}
 		log.Println("Idle timeout reached, closing connection")
 		wsc.shutdown()
-		wsc.resetIdleTimer()
 	})
+	wsc.idleTimer.Stop()
 }
 
 func (wsc *WSSClient) osInterrupt() {
@@ -145,14 +408,17 @@ func (wsc *WSSClient) osInterrupt() {
 				wsc.shutdown()
 				wsc.osInterrupt()
 				return
+			case <-wsc.done:
+				return
 			}
 		}
 	}()
 }
 
 // Async function to send message through channel
-func (wsc *WSSClient) sendMessageAsync() {
+func (wsc *WSSClient) sendMessageAsync(stop <-chan []byte) {
 	defer wsc.shutdown()
+	defer wsc.recoverPanic("send")
 	for {
 		select {
 		// Read message from the query message channel
@@ -160,94 +426,170 @@ func (wsc *WSSClient) sendMessageAsync() {
 			if !ok {
 				return
 			} else {
-				if wsc.Conn == nil {
-					log.Println(fmt.Errorf("Could not send message to websocket -> Not connected to WebSocket server"))
-					wsc.Error = "Could not send message to websocket -> Not connected to WebSocket server"
-					wsc.resultsMap.Set("error", fmt.Errorf("Could not send message to websocket -> "+"Not connected to WebSocket server"))
+				if wsc.IsWebSocketClosed() {
+					err := fmt.Errorf("Could not send message to websocket -> %w", ErrNotConnected)
+					log.Println(err)
+					wsc.setError(err)
+					wsc.failAll(err)
 					return
 				}
-				wsc.idleTimer.Reset(constants.IdleTimeoutMinutes)
+				wsc.markActive()
 				wsc.mu.Lock()
-				err := wsc.Conn.WriteMessage(websocket.TextMessage, message)
+				err := wsc.transport.Send(message)
 				wsc.mu.Unlock()
+				if err == nil {
+					wsc.counters.sent(len(message))
+				}
 				if err != nil {
-					log.Println(fmt.Errorf("Could not send message to websocket: %s", err.Error()))
-					wsc.resultsMap.Set("error", fmt.Errorf("Could not send message to websocket: %s", err.Error()))
+					err = fmt.Errorf("Could not send message to websocket: %w", wsc.writeTimeoutError(err))
+					log.Println(err)
+					wsc.setError(err)
+					wsc.failAll(err)
 					return
 				}
 			}
-		case <-wsc.stopChannel:
+		case <-stop:
 			log.Println("SendMessageAsync process interrupted. No messages will be sent to websocket now onwards.  Action : Reconnect websocket")
 			return
 		}
 	}
 }
 
-// Async function to receive message through channel
-func (wsc *WSSClient) receiveMessageAsync() {
-	defer wsc.shutdown()
+// Async function to receive message through channel. A transport retired by
+// rotate stops receiving without shutting down the client.
+func (wsc *WSSClient) receiveMessageAsync(transport Transport, stop <-chan []byte) {
+	defer func() {
+		if wsc.isCurrent(transport) {
+			wsc.shutdown()
+		}
+	}()
+	defer wsc.recoverPanic("receive")
 	for {
 		select {
-		case <-wsc.stopChannel:
+		case <-stop:
 			log.Println("ReceiveMessageAsync process intrrupted. No message will be consumed further. Action : Reconnect websocket")
 			return
 		default:
-			if wsc.Conn == nil {
+			if wsc.IsWebSocketClosed() {
 				log.Println("Could not receive message from websocket -> Not connected to WebSocket server")
-				wsc.Error = "Could not receive message from websocket -> Not connected to WebSocket server"
-				wsc.resultsMap.Set("error", fmt.Errorf("Could not recieve message from websocket -> "+"Not connected to WebSocket server"))
+				err := fmt.Errorf("Could not receive message from websocket -> %w", ErrNotConnected)
+				wsc.setError(err)
+				wsc.failAll(err)
+				return
+			}
+			frame, err := readFrame(transport)
+			if err != nil && !wsc.isCurrent(transport) {
+				wsc.retire(transport, err)
 				return
 			}
-			_, message, err := wsc.Conn.ReadMessage()
 			if err != nil {
-				log.Println(fmt.Errorf("Could not read message from websocket -> ", err.Error()))
-				wsc.resultsMap.Set("error", fmt.Errorf("Could not read message from websocket -> ", err.Error()))
+				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+					log.Println("No message or pong from server within", wsc.readWait(), "closing dead connection")
+				}
+				if !errors.Is(err, ErrClosedByServer) {
+					err = fmt.Errorf("Could not read message from websocket -> %w", err)
+				}
+				log.Println(err)
+				wsc.setError(err)
+				wsc.failAll(err)
 				return
-			} else if message != nil {
-				var response *messages.Response
-				err = json.Unmarshal([]byte(message), &response)
-				if err != nil {
-					log.Println("Error parsing JSON:", err.Error())
-					wsc.resultsMap.Set(response.RequestID, fmt.Errorf("Error parsing JSON: "+err.Error()))
+			}
+			wsc.counters.received(frame.Len())
+			wsc.markActive()
+			if frame, ok := wsc.inflateOrDrop(frame); ok {
+				wsc.handleMessage(wsc.frameBytes(frame))
+				releaseFrame(frame)
+			}
+		}
+	}
+}
+
+// handleMessage decodes a frame and records it against its request ID. The
+// frame buffer is reused once it returns, so nothing may retain message.
+func (wsc *WSSClient) handleMessage(message []byte) {
+	if wsc.streams.Load() > 0 && wsc.handleStreamFrame(message) {
+		return
+	}
+	if wsc.projections.Load() > 0 && wsc.handleProjectedFrame(message) {
+		return
+	}
+	var response *messages.Response
+	err := wsc.codec.Unmarshal(message, &response)
+	if err != nil && !wsc.messageTypes.empty() && wsc.handleRegisteredFrame(message) {
+		// A registered message type whose fields do not fit Response.
+		return
+	}
+	if err != nil {
+		log.Println("Error parsing JSON:", err.Error())
+		if wsc.protocolMismatch() {
+			err = fmt.Errorf("%w (server protocol version %s, client %s)", err, wsc.ServerProtocolVersion(), constants.ProtocolVersion)
+		}
+		if response != nil {
+			wsc.failRequest(response.RequestID, fmt.Errorf("Error parsing JSON: %w", err))
+		}
+		return
+	}
+	if response == nil {
+		return
+	}
+	if !wsc.messageTypes.empty() && wsc.dispatchMessageType(response.MessageType, response.RequestID, message) {
+		return
+	}
+	if isInfo(response) {
+		wsc.handleInfo(message)
+		return
+	}
+	if messages.LOG_MESSAGE.String() == response.MessageType {
+		var logMessage *messages.LogMessage
+		err = wsc.codec.Unmarshal(message, &logMessage)
+		if err != nil {
+			log.Println("Error parsing JSON:", err.Error())
+			wsc.failRequest(response.RequestID, fmt.Errorf("Error parsing JSON: %w", err))
+		} else {
+			text := wsc.redactor.Redact(logMessage.LogMessage)
+			log.Println("Log message from server :", text)
+			if logMessage.LogLevel == "ERROR" {
+				var err error = &ServerError{
+					RequestID: logMessage.RequestID,
+					LogLevel:  logMessage.LogLevel,
+					Message:   text,
 				}
-				if messages.LOG_MESSAGE.String() == response.MessageType {
-					var logMessage *messages.LogMessage
-					err = json.Unmarshal([]byte(message), &logMessage)
-					if err != nil {
-						log.Println("Error parsing JSON:", err.Error())
-						wsc.resultsMap.Set(response.RequestID, fmt.Errorf("Error parsing JSON: "+err.Error()))
-					} else {
-						log.Println("Log message from server :", logMessage.LogMessage)
-						if logMessage.LogLevel == "ERROR" {
-							wsc.resultsMap.Set(response.RequestID, fmt.Errorf("Log message from server: "+logMessage.LogMessage))
-						}
-					}
-				} else if messages.DATA.String() == response.MessageType {
-					v, _ := wsc.resultsMap.Get(response.RequestID)
-					if _, ok := v.(cmap.ConcurrentMap); !ok {
-						var responses = cmap.New()
-						wsc.resultsMap.Set(response.RequestID, responses)
-						v, _ = wsc.resultsMap.Get(response.RequestID)
-					}
-					if response.TotalSubBatches == 0 || response.TotalSubBatches == response.SubBatchSerial {
-						response.Keys = extractKeys(message)
-					}
-					v.(cmap.ConcurrentMap).Set(string(response.SubBatchSerial), response)
+				if IsThrottleMessage(logMessage.LogMessage) {
+					err = &ThrottledError{RetryAfter: ParseRetryAfter(logMessage.LogMessage), Cause: err}
 				}
+				wsc.failRequest(response.RequestID, err)
 			}
 		}
+	} else if messages.DATA.String() == response.MessageType {
+		pending, ok := wsc.pending(response.RequestID)
+		if !ok {
+			log.Println("Dropping DATA for unknown request:", response.RequestID)
+			return
+		}
+		if response.TotalSubBatches == 0 || response.TotalSubBatches == response.SubBatchSerial {
+			response.Keys = wsc.responseKeys(pending, response.Data, message)
+			response.Extra, _ = messages.ExtraFields(message)
+		}
+		if err := verifyBatch(response, len(response.Data), message); err != nil {
+			pending.fail(err)
+			return
+		}
+		wsc.notifyProgress(response, len(message))
+		pending.add(response)
 	}
 }
 
 // Function to extract keys from the "data" array
-func extractKeys(jsonData []byte) []string {
-	// Define a struct to hold the "data" array
-	var data struct {
-		Data []json.RawMessage `json:"data"`
-	}
+func extractKeys(codec Codec, jsonData []byte) []string {
+	// Reuse the struct holding the "data" array between frames
+	data := keysEnvelopePool.Get().(*keysEnvelope)
+	defer func() {
+		data.Data = data.Data[:0]
+		keysEnvelopePool.Put(data)
+	}()
 
 	// Unmarshal the JSON data into the struct
-	err := json.Unmarshal(jsonData, &data)
+	err := codec.Unmarshal(jsonData, data)
 	if err != nil {
 		log.Println("Error extracting keys from response data:", err)
 		return nil
@@ -263,7 +605,7 @@ func extractKeys(jsonData []byte) []string {
 	var firstEntry json.RawMessage
 
 	// Unmarshal the first entry to extract the keys
-	err = json.Unmarshal(data.Data[0], &firstEntry)
+	err = codec.Unmarshal(data.Data[0], &firstEntry)
 	if err != nil {
 		log.Println("Error extracting keys from response data:", err)
 		return nil
@@ -315,64 +657,91 @@ func parse(raw json.RawMessage) []string {
 	return keys
 }
 
+// GetResponseSync waits for the response to requestID and returns the
+// assembled sub-batches. Only failures of this request, or of the
+// connection, are reported.
 func (wsc *WSSClient) GetResponseSync(requestID string) (*messages.Response, error) {
-	var temp *messages.Response
-	timeout := time.After(constants.TimeOutWaintForResponse)
+	return wsc.GetResponseContext(context.Background(), requestID)
+}
+
+// GetResponseContext is like GetResponseSync but gives up when ctx is done,
+// in which case its buffered frames are released and the query is
+// cancelled on servers supporting it.
+func (wsc *WSSClient) GetResponseContext(ctx context.Context, requestID string) (*messages.Response, error) {
+	pending, ok := wsc.pending(requestID)
+	if !ok {
+		return &messages.Response{}, fmt.Errorf("unknown request ID %q", requestID)
+	}
+	defer wsc.release(requestID, pending)
+	if pending.columns != nil {
+		defer wsc.projections.Add(-1)
+	}
+	timeout, stop := wsc.after(wsc.responseTimeout)
+	defer stop()
+	var firstByte <-chan time.Time
+	if wsc.firstByteTimeout > 0 {
+		var stop func() bool
+		firstByte, stop = wsc.after(wsc.firstByteTimeout)
+		defer stop()
+	}
+	first := pending.first
 	for {
 		select {
-		case <-timeout:
-			return nil, errors.New("timeout occurred while waiting for response")
-		default:
-			if v, ok := wsc.resultsMap.Get("error"); ok {
-				if v != nil {
-					return &messages.Response{}, v.(error)
-				}
+		case <-pending.done:
+			return pending.result()
+		case <-first:
+			first, firstByte = nil, nil
+		case <-ctx.Done():
+			if response, ok := wsc.truncated(requestID, pending); ok {
+				return response, nil
 			}
-			if _, ok := wsc.resultsMap.Get(requestID); !ok {
-				continue
-			}
-			responses, _ := wsc.resultsMap.Get(requestID)
-			if v, ok := responses.(error); ok {
-				return &messages.Response{}, v
-			}
-			commonError, _ := wsc.resultsMap.Get("")
-			if v, ok := commonError.(error); ok {
-				wsc.resultsMap.Set("", nil)
-				return &messages.Response{}, v
-			}
-			if responses == nil {
-				continue
-			}
-			if v, ok := responses.(cmap.ConcurrentMap); ok {
-				if v.Count() > 0 {
-					if temp == nil {
-						for item := range v.IterBuffered() {
-							temp = item.Val.(*messages.Response)
-							break
-						}
-					}
-					if len(temp.Data) <= 0 {
-						return &messages.Response{}, fmt.Errorf("No response from server. Check SQL syntax")
-					} else if temp.TotalSubBatches == 0 || temp.TotalSubBatches == v.Count() {
-						var data []map[string]interface{}
-						for i := 0; i <= v.Count(); i++ {
-							v, _ := v.Get(string(rune(i)))
-							if v != nil {
-								data = append(data, v.(*messages.Response).Data...)
-							}
-						}
-						if v.Count() > 0 {
-							val, _ := v.Get(string(rune(v.Count())))
-							if val == nil {
-								val, _ = v.Get(string(rune(0)))
-							}
-							finalResponse := val.(*messages.Response)
-							finalResponse.Data = data
-							return finalResponse, nil
-						}
-					}
-				}
+			wsc.CancelRequest(requestID, ctx.Err())
+			return nil, ctx.Err()
+		case <-firstByte:
+			err := &TimeoutError{Phase: PhaseFirstByte, Budget: wsc.firstByteTimeout}
+			wsc.CancelRequest(requestID, err)
+			return nil, err
+		case <-timeout:
+			if response, ok := wsc.truncated(requestID, pending); ok {
+				return response, nil
 			}
+			return nil, &TimeoutError{Phase: PhaseTotal, Budget: wsc.responseTimeout}
 		}
 	}
 }
+
+// truncated returns the rows received so far, flagged Truncated, when
+// WithPartialOnTimeout is set and any have arrived. The query is cancelled
+// on the server.
+func (wsc *WSSClient) truncated(requestID string, pending *pendingRequest) (*messages.Response, bool) {
+	if !wsc.partialOnTimeout {
+		return nil, false
+	}
+	response := pending.partial()
+	if len(response.Data) == 0 {
+		return nil, false
+	}
+	wsc.CancelRequest(requestID, ErrResponseTimeout)
+	response.Truncated = true
+	return response, true
+}
+
+// Result is the outcome of a request delivered by GetResponseChan: the
+// assembled response or the error that failed it.
+type Result struct {
+	Response *messages.Response
+	Err      error
+}
+
+// GetResponseChan is a non-blocking GetResponseSync. The returned channel
+// receives a single Result and is then closed, so callers can select across
+// several outstanding requests.
+func (wsc *WSSClient) GetResponseChan(requestID string) <-chan Result {
+	results := make(chan Result, 1)
+	go func() {
+		defer close(results)
+		response, err := wsc.GetResponseSync(requestID)
+		results <- Result{Response: response, Err: err}
+	}()
+	return results
+}